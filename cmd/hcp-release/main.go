@@ -4,17 +4,20 @@ import (
 	"bufio"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
-	"time"
 
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/windgeek/HCP/pkg/aha"
 	"github.com/windgeek/HCP/pkg/config"
 	"github.com/windgeek/HCP/pkg/identity"
 	"github.com/windgeek/HCP/pkg/manifest"
+	"github.com/windgeek/HCP/pkg/zkp"
 	"golang.org/x/term"
 )
 
@@ -23,8 +26,23 @@ func main() {
 	targetPath := flag.String("path", ".", "Path to the directory to release")
 	keyPath := flag.String("key", "", "Path to identity key file")
 	dryRun := flag.Bool("dry-run", false, "Preview changes without writing to disk")
+	buildTags := flag.String("tags", "", "Comma-separated build tags for Go package analysis")
+	proofSystem := flag.String("proof-system", zkp.SystemID(zkp.DefaultSystem), "Cognitive proof backend (name@version)")
+	aggregateProofs := flag.Bool("aggregate", false, "Publish one release-level cognitive proof instead of one per file")
+	humanShare := flag.Int("human-share", 80, "Share of complexity (percent) in files asserted human claimed by -aggregate")
 	flag.Parse()
 
+	if *proofSystem == zkp.SystemID(zkp.MockSystem) {
+		// Only on request: mock proofs state their values and prove nothing.
+		fmt.Println("Warning: mock proofs prove nothing and verify as unproven")
+		zkp.RegisterSystem(zkp.MockSystem)
+	}
+	system, ok := zkp.LookupSystem(*proofSystem)
+	if !ok {
+		fmt.Printf("Unknown proof system %q (available: %s)\n", *proofSystem, strings.Join(zkp.Systems(), ", "))
+		os.Exit(1)
+	}
+
 	// Resolve absolute path for scanning
 	absPath, err := filepath.Abs(*targetPath)
 	if err != nil {
@@ -47,55 +65,7 @@ func main() {
 	// Add default ignores
 	ignorePatterns = append(ignorePatterns, ".git", ".hcp", "node_modules", ".DS_Store", "*.hcp")
 
-	// 3. Scan and Hash
-	globalHash, assets, contribMap, zkpMap, err := manifest.CalculateDirHash(absPath, ignorePatterns)
-	if err != nil {
-		fmt.Printf("Error calculating hash: %v\n", err)
-		os.Exit(1)
-	}
-	fmt.Printf("Total Assets: %d\n", len(assets))
-	
-	var totalScore float64
-	for _, m := range contribMap {
-		totalScore += m.AHAScore
-	}
-	avgScore := 0.0
-	if len(assets) > 0 {
-		avgScore = totalScore / float64(len(assets))
-	}
-	
-	fmt.Printf("Global Content Hash: %s\n", globalHash)
-	fmt.Printf("Average AHA Score: %.1f / 100\n", avgScore)
-
-	// 4. Determine Output Filename
-	defaultFilename := "manifest.hcp"
-	defaultOutputPath := filepath.Join(absPath, defaultFilename)
-	finalOutputPath := defaultOutputPath
-
-	if _, err := os.Stat(defaultOutputPath); err == nil {
-		// File exists, prompt for version
-		
-		// Check if interactive
-		stat, _ := os.Stdin.Stat()
-		isInteractive := (stat.Mode() & os.ModeCharDevice) != 0
-
-		if isInteractive {
-			fmt.Printf("\n'%s' already exists.\n", defaultFilename)
-			fmt.Print("Enter version tag to create a new file (e.g., 'v1.0'), or press Enter to overwrite: ")
-			reader := bufio.NewReader(os.Stdin)
-			input, _ := reader.ReadString('\n')
-			input = strings.TrimSpace(input)
-			if input != "" {
-				// Sanitize input
-				tag := strings.ReplaceAll(input, " ", "_")
-				finalOutputPath = filepath.Join(absPath, fmt.Sprintf("manifest-%s.hcp", tag))
-			}
-		} 
-		// If piped, we default to overwrite for automation, unless logic dictates otherwise. 
-		// For now, automation overwrites.
-	}
-
-	// 5. Load Identity
+	// 3. Load Identity (needed to scope AHA to signed commits)
 	cfg, err := config.LoadConfig(*keyPath)
 	if err != nil {
 		fmt.Printf("Error loading config: %v\n", err)
@@ -109,8 +79,8 @@ func main() {
 	}
 
 	var passphrase string
-	fmt.Print("\nEnter passphrase to sign release: ")
-	
+	fmt.Print("Enter passphrase to sign release: ")
+
 	// Check if simple stdin (piped) or terminal
 	stat, _ := os.Stdin.Stat()
 	if (stat.Mode() & os.ModeCharDevice) == 0 {
@@ -142,38 +112,198 @@ func main() {
 		fmt.Printf("Error deriving address: %v\n", err)
 		os.Exit(1)
 	}
-	
+
 	pubKeyHex := hex.EncodeToString(key.PubKey().SerializeCompressed())
 
-	// 6. Check for Parent Manifest (Evolutionary Chain)
+	// 4. Analyze History, counting only the configured authors
+	opts := aha.Options{Authors: cfg.AuthorEmails, Aliases: cfg.EmailAliases, MicroHistory: cfg.MicroHistory}
+	if cfg.SignedCommitsOnly {
+		opts.SigningKey = key.PubKey()
+	}
+	if cfg.VimUndo {
+		opts.VimUndo = true
+		opts.VimUndoDirs = cfg.VimUndoDirs
+		if len(opts.VimUndoDirs) == 0 {
+			opts.VimUndoDirs = aha.DefaultVimUndoDirs()
+		}
+		opts.Warn = func(path string, err error) {
+			fmt.Printf("Warning: Vim undo history of %s skipped: %v\n", path, err)
+		}
+	}
+	model, err := scoringModel(cfg)
+	if err != nil {
+		fmt.Printf("Error loading scoring model: %v\n", err)
+		os.Exit(1)
+	}
+	opts.Model = model
+	fmt.Printf("AHA Scoring Model: %s\n", model.Name())
+	history, err := aha.AnalyzeRepo(absPath, opts)
+	if errors.Is(err, aha.ErrNotRepository) {
+		fmt.Println("AHA History Analysis skipped: not a git repository")
+	} else if err != nil {
+		// A shallow or damaged history would release understated scores.
+		fmt.Printf("Error analyzing history: %v\n", err)
+		os.Exit(1)
+	}
+	if len(opts.Authors) > 0 {
+		fmt.Printf("AHA Authors: %s\n", strings.Join(opts.Authors, ", "))
+	}
+	if opts.SigningKey != nil {
+		fmt.Println("AHA Commits: HCP-signed only")
+	}
+	if opts.MicroHistory {
+		micro := 0
+		for _, m := range history {
+			micro += m.MicroRevisions
+		}
+		fmt.Printf("Micro-History: %d revisions outside the final history\n", micro)
+	}
+	if opts.VimUndo {
+		files, changes := 0, 0
+		for _, m := range history {
+			if m.VimUndo != nil {
+				files++
+				changes += m.VimUndo.Changes
+			}
+		}
+		fmt.Printf("Vim Undo History: %d files, %d undo states\n", files, changes)
+	}
+
+	// 5. Scan and Hash
+	globalHash, assets, contribMap, err := manifest.CalculateDirHashWithHistory(absPath, ignorePatterns, history)
+	if err != nil {
+		fmt.Printf("Error calculating hash: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Total Assets: %d\n", len(assets))
+
+	var totalScore, totalShare float64
+	for _, m := range contribMap {
+		totalScore += m.AHAScore
+		totalShare += m.IteratedShare
+	}
+	avgScore := 0.0
+	if len(assets) > 0 {
+		avgScore = totalScore / float64(len(assets))
+	}
+
+	var tags []string
+	if *buildTags != "" {
+		tags = strings.Split(*buildTags, ",")
+	}
+	packages, err := manifest.CalculatePackageHashes(absPath, ignorePatterns, tags)
+	if err != nil {
+		// Not every release is a Go module; file-level hashes still apply.
+		fmt.Printf("Go Package Analysis skipped: %v\n", err)
+	} else if len(packages) > 0 {
+		fmt.Printf("Go Packages: %d\n", len(packages))
+	}
+
+	fmt.Printf("Global Content Hash: %s\n", globalHash)
+	fmt.Printf("Average AHA Score: %.1f / 100\n", avgScore)
+	if len(assets) > 0 {
+		fmt.Printf("Average Iterated Share: %.1f%% of lines\n", 100*totalShare/float64(len(assets)))
+	}
+	complexity := manifest.SummarizeComplexity(absPath, assets)
+	if complexity.Total.Files > 0 {
+		fmt.Printf("Cognitive Complexity: %d across %d source files\n", complexity.Total.Cognitive, complexity.Total.Files)
+		fmt.Printf("Maintainability Index: %.1f / 100\n", complexity.Total.Maintainability)
+	}
+	// SOURCE_DATE_EPOCH pins the timestamps so that rebuilding a commit
+	// reproduces the manifest byte for byte.
+	releaseTime, err := manifest.ReleaseTime()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	zkpMap, proofErrs := manifest.GenerateProofs(absPath, assets, contribMap, system, key, releaseTime)
+	unproven := make([]string, 0, len(proofErrs))
+	for path := range proofErrs {
+		unproven = append(unproven, path)
+	}
+	sort.Strings(unproven)
+	for _, path := range unproven {
+		fmt.Printf("Warning: no cognitive proof for %s: %v\n", path, proofErrs[path])
+	}
+	human := 0
+	for _, p := range zkpMap {
+		if p.Claim() == zkp.ClaimHumanRatio {
+			human++
+		}
+	}
+	fmt.Printf("Cognitive Proofs: %d files, %d with a human ratio (%s)\n", len(zkpMap), human, zkp.SystemID(system))
+	var aggregate *zkp.AggregateProof
+	if *aggregateProofs {
+		aggregate, err = zkp.GenerateAggregate(zkpMap, *humanShare, globalHash, key)
+		if err != nil {
+			fmt.Printf("Error aggregating cognitive proofs: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Aggregate Proof: %s (%d files)\n", aggregate.Claim, aggregate.Files)
+	}
+	printContributors(contribMap)
+	printPasteFlags(contribMap)
+
+	// 6. Determine Output Filename
+	defaultFilename := "manifest.hcp"
+	defaultOutputPath := filepath.Join(absPath, defaultFilename)
+	finalOutputPath := defaultOutputPath
+
+	if _, err := os.Stat(defaultOutputPath); err == nil {
+		// File exists, prompt for version
+
+		// Check if interactive
+		stat, _ := os.Stdin.Stat()
+		isInteractive := (stat.Mode() & os.ModeCharDevice) != 0
+
+		if isInteractive {
+			fmt.Printf("\n'%s' already exists.\n", defaultFilename)
+			fmt.Print("Enter version tag to create a new file (e.g., 'v1.0'), or press Enter to overwrite: ")
+			reader := bufio.NewReader(os.Stdin)
+			input, _ := reader.ReadString('\n')
+			input = strings.TrimSpace(input)
+			if input != "" {
+				// Sanitize input
+				tag := strings.ReplaceAll(input, " ", "_")
+				finalOutputPath = filepath.Join(absPath, fmt.Sprintf("manifest-%s.hcp", tag))
+			}
+		}
+		// If piped, we default to overwrite for automation, unless logic dictates otherwise.
+		// For now, automation overwrites.
+	}
+
+	// 7. Check for Parent Manifest (Evolutionary Chain)
+	// manifest.hcp is the current state. It is archived under its hash
+	// before being superseded so that `hcp trend` can still resolve it.
 	var parentHash string
-	if _, err := os.Stat(finalOutputPath); err == nil {
-		// Existing manifest found (before overwrite)
-		// Wait, finalOutputPath is where we WRITE. If we are versioning, we should check previous latest?
-		// User said: "look for an existing manifest.hcp, take its hash".
-		// We should check 'manifest.hcp' specifically or the one we are about to overwrite/supersede.
-		// Let's check 'manifest.hcp' as the "current state".
-		if data, err := os.ReadFile("manifest.hcp"); err == nil {
-			// Hash it
-			h := sha256.New()
-			h.Write(data)
-			parentHash = hex.EncodeToString(h.Sum(nil))
-			fmt.Printf("Linking to Parent Manifest: %s...\n", parentHash[:8])
-		}
-	}
-
-	// 7. Create Manifest
+	if data, err := os.ReadFile(defaultOutputPath); err == nil {
+		h := sha256.Sum256(data)
+		parentHash = hex.EncodeToString(h[:])
+		fmt.Printf("Linking to Parent Manifest: %s...\n", parentHash[:8])
+	}
+
+	// 8. Create Manifest
+	spec := model.Spec()
 	m := manifest.Manifest{
-		Version:     "v1-release",
-		Author:      authAddr,
-		PublicKey:   pubKeyHex,
-		ContentHash: globalHash,
-		ParentHash:  parentHash,
-		Timestamp:   time.Now().Unix(),
+		Version:         "v1-release",
+		Author:          authAddr,
+		PublicKey:       pubKeyHex,
+		ContentHash:     globalHash,
+		ParentHash:      parentHash,
+		Timestamp:       releaseTime,
 		EntropyDNA:      "universal-release",
 		Assets:          assets,
 		ContributionMap: contribMap,
+		AHAScoreVersion: model.Name(),
+		ScoringModel:    &spec,
 		CognitiveProofs: zkpMap,
+		Packages:        packages,
+		BuildTags:       tags,
+		Complexity:      complexity,
+	}
+	if aggregate != nil {
+		// The file proofs move to the private sidecar.
+		m.CognitiveProofs, m.AggregateProof = nil, aggregate
 	}
 
 	// 7. Sign & Save
@@ -194,10 +324,32 @@ func main() {
 	}
 
 	// 8. Save
+	if parentHash != "" {
+		if _, err := manifest.Archive(defaultOutputPath, absPath); err != nil {
+			fmt.Printf("Error archiving parent manifest: %v\n", err)
+			os.Exit(1)
+		}
+	}
 	if err := m.Save(finalOutputPath); err != nil {
 		fmt.Printf("Error saving manifest: %v\n", err)
 		os.Exit(1)
 	}
+	manifestHash, err := manifest.Archive(finalOutputPath, absPath)
+	if err != nil {
+		fmt.Printf("Error archiving manifest: %v\n", err)
+		os.Exit(1)
+	}
+	openingsPath, err := manifest.SaveOpenings(manifestHash, zkpMap)
+	if err != nil {
+		fmt.Printf("Error saving proof openings: %v\n", err)
+		os.Exit(1)
+	}
+	if aggregate != nil {
+		if _, err := manifest.SaveAggregateOpening(manifestHash, aggregate.Opening); err != nil {
+			fmt.Printf("Error saving aggregate opening: %v\n", err)
+			os.Exit(1)
+		}
+	}
 	// 9. Format Output Path for Display
 	cwd, _ = os.Getwd()
 	displayPath := finalOutputPath
@@ -207,6 +359,98 @@ func main() {
 		}
 	}
 	fmt.Printf("\nRelease Manifest generated: %s\n", displayPath)
+	fmt.Printf("Proof Openings (keep private): %s\n", openingsPath)
+}
+
+// printContributors summarizes the per-contributor breakdown of the
+// contribution map. Contributors excluded from the AHA score are marked.
+func printContributors(contribMap map[string]aha.AHAMetrics) {
+	totals := make(map[string]*aha.Contribution)
+	for _, m := range contribMap {
+		for who, c := range m.Contributors {
+			t, ok := totals[who]
+			if !ok {
+				t = &aha.Contribution{}
+				totals[who] = t
+			}
+			t.Commits += c.Commits
+			t.LinesAdded += c.LinesAdded
+			t.Counted = t.Counted || c.Counted
+		}
+	}
+	if len(totals) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(totals))
+	for who := range totals {
+		names = append(names, who)
+	}
+	sort.Strings(names)
+
+	fmt.Println("Contributors:")
+	for _, who := range names {
+		t := totals[who]
+		note := ""
+		if !t.Counted {
+			note = " (not counted)"
+		}
+		fmt.Printf("  %s: %d file revisions, +%d lines%s\n", who, t.Commits, t.LinesAdded, note)
+	}
+}
+
+// printPasteFlags reports commits whose cognitive velocity is outside the
+// human range. Suspected pastes are listed; cognitive pauses only counted.
+func printPasteFlags(contribMap map[string]aha.AHAMetrics) {
+	paths := make([]string, 0, len(contribMap))
+	for p := range contribMap {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var pastes []string
+	pauses := 0
+	for _, p := range paths {
+		for _, f := range contribMap[p].PasteFlags {
+			if f.Kind == aha.FlagPause {
+				pauses++
+				continue
+			}
+			pastes = append(pastes, fmt.Sprintf("  [PASTE] %s @ %.8s: +%d complexity in %.1f min (%.1f/min)",
+				p, f.Commit, f.ComplexityAdded, f.ElapsedMinutes, f.Velocity))
+		}
+	}
+	if len(pastes) == 0 && pauses == 0 {
+		return
+	}
+
+	fmt.Printf("Velocity Flags: %d suspected AI pastes, %d cognitive pauses (human range %.1f-%.0f complexity/min)\n",
+		len(pastes), pauses, aha.MinHumanVelocity, aha.MaxHumanVelocity)
+	for _, line := range pastes {
+		fmt.Println(line)
+	}
+}
+
+// scoringModel resolves cfg.ScoringModel: a model defined in
+// cfg.ScoringModels, else a built-in one. Empty selects the default.
+func scoringModel(cfg *config.Config) (aha.ScoringModel, error) {
+	for name := range cfg.ScoringModels {
+		if _, ok := aha.BuiltinModel(name); ok {
+			return nil, fmt.Errorf("scoring model %s shadows a built-in model", name)
+		}
+	}
+	if cfg.ScoringModel == "" {
+		return aha.DefaultModel, nil
+	}
+	features, ok := cfg.ScoringModels[cfg.ScoringModel]
+	if !ok {
+		return aha.ModelFromSpec(aha.ModelSpec{Name: cfg.ScoringModel})
+	}
+	spec := aha.ModelSpec{Name: cfg.ScoringModel}
+	for _, f := range features {
+		spec.Features = append(spec.Features, aha.Feature{Metric: f.Metric, Weight: f.Weight, Cap: f.Cap})
+	}
+	return aha.NewWeightedModel(spec.Name, spec.Features)
 }
 
 func loadIgnorePatterns(root string) []string {
@@ -224,4 +468,3 @@ func loadIgnorePatterns(root string) []string {
 	}
 	return patterns
 }
-
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/windgeek/HCP/pkg/aha"
+)
+
+var blameCmd = &cobra.Command{
+	Use:   "blame <file>",
+	Short: "Attribute each line of a file to the commits that wrote it",
+	Long: `Attribute every surviving line of a file to the commit that wrote its current text
+and classify it as iterated (touched in multiple commits, marked I) or single-shot (S).
+Uncommitted lines are attributed to the working tree.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		filePath, err := filepath.Abs(args[0])
+		if err != nil {
+			fmt.Printf("Error resolving path: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 1. Locate Repository
+		root, err := aha.FindRepoRoot(filePath)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 2. Attribute Lines
+		blame, err := aha.BlameFile(filePath, root)
+		if err != nil {
+			fmt.Printf("Error attributing lines: %v\n", err)
+			os.Exit(1)
+		}
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			out, _ := json.MarshalIndent(blame, "", "  ")
+			fmt.Println(string(out))
+			return
+		}
+
+		// 3. Print
+		for _, l := range blame.Lines {
+			commit, author := "working ", "(uncommitted)"
+			if l.Commit != "" {
+				commit, author = l.Commit[:8], l.Author
+			}
+			kind := "S"
+			if l.Iterated() {
+				kind = "I"
+			}
+			fmt.Printf("%s %-24.24s %2dx %s %5d| %s\n", commit, author, l.Revisions, kind, l.Number, l.Text)
+		}
+		fmt.Printf("\nIterated Share: %.1f%% of non-blank lines\n", blame.IteratedShare*100)
+	},
+}
+
+func init() {
+	blameCmd.Flags().Bool("json", false, "Print the attribution as JSON")
+	rootCmd.AddCommand(blameCmd)
+}
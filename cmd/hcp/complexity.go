@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/windgeek/HCP/pkg/cognitive"
+	"github.com/windgeek/HCP/pkg/manifest"
+)
+
+// functionRow is one function of the report, with the file it lives in.
+type functionRow struct {
+	Path     string `json:"path"`
+	Analyzer string `json:"analyzer"`
+	cognitive.FunctionStats
+}
+
+// functionOrders sorts rows by a column, highest first (names A-Z).
+var functionOrders = map[string]func(a, b functionRow) bool{
+	"cognitive":  func(a, b functionRow) bool { return a.Cognitive > b.Cognitive },
+	"cyclomatic": func(a, b functionRow) bool { return a.Cyclomatic > b.Cyclomatic },
+	"nesting":    func(a, b functionRow) bool { return a.MaxNesting > b.MaxNesting },
+	"params":     func(a, b functionRow) bool { return a.Params > b.Params },
+	"lines":      func(a, b functionRow) bool { return a.Lines > b.Lines },
+	"name":       func(a, b functionRow) bool { return a.Name < b.Name },
+}
+
+var complexityCmd = &cobra.Command{
+	Use:   "complexity <path>",
+	Short: "Report the complexity of each function in a file or tree",
+	Long: `Measure every function of the source files under a path (Go, C-family languages
+such as C, Java, JavaScript/TypeScript and Rust, and Python): cyclomatic complexity,
+cognitive complexity (SonarSource, with nesting increments), maximum nesting depth,
+parameter count and lines. Sort with --sort cognitive|cyclomatic|nesting|params|lines|name.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		order, _ := cmd.Flags().GetString("sort")
+		less, ok := functionOrders[order]
+		if !ok {
+			fmt.Printf("Unknown sort column %q\n", order)
+			os.Exit(1)
+		}
+
+		// 1. Collect Files
+		root := args[0]
+		info, err := os.Stat(root)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		var files []string
+		if info.IsDir() {
+			ignorePatterns := []string{".git", ".hcp", "node_modules", "vendor", "testdata"}
+			err = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				rel, _ := filepath.Rel(root, path)
+				if rel != "." && (manifest.ShouldIgnore(rel, ignorePatterns) || strings.HasPrefix(d.Name(), ".")) {
+					if d.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if !d.IsDir() && cognitive.LookupAnalyzer(path).Name() != "generic" {
+					files = append(files, path)
+				}
+				return nil
+			})
+			if err != nil {
+				fmt.Printf("Error walking %s: %v\n", root, err)
+				os.Exit(1)
+			}
+		} else {
+			files = []string{root}
+		}
+
+		// 2. Measure Functions
+		var rows []functionRow
+		for _, file := range files {
+			stats, err := cognitive.AnalyzeComplexity(file)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Skipping %s: %v\n", file, err)
+				continue
+			}
+			for _, f := range stats.PerFunction {
+				rows = append(rows, functionRow{Path: filepath.ToSlash(file), Analyzer: stats.Analyzer, FunctionStats: f})
+			}
+		}
+		sort.SliceStable(rows, func(i, j int) bool { return less(rows[i], rows[j]) })
+		if top, _ := cmd.Flags().GetInt("top"); top > 0 && top < len(rows) {
+			rows = rows[:top]
+		}
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			out, _ := json.MarshalIndent(rows, "", "  ")
+			fmt.Println(string(out))
+			return
+		}
+
+		// 3. Print
+		fmt.Printf("%9s %5s %7s %6s %5s  %s\n", "COGNITIVE", "CYCLO", "NESTING", "PARAMS", "LINES", "FUNCTION")
+		total := 0
+		for _, r := range rows {
+			fmt.Printf("%9d %5d %7d %6d %5d  %s:%d %s\n", r.Cognitive, r.Cyclomatic, r.MaxNesting, r.Params, r.Lines, r.Path, r.Line, r.Name)
+			total += r.Cognitive
+		}
+		fmt.Printf("\n%d functions, total cognitive complexity %d\n", len(rows), total)
+	},
+}
+
+func init() {
+	complexityCmd.Flags().String("sort", "cognitive", "Column to sort by: cognitive, cyclomatic, nesting, params, lines or name")
+	complexityCmd.Flags().Int("top", 0, "Only print the first N functions (0 prints all)")
+	complexityCmd.Flags().Bool("json", false, "Print the functions as JSON")
+	rootCmd.AddCommand(complexityCmd)
+}
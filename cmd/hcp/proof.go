@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/windgeek/HCP/pkg/manifest"
+	"github.com/windgeek/HCP/pkg/zkp"
+)
+
+var proofCmd = &cobra.Command{
+	Use:   "proof",
+	Short: "Open the cognitive proofs of a release to an auditor",
+	Long: `Cognitive proofs commit to each file's commits, complexity, ratio and Halstead measures
+with Pedersen commitments on secp256k1. hcp-release keeps the blinding factors in a private
+sidecar (~/.hcp/openings/<manifest sha256>.json); 'hcp proof open' reveals chosen values and
+'hcp proof check' lets an auditor check them against the public commitments.`,
+}
+
+var proofOpenCmd = &cobra.Command{
+	Use:   "open <file> [value...]",
+	Short: "Reveal committed values of a file's proof",
+	Long: `Print a disclosure of the named values (default: all) of the proof of a file, as JSON
+for 'hcp proof check'. Values: commits, complexity, ratio (in thousandths),
+halstead_volume and halstead_effort.
+
+For a release with an aggregate proof, the disclosure reveals the file's proof and its
+path to the aggregate, plus the named values (default: none).`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		manifestPath, _ := cmd.Flags().GetString("manifest")
+
+		// 1. Locate the Private Sidecar
+		m, err := manifest.Load(manifestPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading manifest: %v\n", err)
+			os.Exit(1)
+		}
+		sum, err := manifest.FileHash(manifestPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading manifest: %v\n", err)
+			os.Exit(1)
+		}
+		openingsPath, _ := cmd.Flags().GetString("openings")
+		if openingsPath == "" {
+			if openingsPath, err = manifest.OpeningsPath(sum); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		openings, err := manifest.LoadOpenings(openingsPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading openings: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 2. Disclose
+		path := filepath.ToSlash(filepath.Clean(args[0]))
+		opening, ok := openings[path]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "No proof opening for %s\n", path)
+			os.Exit(1)
+		}
+		names := args[1:]
+		if len(names) == 0 && m.AggregateProof == nil {
+			for name := range opening.Values {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+		}
+		var disclosures []*zkp.Disclosure
+		for _, name := range names {
+			d, err := opening.Disclose(path, name)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			disclosures = append(disclosures, d)
+		}
+		if m.AggregateProof == nil {
+			out, _ := json.MarshalIndent(disclosures, "", "  ")
+			fmt.Println(string(out))
+			return
+		}
+
+		// 3. Disclose the File From the Aggregate
+		aggregatePath, err := manifest.AggregatePath(sum)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		aggregate, err := manifest.LoadAggregateOpening(aggregatePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading aggregate opening: %v\n", err)
+			os.Exit(1)
+		}
+		fd, err := aggregate.Disclose(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fd.Values = disclosures
+		out, _ := json.MarshalIndent(fd, "", "  ")
+		fmt.Println(string(out))
+	},
+}
+
+var proofCheckCmd = &cobra.Command{
+	Use:   "check <disclosure.json>",
+	Short: "Check disclosed values against a manifest's commitments",
+	Long: `Check every value of a disclosure made by 'hcp proof open' against the commitments in
+the manifest. Run 'hcp verify' to check the manifest's signature itself.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		manifestPath, _ := cmd.Flags().GetString("manifest")
+		m, err := manifest.Load(manifestPath)
+		if err != nil {
+			fmt.Printf("Error reading manifest: %v\n", err)
+			os.Exit(1)
+		}
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			fmt.Printf("Error reading disclosure: %v\n", err)
+			os.Exit(1)
+		}
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+			checkFileDisclosure(m, data)
+			return
+		}
+		var disclosures []*zkp.Disclosure
+		if err := json.Unmarshal(data, &disclosures); err != nil {
+			fmt.Printf("Error parsing disclosure: %v\n", err)
+			os.Exit(1)
+		}
+
+		failed := false
+		for _, d := range disclosures {
+			proof, ok := m.CognitiveProofs[d.Path]
+			if !ok {
+				fmt.Printf("[FAIL] %s: no cognitive proof in the manifest\n", d.Path)
+				failed = true
+				continue
+			}
+			if err := d.Check(&proof); err != nil {
+				fmt.Printf("[FAIL] %s %s = %d: %v\n", d.Path, d.Name, d.Value, err)
+				failed = true
+				continue
+			}
+			fmt.Printf("[PASS] %s %s = %d\n", d.Path, d.Name, d.Value)
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// checkFileDisclosure checks a file disclosed from the aggregate proof of m.
+func checkFileDisclosure(m *manifest.Manifest, data []byte) {
+	var d zkp.FileDisclosure
+	if err := json.Unmarshal(data, &d); err != nil {
+		fmt.Printf("Error parsing disclosure: %v\n", err)
+		os.Exit(1)
+	}
+	if err := m.CheckDisclosure(&d); err != nil {
+		fmt.Printf("[FAIL] %s: %v\n", d.Path, err)
+		os.Exit(1)
+	}
+	fmt.Printf("[PASS] %s is part of the aggregate proof (claim: %s)\n", d.Path, d.Proof.Claim())
+	for _, v := range d.Values {
+		fmt.Printf("[PASS] %s %s = %d\n", v.Path, v.Name, v.Value)
+	}
+}
+
+func init() {
+	proofCmd.PersistentFlags().String("manifest", "manifest.hcp", "Release manifest holding the proofs")
+	proofOpenCmd.Flags().String("openings", "", "Openings sidecar (default: ~/.hcp/openings/<manifest sha256>.json)")
+	proofCmd.AddCommand(proofOpenCmd, proofCheckCmd)
+	rootCmd.AddCommand(proofCmd)
+}
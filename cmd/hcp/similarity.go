@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/windgeek/HCP/pkg/config"
+	"github.com/windgeek/HCP/pkg/hash"
+	"github.com/windgeek/HCP/pkg/manifest"
+)
+
+var similarityCmd = &cobra.Command{
+	Use:   "similarity <file> <manifest>",
+	Short: "Score how similar a text file is to its signed version",
+	Long: `Compare a text file against the similarity digest recorded in a release manifest
+and report a 0-100 similarity score. Useful for prose that has been lightly edited.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		filePath, manifestPath := args[0], args[1]
+
+		// 1. Read Manifest
+		data, err := os.ReadFile(manifestPath)
+		if err != nil {
+			fmt.Printf("Error reading manifest: %v\n", err)
+			os.Exit(1)
+		}
+
+		var m manifest.Manifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			fmt.Printf("Error parsing manifest: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 2. Locate the asset (paths are relative to the manifest directory)
+		absFile, err := filepath.Abs(filePath)
+		if err != nil {
+			fmt.Printf("Error resolving path: %v\n", err)
+			os.Exit(1)
+		}
+		absManifest, err := filepath.Abs(manifestPath)
+		if err != nil {
+			fmt.Printf("Error resolving path: %v\n", err)
+			os.Exit(1)
+		}
+		relPath, err := filepath.Rel(filepath.Dir(absManifest), absFile)
+		if err != nil {
+			fmt.Printf("Error resolving path: %v\n", err)
+			os.Exit(1)
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		var recorded *manifest.Asset
+		for i := range m.Assets {
+			if m.Assets[i].Path == relPath {
+				recorded = &m.Assets[i]
+				break
+			}
+		}
+		if recorded == nil {
+			fmt.Printf("Error: %s is not an asset of %s\n", relPath, manifestPath)
+			os.Exit(1)
+		}
+		if recorded.SimDigest == "" {
+			fmt.Printf("Error: no similarity digest recorded for %s\n", relPath)
+			os.Exit(1)
+		}
+
+		// 3. Score
+		current, err := hash.ComputeSimilarityDigest(filePath)
+		if err != nil {
+			fmt.Printf("Error computing similarity digest: %v\n", err)
+			os.Exit(1)
+		}
+		score, err := hash.Similarity(recorded.SimDigest, current)
+		if err != nil {
+			fmt.Printf("Error comparing digests: %v\n", err)
+			os.Exit(1)
+		}
+
+		threshold, _ := cmd.Flags().GetInt("threshold")
+		if !cmd.Flags().Changed("threshold") {
+			if cfg, err := config.LoadConfig(""); err == nil && cfg.SimilarityThreshold != nil {
+				threshold = *cfg.SimilarityThreshold
+			}
+		}
+		if err := (manifest.CompareOptions{SimilarityThreshold: &threshold}).Validate(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Similarity: %d / 100\n", score)
+		if score >= threshold {
+			fmt.Printf("[SUCCESS] Content Substantially Preserved (threshold %d).\n", threshold)
+			return
+		}
+		fmt.Printf("[WARNING] Content Changed (threshold %d).\n", threshold)
+		os.Exit(1)
+	},
+}
+
+func init() {
+	similarityCmd.Flags().Int("threshold", hash.DefaultSimilarityThreshold, "Min score (0-100) for content to count as substantially preserved")
+	rootCmd.AddCommand(similarityCmd)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/windgeek/HCP/pkg/aha"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Record working-tree changes as iteration evidence",
+	Long: `Record which files changed in the working tree since the last snapshot into
+.hcp/history/. With micro_history enabled, hcp-release counts snapshots as
+revisions, so iteration survives squash merges. Run it from an editor hook,
+or periodically with --every.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// 1. Locate Repository
+		root, err := aha.FindRepoRoot(".")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 2. Snapshot, once or periodically
+		every, _ := cmd.Flags().GetDuration("every")
+		for {
+			snap, err := aha.TakeSnapshot(root)
+			if err != nil {
+				fmt.Printf("Error taking snapshot: %v\n", err)
+				os.Exit(1)
+			}
+			stamp := time.Now().Format("15:04:05")
+			if snap == nil {
+				fmt.Printf("[%s] No changes since the last snapshot.\n", stamp)
+			} else {
+				fmt.Printf("[%s] Snapshot recorded: %d files changed\n", stamp, len(snap.Files))
+			}
+			if every <= 0 {
+				return
+			}
+			time.Sleep(every)
+		}
+	},
+}
+
+func init() {
+	snapshotCmd.Flags().Duration("every", 0, "Keep running and take a snapshot at this interval (e.g. 5m)")
+	rootCmd.AddCommand(snapshotCmd)
+}
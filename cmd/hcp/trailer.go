@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/windgeek/HCP/pkg/config"
+	"github.com/windgeek/HCP/pkg/identity"
+)
+
+var trailerCmd = &cobra.Command{
+	Use:   "trailer",
+	Short: "Print an HCP-Signature commit trailer for the staged changes",
+	Long: `Sign the next commit with your identity and print the signature as a commit trailer.
+The signature covers the tree of the git index, the parents (HEAD, and MERGE_HEAD
+during a merge) and the author git will record. Commits carrying the trailer count
+towards AHA when signed_commits_only is set:
+
+  git commit --trailer "$(hcp trailer)"
+
+Amending, rebasing or changing the author invalidates the trailer. Prompts are
+written to stderr so the output can be captured.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// 1. Resolve the staged commit
+		payload, err := stagedCommit()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 2. Load Identity
+		keyPath, _ := cmd.Flags().GetString("key")
+		cfg, err := config.LoadConfig(keyPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+			os.Exit(1)
+		}
+		if _, err := os.Stat(cfg.IdentityKeyPath); os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Identity not found at %s. Please run 'hcp keygen' or check config.\n", cfg.IdentityKeyPath)
+			os.Exit(1)
+		}
+
+		fmt.Fprint(os.Stderr, "Enter passphrase: ")
+		var passphrase string
+		fmt.Scanln(&passphrase)
+
+		key, err := identity.LoadKey(cfg.IdentityKeyPath, passphrase)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading key: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 3. Sign
+		fmt.Printf("%s: %s\n", identity.CommitTrailer, identity.SignCommit(key, payload))
+	},
+}
+
+// stagedCommit returns the payload of the commit `git commit` would make
+// from the index.
+func stagedCommit() (identity.CommitPayload, error) {
+	var p identity.CommitPayload
+	out, err := exec.Command("git", "write-tree").Output()
+	if err != nil {
+		return p, fmt.Errorf("failed to write index tree: %w", err)
+	}
+	p.Tree = strings.TrimSpace(string(out))
+
+	// A root commit has no HEAD, and only a merge has a MERGE_HEAD.
+	for _, ref := range []string{"HEAD", "MERGE_HEAD"} {
+		if out, err := exec.Command("git", "rev-parse", "-q", "--verify", ref).Output(); err == nil {
+			p.Parents = append(p.Parents, strings.TrimSpace(string(out)))
+		}
+	}
+
+	// "Name <email> timestamp zone"
+	out, err = exec.Command("git", "var", "GIT_AUTHOR_IDENT").Output()
+	if err != nil {
+		return p, fmt.Errorf("failed to resolve the author: %w", err)
+	}
+	ident := strings.TrimSpace(string(out))
+	if i := strings.LastIndex(ident, ">"); i >= 0 {
+		ident = ident[:i+1]
+	}
+	p.Author = ident
+	return p, nil
+}
+
+func init() {
+	trailerCmd.Flags().String("key", "", "Path to identity key file")
+	rootCmd.AddCommand(trailerCmd)
+}
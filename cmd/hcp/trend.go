@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/windgeek/HCP/pkg/cognitive"
+	"github.com/windgeek/HCP/pkg/manifest"
+)
+
+// trendRow is one release of the provenance chain.
+type trendRow struct {
+	Hash       string             `json:"hash"`
+	Path       string             `json:"path"`
+	Timestamp  int64              `json:"timestamp"`
+	Files      int                `json:"files"`
+	AHAScore   float64            `json:"aha_score"`            // Mean over the contribution map
+	Complexity *cognitive.Summary `json:"complexity,omitempty"` // Absent before complexity summaries were recorded
+}
+
+var trendCmd = &cobra.Command{
+	Use:   "trend [manifest]",
+	Short: "Chart complexity and AHA scores across the release chain",
+	Long: `Follow the ParentHash links from a manifest (default: manifest.hcp) back to the
+first release and print, oldest first, the average AHA score and the complexity summary
+recorded in each manifest: cognitive and cyclomatic complexity and the maintainability
+index. Old releases are not re-analyzed; parents are found among the .hcp files next to
+the manifest and in .hcp/manifests, where hcp-release archives every manifest.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := "manifest.hcp"
+		if len(args) > 0 {
+			path = args[0]
+		}
+
+		// 1. Walk the Chain
+		links, err := manifest.Chain(path)
+		if len(links) == 0 {
+			fmt.Printf("Error reading manifest: %v\n", err)
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] Chain incomplete: %v\n", err)
+		}
+
+		// 2. Collect Releases, Oldest First
+		rows := make([]trendRow, len(links))
+		for i, link := range links {
+			m := link.Manifest
+			row := trendRow{Hash: link.Hash, Path: link.Path, Timestamp: m.Timestamp, Files: len(m.Assets)}
+			if len(m.ContributionMap) > 0 {
+				var total float64
+				for _, metrics := range m.ContributionMap {
+					total += metrics.AHAScore
+				}
+				row.AHAScore = math.Round(total/float64(len(m.ContributionMap))*10) / 10
+			}
+			if m.Complexity != nil {
+				row.Complexity = &m.Complexity.Total
+			}
+			rows[len(links)-1-i] = row
+		}
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			out, _ := json.MarshalIndent(rows, "", "  ")
+			fmt.Println(string(out))
+			return
+		}
+
+		// 3. Print
+		fmt.Printf("%-16s %-8s %5s %5s %9s %6s %6s\n", "RELEASE", "MANIFEST", "FILES", "AHA", "COGNITIVE", "CYCLO", "MI")
+		aha, cog, mi := make([]float64, len(rows)), make([]float64, len(rows)), make([]float64, len(rows))
+		for i, r := range rows {
+			when := time.Unix(r.Timestamp, 0).UTC().Format("2006-01-02 15:04")
+			aha[i] = r.AHAScore
+			if r.Complexity == nil {
+				cog[i], mi[i] = math.NaN(), math.NaN()
+				fmt.Printf("%-16s %-8s %5d %5.1f %9s %6s %6s\n", when, r.Hash[:8], r.Files, r.AHAScore, "-", "-", "-")
+				continue
+			}
+			c := r.Complexity
+			cog[i], mi[i] = float64(c.Cognitive), c.Maintainability
+			fmt.Printf("%-16s %-8s %5d %5.1f %9d %6d %6.1f\n", when, r.Hash[:8], r.Files, r.AHAScore, c.Cognitive, c.Cyclomatic, c.Maintainability)
+		}
+		if len(rows) > 1 {
+			fmt.Println()
+			fmt.Printf("AHA Score         %s\n", sparkline(aha))
+			fmt.Printf("Cognitive         %s\n", sparkline(cog))
+			fmt.Printf("Maintainability   %s\n", sparkline(mi))
+		}
+		if errors.Is(err, manifest.ErrParentNotFound) {
+			fmt.Printf("\n%d releases (older releases not found)\n", len(rows))
+		} else {
+			fmt.Printf("\n%d releases\n", len(rows))
+		}
+	},
+}
+
+// sparkline charts values from lowest to highest; NaN values are blank.
+func sparkline(values []float64) string {
+	const bars = "▁▂▃▄▅▆▇█"
+	levels := []rune(bars)
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+		}
+	}
+	out := make([]rune, len(values))
+	for i, v := range values {
+		switch {
+		case math.IsNaN(v):
+			out[i] = ' '
+		case hi == lo:
+			out[i] = levels[len(levels)/2]
+		default:
+			out[i] = levels[int((v-lo)/(hi-lo)*float64(len(levels)-1)+0.5)]
+		}
+	}
+	return string(out)
+}
+
+func init() {
+	trendCmd.Flags().Bool("json", false, "Print the releases as JSON")
+	rootCmd.AddCommand(trendCmd)
+}
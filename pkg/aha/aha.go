@@ -2,57 +2,165 @@ package aha
 
 import (
 	"fmt"
-	"os/exec"
+	"math"
+	"os"
 	"path/filepath"
-	"strings"
+
+	"github.com/windgeek/HCP/pkg/cognitive"
 )
 
+// ScoreVersion identifies the formula behind AHAMetrics.AHAScore.
+// It is recorded in manifests so verifiers know how a score was derived.
+//
+//	aha-v1: min(commits * 10, 100)
+//	aha-v2: churn based
+//	aha-v3: churn and refactoring vectors
+//	aha-v4: aha-v3 with micro revisions counted as iterations, see Score
+const ScoreVersion = "aha-v4"
+
 // AHAMetrics represents the Advanced Human Attribution scores for a file.
 type AHAMetrics struct {
-	Commits  int     `json:"commits"`   // Number of revisions
-	AHAScore float64 `json:"aha_score"` // 0-100 score
+	Commits           int     `json:"commits"`                      // Number of revisions
+	LinesAdded        int     `json:"lines_added,omitempty"`        // Total lines inserted over all revisions
+	LinesDeleted      int     `json:"lines_deleted,omitempty"`      // Total lines removed over all revisions
+	RewrittenLines    int     `json:"rewritten_lines,omitempty"`    // Lines replaced in place (per commit: min(added, deleted))
+	ModificationRatio float64 `json:"modification_ratio,omitempty"` // RFC-002 §3.1 Modification/Growth
+	EditingDays       int     `json:"editing_days,omitempty"`       // Distinct calendar days with a revision
+	LargestInsertion  int     `json:"largest_insertion,omitempty"`  // Most lines added by a single commit
+	AHAScore          float64 `json:"aha_score"`                    // 0-100 score
+	MicroRevisions    int     `json:"micro_revisions,omitempty"`    // Revisions outside the history of HEAD (reflog, stash, dangling, snapshots)
+
+	// Refactorings classifies the function-level edits of Go files.
+	Refactorings *RefactoringCounts `json:"refactorings,omitempty"`
+	// IteratedShare is the share (0-1) of non-blank lines touched in more
+	// than one commit, as opposed to written in a single shot (see Blame).
+	IteratedShare float64 `json:"iterated_share,omitempty"`
+
+	// Contributors breaks the history down per canonical author email,
+	// including authors excluded from the score.
+	Contributors map[string]Contribution `json:"contributors,omitempty"`
+	// PasteFlags lists counted commits whose cognitive velocity on the file
+	// is outside the human range (RFC-002 §3.2), newest first.
+	PasteFlags []PasteFlag `json:"paste_flags,omitempty"`
+	// VimUndo summarizes the file's Vim undo tree, if one was found. It is
+	// evidence only and does not affect the score.
+	VimUndo *UndoSummary `json:"vim_undo,omitempty"`
+	// Halstead measures the file's current content for scoring models.
+	// Binary files and files that fail to parse have none. It is not
+	// recorded: verifiers measure the released file again (AttachHalstead).
+	Halstead *cognitive.Halstead `json:"-"`
 }
 
-// AnalyzeFile calculates the AHA metrics for a specific file.
+// AnalyzeFile calculates the AHA metrics for a specific file, counting
+// every author. Paste detection and functions moved between files need the
+// history of the whole repository and are only detected by AnalyzeRepo.
+//
+// A file without commits (e.g. not committed yet) has zero metrics. A
+// history that cannot be read is an error, see ErrShallowClone and the
+// other errors of this package.
 func AnalyzeFile(filePath string, repoRoot string) (*AHAMetrics, error) {
-	// Use relative path for git command
 	relPath, err := filepath.Rel(repoRoot, filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve relative path: %w", err)
 	}
 
-	// Git command to count commits affecting this file
-	// --follow handles renames
-	cmd := exec.Command("git", "log", "--follow", "--format=format:%H", "--", relPath)
-	cmd.Dir = repoRoot
-	output, err := cmd.Output()
+	repo, err := openRepository(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.Close()
+	if err := repo.requireFullHistory(); err != nil {
+		return nil, err
+	}
+
+	// Every commit affecting this file, following renames
+	records, err := repo.followLog(filepath.ToSlash(relPath))
 	if err != nil {
-		// If git fails (e.g. file not committed yet), return 0 commits
-		// This is valid for new files.
-		return &AHAMetrics{Commits: 0, AHAScore: 0}, nil
-	}
-
-	// Count lines (each line is a commit hash)
-	commits := 0
-	outStr := strings.TrimSpace(string(output))
-	if outStr != "" {
-		commits = len(strings.Split(outStr, "\n"))
-	}
-
-	// Calculate AHA Score
-	// Simple Heuristic:
-	// 1 commit = 10 (Initial commit)
-	// 5 commits = 50 (Iterative refinement)
-	// 10+ commits = 100 (Deep revisions)
-	// New uncommitted files = 0
-	
-	score := float64(commits) * 10
-	if score > 100 {
-		score = 100
-	}
-
-	return &AHAMetrics{
-		Commits:  commits,
-		AHAScore: score,
-	}, nil
+		return nil, fmt.Errorf("failed to read history of %s: %w", relPath, err)
+	}
+
+	refactors := newRefactorDetector(repo)
+	b := newMetricsBuilder()
+	for _, rec := range records {
+		contributor := Options{}.contributor(&rec)
+		edits := refactors.commit(&rec)
+		for _, ch := range rec.Changes {
+			b.add(&rec, ch, contributor, true)
+			b.metrics.addRefactorings(edits[ch.Path])
+		}
+	}
+	m := b.build(repo)
+	m.AttachHalstead(filePath)
+	return m, nil
+}
+
+// AttachHalstead records the Halstead measures of the file at path.
+func (m *AHAMetrics) AttachHalstead(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil || isBinary(data) {
+		return
+	}
+	if stats, err := cognitive.AnalyzeSource(path, data); err == nil {
+		m.Halstead = &stats.Halstead
+	}
+}
+
+// addChange accumulates the line counts of one commit.
+func (m *AHAMetrics) addChange(added, deleted int) {
+	m.LinesAdded += added
+	m.LinesDeleted += deleted
+	m.RewrittenLines += min(added, deleted)
+	if added > m.LargestInsertion {
+		m.LargestInsertion = added
+	}
+}
+
+// finish derives the ratio and the score once all commits are accumulated.
+func (m *AHAMetrics) finish() {
+	// Modification = every line that was written and later removed or
+	// rewritten; Growth = the lines that survived. AI output is typically
+	// high growth, low modification (RFC-002 §3.1).
+	growth := max(m.LinesAdded-m.LinesDeleted, 1)
+	m.ModificationRatio = roundTo(float64(m.LinesDeleted)/float64(growth), 3)
+	m.AHAScore = Score(m)
+}
+
+// Score computes the aha-v4 score (0-100). The churn part is
+//
+//	iteration     30 * min(commits + micro_revisions, 10) / 10
+//	modification  30 * min(modification_ratio, 1)
+//	persistence   20 * min(editing_days, 10) / 10
+//	granularity   20 * (1 - largest_insertion / lines_added)
+//
+// Micro revisions keep the iteration of squashed or rewritten work; their
+// days count towards persistence. Granularity rewards files whose lines
+// arrived over many commits rather than in one bulk insertion. Files with
+// classified Go edits weigh churn 80% and the share of refactorings among
+// them 20%:
+//
+//	0.8 * churn + 20 * refactorings / (refactorings + pure_insertions)
+//
+// Files without history score 0.
+func Score(m *AHAMetrics) float64 {
+	if m.Commits == 0 {
+		return 0
+	}
+
+	score := 30 * float64(min(m.Commits+m.MicroRevisions, 10)) / 10
+	score += 30 * min(m.ModificationRatio, 1)
+	score += 20 * float64(min(m.EditingDays, 10)) / 10
+	if m.LinesAdded > 0 {
+		score += 20 * (1 - float64(m.LargestInsertion)/float64(m.LinesAdded))
+	}
+
+	refactorings := m.Refactorings.Refactorings()
+	if edits := refactorings + m.Refactorings.pureInsertions(); edits > 0 {
+		score = 0.8*score + 20*float64(refactorings)/float64(edits)
+	}
+	return roundTo(min(score, 100), 1)
+}
+
+func roundTo(v float64, decimals int) float64 {
+	p := math.Pow(10, float64(decimals))
+	return math.Round(v*p) / p
 }
@@ -0,0 +1,130 @@
+package aha
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gitRepo is a throwaway repository for history-based tests.
+type gitRepo struct {
+	t   *testing.T
+	dir string
+}
+
+func newGitRepo(t *testing.T) *gitRepo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	r := &gitRepo{t: t, dir: t.TempDir()}
+	r.git("init", "-q")
+	return r
+}
+
+func (r *gitRepo) git(args ...string) string {
+	r.t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Alice", "GIT_AUTHOR_EMAIL=alice@example.com",
+		"GIT_COMMITTER_NAME=Alice", "GIT_COMMITTER_EMAIL=alice@example.com",
+		"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
+
+// commit writes content to name and commits it with the given author date.
+func (r *gitRepo) commit(name, content, date string) {
+	r.t.Helper()
+	r.write(name, content)
+	r.git("commit", "-q", "-m", "edit "+name, "--date", date)
+}
+
+// write stores content in name and stages it.
+func (r *gitRepo) write(name, content string) {
+	r.t.Helper()
+	p := filepath.Join(r.dir, name)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		r.t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		r.t.Fatal(err)
+	}
+	r.git("add", "-A")
+}
+
+func lines(n int, prefix string) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		sb.WriteString(prefix)
+		sb.WriteString(strings.Repeat("x", i%7))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func TestAnalyzeFileChurn(t *testing.T) {
+	r := newGitRepo(t)
+	r.commit("main.go", lines(10, "a"), "2026-01-01T10:00:00")
+	r.commit("main.go", lines(4, "b")+lines(10, "a")[8:], "2026-01-02T10:00:00") // rewrite the head
+	r.commit("main.go", lines(4, "b")+lines(10, "a")[8:]+lines(3, "c"), "2026-01-02T18:00:00")
+
+	m, err := AnalyzeFile(filepath.Join(r.dir, "main.go"), r.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Commits != 3 {
+		t.Errorf("commits = %d, want 3", m.Commits)
+	}
+	if m.EditingDays != 2 {
+		t.Errorf("editing days = %d, want 2", m.EditingDays)
+	}
+	if m.LargestInsertion != 10 {
+		t.Errorf("largest insertion = %d, want 10", m.LargestInsertion)
+	}
+	if m.LinesDeleted == 0 || m.RewrittenLines == 0 || m.ModificationRatio == 0 {
+		t.Errorf("expected modification churn, got %+v", m)
+	}
+	if m.AHAScore <= 0 || m.AHAScore > 100 {
+		t.Errorf("score out of range: %v", m.AHAScore)
+	}
+}
+
+func TestScore(t *testing.T) {
+	// One bulk commit, never touched again: only the iteration share counts.
+	bulk := &AHAMetrics{Commits: 1, LinesAdded: 500, LargestInsertion: 500, EditingDays: 1}
+	bulk.finish()
+	if bulk.AHAScore != 5 {
+		t.Errorf("bulk score = %v, want 5", bulk.AHAScore)
+	}
+
+	// Ten commits over ten days, half of the written lines reworked.
+	iterated := &AHAMetrics{Commits: 10, LinesAdded: 300, LinesDeleted: 100, LargestInsertion: 60, EditingDays: 10}
+	iterated.finish()
+	if iterated.ModificationRatio != 0.5 {
+		t.Errorf("modification ratio = %v, want 0.5", iterated.ModificationRatio)
+	}
+	// 30 + 15 + 20 + 16
+	if iterated.AHAScore != 81 {
+		t.Errorf("iterated score = %v, want 81", iterated.AHAScore)
+	}
+
+	// Go edits: 3 of 4 classified edits restructure existing code.
+	iterated.Refactorings = &RefactoringCounts{Rename: 2, ExtractFunction: 1, PureInsertion: 1}
+	iterated.finish()
+	// 0.8 * 81 + 20 * 3/4
+	if iterated.AHAScore != 79.8 {
+		t.Errorf("refactored score = %v, want 79.8", iterated.AHAScore)
+	}
+
+	if s := Score(&AHAMetrics{}); s != 0 {
+		t.Errorf("untracked score = %v, want 0", s)
+	}
+}
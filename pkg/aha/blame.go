@@ -0,0 +1,183 @@
+package aha
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BlameLine attributes one surviving line of a file.
+type BlameLine struct {
+	Number    int    `json:"line"`
+	Text      string `json:"text"`
+	Commit    string `json:"commit"`    // Commit that wrote the current text, empty if uncommitted
+	Origin    string `json:"origin"`    // Commit that first introduced the line
+	Author    string `json:"author"`    // Author of Commit
+	Revisions int    `json:"revisions"` // Commits that wrote a version of the line
+}
+
+// Iterated reports whether the line was touched in more than one commit,
+// as opposed to surviving as written in a single shot.
+func (l BlameLine) Iterated() bool {
+	return l.Revisions > 1
+}
+
+// Blame is the line-level attribution of a file.
+type Blame struct {
+	Lines         []BlameLine `json:"lines"`
+	IteratedShare float64     `json:"iterated_share"` // Iterated share of the non-blank lines, 0-1
+}
+
+// BlameFile attributes each line of a file, including uncommitted edits in
+// the working tree, to the commits that wrote it. Renames are followed.
+func BlameFile(filePath string, repoRoot string) (*Blame, error) {
+	relPath, err := filepath.Rel(repoRoot, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve relative path: %w", err)
+	}
+
+	repo, err := openRepository(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.Close()
+	if err := repo.requireFullHistory(); err != nil {
+		return nil, err
+	}
+	records, err := repo.followLog(filepath.ToSlash(relPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history of %s: %w", relPath, err)
+	}
+
+	var t lineTracker
+	for i := len(records) - 1; i >= 0; i-- {
+		rec := &records[i]
+		for _, ch := range rec.Changes {
+			if err := t.replay(repo, ch.NewBlob, rec.Hash, Options{}.contributor(rec)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	current, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	t.apply(current, "", "")
+	return t.blame(), nil
+}
+
+// lineState is the lineage of one line.
+type lineState struct {
+	commit, origin, author string
+	revisions              int
+}
+
+// lineTracker follows the lines of a file through its revisions, oldest
+// first, diffing each revision against the previous one.
+type lineTracker struct {
+	lines  []string
+	states []lineState
+}
+
+// replay applies the revision stored in blob. Deleting the file resets the
+// lineage.
+func (t *lineTracker) replay(repo *repository, blob, commit, author string) error {
+	if isNullObject(blob) {
+		t.lines, t.states = nil, nil
+		return nil
+	}
+	data, err := repo.readBlob(blob)
+	if err != nil {
+		return err
+	}
+	t.apply(data, commit, author)
+	return nil
+}
+
+// apply moves to the next revision. Unchanged lines keep their lineage;
+// within each changed hunk, new lines replace old ones positionally and
+// count one more revision, extra lines start a new lineage.
+func (t *lineTracker) apply(data []byte, commit, author string) {
+	lines := splitLines(data)
+	states := make([]lineState, len(lines))
+
+	prevOld, prevNew := -1, -1
+	hunk := func(oldEnd, newEnd int) {
+		for j := prevNew + 1; j < newEnd; j++ {
+			s := lineState{commit: commit, origin: commit, author: author, revisions: 1}
+			if i := prevOld + 1 + (j - prevNew - 1); i < oldEnd {
+				s.origin = t.states[i].origin
+				s.revisions = t.states[i].revisions + 1
+			}
+			states[j] = s
+		}
+	}
+	for _, m := range matchLines(t.lines, lines) {
+		hunk(m[0], m[1])
+		states[m[1]] = t.states[m[0]]
+		prevOld, prevNew = m[0], m[1]
+	}
+	hunk(len(t.lines), len(lines))
+
+	t.lines, t.states = lines, states
+}
+
+func (t *lineTracker) blame() *Blame {
+	b := &Blame{Lines: make([]BlameLine, len(t.lines))}
+	nonBlank, iterated := 0, 0
+	for i, line := range t.lines {
+		s := t.states[i]
+		b.Lines[i] = BlameLine{
+			Number:    i + 1,
+			Text:      line,
+			Commit:    s.commit,
+			Origin:    s.origin,
+			Author:    s.author,
+			Revisions: s.revisions,
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		nonBlank++
+		if s.revisions > 1 {
+			iterated++
+		}
+	}
+	if nonBlank > 0 {
+		b.IteratedShare = roundTo(float64(iterated)/float64(nonBlank), 3)
+	}
+	return b
+}
+
+func splitLines(data []byte) []string {
+	if len(data) == 0 || bytes.IndexByte(data, 0) >= 0 {
+		// Empty or binary: nothing to attribute.
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+// FindRepoRoot returns the top-level directory of the git repository
+// containing path.
+func FindRepoRoot(path string) (string, error) {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("%s: %w", path, ErrNotRepository)
+		}
+		dir = parent
+	}
+}
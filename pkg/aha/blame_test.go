@@ -0,0 +1,58 @@
+package aha
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBlameFile(t *testing.T) {
+	r := newGitRepo(t)
+	r.commit("doc.md", "title\n\nintro\nbody\nend\n", "2026-01-01T10:00:00")
+	r.commit("doc.md", "title\n\nbetter intro\nbody\nend\nappendix\n", "2026-01-02T10:00:00")
+	r.commit("doc.md", "title\n\nthe best intro\nbody\nend\nappendix\n", "2026-01-03T10:00:00")
+
+	path := filepath.Join(r.dir, "doc.md")
+	b, err := BlameFile(path, r.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantRevisions := []int{1, 1, 3, 1, 1, 1}
+	if len(b.Lines) != len(wantRevisions) {
+		t.Fatalf("got %d lines, want %d", len(b.Lines), len(wantRevisions))
+	}
+	for i, want := range wantRevisions {
+		if b.Lines[i].Revisions != want {
+			t.Errorf("line %d (%q): revisions = %d, want %d", i+1, b.Lines[i].Text, b.Lines[i].Revisions, want)
+		}
+	}
+	intro := b.Lines[2]
+	if intro.Origin != b.Lines[0].Commit || intro.Commit == intro.Origin {
+		t.Errorf("intro commit %s origin %s, want origin in the first commit", intro.Commit, intro.Origin)
+	}
+	// One of five non-blank lines was iterated.
+	if b.IteratedShare != 0.2 {
+		t.Errorf("iterated share = %v, want 0.2", b.IteratedShare)
+	}
+
+	repo, err := AnalyzeRepo(r.dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo["doc.md"].IteratedShare != b.IteratedShare {
+		t.Errorf("AnalyzeRepo iterated share = %v, BlameFile = %v", repo["doc.md"].IteratedShare, b.IteratedShare)
+	}
+
+	// Uncommitted edits are attributed to no commit.
+	if err := os.WriteFile(path, []byte("title\n\nthe best intro\nbody\nthe end\nappendix\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	b, err = BlameFile(path, r.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if end := b.Lines[4]; end.Commit != "" || end.Revisions != 2 {
+		t.Errorf("edited line = %+v, want uncommitted second revision", end)
+	}
+}
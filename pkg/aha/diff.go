@@ -0,0 +1,96 @@
+package aha
+
+// maxDiffEdits bounds the work of matchLines. Revisions further apart are
+// matched on their common prefix and suffix only, i.e. treated as a rewrite.
+const maxDiffEdits = 2000
+
+// matchLines returns the index pairs (i, j) of a longest common subsequence
+// of a and b in increasing order, using Myers' O(ND) diff.
+func matchLines(a, b []string) [][2]int {
+	var matches [][2]int
+
+	// Common prefix and suffix are matched directly.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		matches = append(matches, [2]int{prefix, prefix})
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	for _, m := range myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		matches = append(matches, [2]int{m[0] + prefix, m[1] + prefix})
+	}
+	for s := suffix; s > 0; s-- {
+		matches = append(matches, [2]int{len(a) - s, len(b) - s})
+	}
+	return matches
+}
+
+// myers returns the matched index pairs of a and b, or none if they differ
+// by more than maxDiffEdits edits.
+func myers(a, b []string) [][2]int {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return nil
+	}
+
+	// v[k+offset] is the furthest x reached on diagonal k = x - y.
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+	found := false
+	for d := 0; d <= min(n+m, maxDiffEdits) && !found; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	// Walk the trace back from (n, m), collecting the diagonal moves.
+	var rev [][2]int
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		snapshot := trace[d] // v[-d-1 .. d+1] before step d
+		at := func(k int) int { return snapshot[k+d+1] }
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			rev = append(rev, [2]int{x, y})
+		}
+		x, y = prevX, prevY
+	}
+
+	matches := make([][2]int, len(rev))
+	for i := range rev {
+		matches[i] = rev[len(rev)-1-i]
+	}
+	return matches
+}
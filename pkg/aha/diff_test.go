@@ -0,0 +1,51 @@
+package aha
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// lcsLength is the textbook dynamic program, for reference.
+func lcsLength(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else {
+				dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+			}
+		}
+	}
+	return dp[0][0]
+}
+
+func TestMatchLines(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	alphabet := []string{"a", "b", "c", "d"}
+	randomLines := func() []string {
+		out := make([]string, rng.Intn(30))
+		for i := range out {
+			out[i] = alphabet[rng.Intn(len(alphabet))]
+		}
+		return out
+	}
+
+	for iter := 0; iter < 500; iter++ {
+		a, b := randomLines(), randomLines()
+		matches := matchLines(a, b)
+		if len(matches) != lcsLength(a, b) {
+			t.Fatalf("%v vs %v: %d matches, want %d", a, b, len(matches), lcsLength(a, b))
+		}
+		prev := [2]int{-1, -1}
+		for _, m := range matches {
+			if m[0] <= prev[0] || m[1] <= prev[1] || a[m[0]] != b[m[1]] {
+				t.Fatalf("%v vs %v: invalid matches %v", a, b, matches)
+			}
+			prev = m
+		}
+	}
+}
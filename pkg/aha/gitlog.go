@@ -0,0 +1,806 @@
+package aha
+
+import (
+	"bytes"
+	"container/heap"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/windgeek/HCP/pkg/identity"
+)
+
+// commitObject is a parsed commit.
+type commitObject struct {
+	id          string
+	tree        string
+	parents     []string
+	authorName  string
+	authorEmail string
+	authorTime  time.Time // In the author's time zone
+	commitTime  int64
+	message     string
+}
+
+func (r *repository) readCommit(id string) (*commitObject, error) {
+	typ, data, err := r.readObject(id)
+	if err != nil {
+		return nil, err
+	}
+	if typ != "commit" {
+		return nil, fmt.Errorf("object %s is a %s, not a commit", id, typ)
+	}
+
+	c := &commitObject{id: id}
+	header, message, _ := strings.Cut(string(data), "\n\n")
+	c.message = message
+	for _, line := range strings.Split(header, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "tree":
+			c.tree = value
+		case "parent":
+			c.parents = append(c.parents, value)
+		case "author":
+			c.authorName, c.authorEmail, c.authorTime = parseIdentity(value)
+		case "committer":
+			_, _, t := parseIdentity(value)
+			c.commitTime = t.Unix()
+		}
+	}
+	return c, nil
+}
+
+// parseIdentity parses "Name <email> <unix time> <+hhmm>".
+func parseIdentity(s string) (name, email string, when time.Time) {
+	open, end := strings.IndexByte(s, '<'), strings.LastIndexByte(s, '>')
+	if open < 0 || end < open {
+		return strings.TrimSpace(s), "", time.Unix(0, 0).UTC()
+	}
+	name, email = strings.TrimSpace(s[:open]), s[open+1:end]
+	fields := strings.Fields(s[end+1:])
+	when = time.Unix(0, 0).UTC()
+	if len(fields) > 0 {
+		secs, _ := strconv.ParseInt(fields[0], 10, 64)
+		when = time.Unix(secs, 0).UTC()
+	}
+	if len(fields) > 1 && len(fields[1]) == 5 {
+		hours, err1 := strconv.Atoi(fields[1][1:3])
+		minutes, err2 := strconv.Atoi(fields[1][3:5])
+		if err1 == nil && err2 == nil {
+			offset := hours*3600 + minutes*60
+			if fields[1][0] == '-' {
+				offset = -offset
+			}
+			when = when.In(time.FixedZone(fields[1], offset))
+		}
+	}
+	return name, email, when
+}
+
+// treeEntry is one entry of a tree object.
+type treeEntry struct {
+	mode uint32
+	name string
+	id   string
+}
+
+const (
+	modeTree    = 0o40000
+	modeSymlink = 0o120000
+	modeGitlink = 0o160000
+)
+
+func (e treeEntry) isTree() bool { return e.mode == modeTree }
+
+// isFile reports whether the entry is a regular file or a symlink, as
+// opposed to a directory or a submodule.
+func (e treeEntry) isFile() bool { return e.mode != modeTree && e.mode != modeGitlink }
+
+// readTree parses a tree; "" is the empty tree.
+func (r *repository) readTree(id string) ([]treeEntry, error) {
+	if id == "" {
+		return nil, nil
+	}
+	typ, data, err := r.readObject(id)
+	if err != nil {
+		return nil, err
+	}
+	if typ != "tree" {
+		return nil, fmt.Errorf("object %s is a %s, not a tree", id, typ)
+	}
+
+	// "<octal mode> <name>\0<20-byte id>" per entry
+	var entries []treeEntry
+	for len(data) > 0 {
+		sp := bytes.IndexByte(data, ' ')
+		nul := bytes.IndexByte(data, 0)
+		if sp < 0 || nul < sp || nul+21 > len(data) {
+			return nil, fmt.Errorf("corrupt tree %s", id)
+		}
+		mode, err := strconv.ParseUint(string(data[:sp]), 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("corrupt tree %s: %w", id, err)
+		}
+		entries = append(entries, treeEntry{
+			mode: uint32(mode),
+			name: string(data[sp+1 : nul]),
+			id:   hex.EncodeToString(data[nul+1 : nul+21]),
+		})
+		data = data[nul+21:]
+	}
+	return entries, nil
+}
+
+// lookup returns the entry at a slash-separated path below a tree.
+func (r *repository) lookup(tree, p string) (treeEntry, bool, error) {
+	entry := treeEntry{mode: modeTree, id: tree}
+	if p == "" {
+		return entry, tree != "", nil
+	}
+	for _, name := range strings.Split(p, "/") {
+		if !entry.isTree() {
+			return treeEntry{}, false, nil
+		}
+		entries, err := r.readTree(entry.id)
+		if err != nil {
+			return treeEntry{}, false, err
+		}
+		found := false
+		for _, e := range entries {
+			if e.name == name {
+				entry, found = e, true
+				break
+			}
+		}
+		if !found {
+			return treeEntry{}, false, nil
+		}
+	}
+	return entry, true, nil
+}
+
+// subtree returns the tree at dir, or "" if there is none.
+func (r *repository) subtree(tree, dir string) (string, error) {
+	e, ok, err := r.lookup(tree, dir)
+	if err != nil || !ok || !e.isTree() {
+		return "", err
+	}
+	return e.id, nil
+}
+
+// listFiles calls fn for every file below a tree.
+func (r *repository) listFiles(tree, dir string, fn func(p string, e treeEntry)) error {
+	entries, err := r.readTree(tree)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		p := path.Join(dir, e.name)
+		if e.isTree() {
+			if err := r.listFiles(e.id, p, fn); err != nil {
+				return err
+			}
+		} else if e.isFile() {
+			fn(p, e)
+		}
+	}
+	return nil
+}
+
+// diffTrees appends the files that differ between two trees ("" for
+// none) to out. Statuses are A, D, M and T (file type change); renames are
+// detected separately.
+func (r *repository) diffTrees(oldTree, newTree, dir string, out *[]fileChange) error {
+	if oldTree == newTree {
+		return nil
+	}
+	oldEntries, err := r.readTree(oldTree)
+	if err != nil {
+		return err
+	}
+	newEntries, err := r.readTree(newTree)
+	if err != nil {
+		return err
+	}
+	old := make(map[string]treeEntry, len(oldEntries))
+	names := make([]string, 0, len(oldEntries)+len(newEntries))
+	for _, e := range oldEntries {
+		old[e.name] = e
+		names = append(names, e.name)
+	}
+	cur := make(map[string]treeEntry, len(newEntries))
+	for _, e := range newEntries {
+		cur[e.name] = e
+		if _, ok := old[e.name]; !ok {
+			names = append(names, e.name)
+		}
+	}
+	// Git's tree order: directories sort as if their name ended in "/".
+	key := func(name string) string {
+		if e, ok := cur[name]; ok && e.isTree() || !ok && old[name].isTree() {
+			return name + "/"
+		}
+		return name
+	}
+	sort.Slice(names, func(i, j int) bool { return key(names[i]) < key(names[j]) })
+
+	for _, name := range names {
+		o, inOld := old[name]
+		n, inNew := cur[name]
+		if inOld && inNew && o == n {
+			continue
+		}
+		p := path.Join(dir, name)
+		if o.isTree() && inOld || n.isTree() && inNew {
+			var ot, nt string
+			if inOld && o.isTree() {
+				ot = o.id
+			}
+			if inNew && n.isTree() {
+				nt = n.id
+			}
+			if err := r.diffTrees(ot, nt, p, out); err != nil {
+				return err
+			}
+		}
+		oldFile, newFile := inOld && o.isFile(), inNew && n.isFile()
+		switch {
+		case oldFile && newFile:
+			status := byte('M')
+			if (o.mode == modeSymlink) != (n.mode == modeSymlink) {
+				status = 'T'
+			}
+			*out = append(*out, fileChange{Status: status, Path: p, OldBlob: o.id, NewBlob: n.id})
+		case oldFile:
+			*out = append(*out, fileChange{Status: 'D', Path: p, OldBlob: o.id, NewBlob: nullObject})
+		case newFile:
+			*out = append(*out, fileChange{Status: 'A', Path: p, OldBlob: nullObject, NewBlob: n.id})
+		}
+	}
+	return nil
+}
+
+// Rename detection, as `git diff -M`: a deleted and an added file are a
+// rename if they are identical, or if at least renameThreshold of the
+// larger one's bytes are in lines common to both.
+const (
+	renameThreshold = 0.5
+	renameLimit     = 1000 // Inexact detection is skipped above renameLimit² pairs
+)
+
+// detectRenames merges pairs of deleted and added files into renames.
+func (r *repository) detectRenames(changes []fileChange) ([]fileChange, error) {
+	var deleted, added []int
+	for i, ch := range changes {
+		switch ch.Status {
+		case 'D':
+			deleted = append(deleted, i)
+		case 'A':
+			added = append(added, i)
+		}
+	}
+	if len(deleted) == 0 || len(added) == 0 {
+		return changes, nil
+	}
+
+	source := make(map[int]int) // Added index -> deleted index
+	used := make(map[int]bool)
+
+	// 1. Exact renames, preferring sources with the same base name
+	for _, a := range added {
+		best := -1
+		for _, d := range deleted {
+			if used[d] || changes[d].OldBlob != changes[a].NewBlob || changes[a].NewBlob == emptyBlob {
+				continue
+			}
+			if best < 0 || path.Base(changes[d].Path) == path.Base(changes[a].Path) && path.Base(changes[best].Path) != path.Base(changes[a].Path) {
+				best = d
+			}
+		}
+		if best >= 0 {
+			source[a], used[best] = best, true
+		}
+	}
+
+	// 2. Similar files, best scores first
+	if len(deleted)*len(added) <= renameLimit*renameLimit {
+		type candidate struct {
+			score float64
+			d, a  int
+		}
+		var candidates []candidate
+		content := make(map[string][]byte)
+		read := func(id string) ([]byte, error) {
+			if data, ok := content[id]; ok {
+				return data, nil
+			}
+			data, err := r.readBlob(id)
+			content[id] = data
+			return data, err
+		}
+		for _, a := range added {
+			if _, ok := source[a]; ok {
+				continue
+			}
+			for _, d := range deleted {
+				if used[d] {
+					continue
+				}
+				oldData, err := read(changes[d].OldBlob)
+				if err != nil {
+					return nil, err
+				}
+				newData, err := read(changes[a].NewBlob)
+				if err != nil {
+					return nil, err
+				}
+				if score := similarity(oldData, newData); score >= renameThreshold {
+					candidates = append(candidates, candidate{score, d, a})
+				}
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+		for _, c := range candidates {
+			if _, ok := source[c.a]; !ok && !used[c.d] {
+				source[c.a], used[c.d] = c.d, true
+			}
+		}
+	}
+
+	// 3. Merge each pair into the added entry
+	var result []fileChange
+	for i, ch := range changes {
+		if used[i] {
+			continue
+		}
+		if d, ok := source[i]; ok {
+			ch.Status = 'R'
+			ch.OldPath = changes[d].Path
+			ch.OldBlob = changes[d].OldBlob
+		}
+		result = append(result, ch)
+	}
+	return result, nil
+}
+
+// emptyBlob is the id of the empty file, never considered for renames.
+const emptyBlob = "e69de29bb2d1d6434b8b29ae775ad8c2d48c5391"
+
+// similarity is the share of the larger file's bytes in lines common to
+// both files, 0-1.
+func similarity(a, b []byte) float64 {
+	larger := max(len(a), len(b))
+	if larger == 0 || isBinary(a) || isBinary(b) || float64(min(len(a), len(b))) < float64(larger)*renameThreshold {
+		return 0
+	}
+	aLines, bLines := rawLines(a), rawLines(b)
+	common := 0
+	for _, m := range matchLines(aLines, bLines) {
+		common += len(aLines[m[0]])
+	}
+	return float64(common) / float64(larger)
+}
+
+// numstat counts the lines added and deleted between two blobs, as
+// `git diff --numstat`. Binary files count zero lines.
+func (r *repository) numstat(oldBlob, newBlob string) (added, deleted int, err error) {
+	var oldData, newData []byte
+	if !isNullObject(oldBlob) {
+		if oldData, err = r.readBlob(oldBlob); err != nil {
+			return 0, 0, err
+		}
+	}
+	if !isNullObject(newBlob) {
+		if newData, err = r.readBlob(newBlob); err != nil {
+			return 0, 0, err
+		}
+	}
+	if isBinary(oldData) || isBinary(newData) {
+		return 0, 0, nil
+	}
+	oldLines, newLines := rawLines(oldData), rawLines(newData)
+	common := len(matchLines(oldLines, newLines))
+	return len(newLines) - common, len(oldLines) - common, nil
+}
+
+// isBinary applies git's heuristic: a NUL byte in the first 8000 bytes.
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0
+}
+
+// rawLines splits data into lines keeping their terminators, so a missing
+// final newline counts as a change like in git.
+func rawLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// commitQueue orders commits newest first by committer date, like git log;
+// ties keep insertion order.
+type commitQueue []*queuedCommit
+
+type queuedCommit struct {
+	*commitObject
+	seq int
+}
+
+func (q commitQueue) Len() int { return len(q) }
+func (q commitQueue) Less(i, j int) bool {
+	if q[i].commitTime != q[j].commitTime {
+		return q[i].commitTime > q[j].commitTime
+	}
+	return q[i].seq < q[j].seq
+}
+func (q commitQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *commitQueue) Push(x any)   { *q = append(*q, x.(*queuedCommit)) }
+func (q *commitQueue) Pop() any {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
+
+// walk visits the commits reachable from tips but not in hidden, newest
+// first. Tips may be tags; other objects are skipped.
+func (r *repository) walk(tips []string, hidden map[string]bool, visit func(*commitObject) error) error {
+	var q commitQueue
+	seen := make(map[string]bool)
+	seq := 0
+	push := func(id string) error {
+		if seen[id] || hidden[id] {
+			return nil
+		}
+		seen[id] = true
+		c, err := r.readCommit(id)
+		if err != nil {
+			return err
+		}
+		heap.Push(&q, &queuedCommit{c, seq})
+		seq++
+		return nil
+	}
+	for _, tip := range tips {
+		if tip == "" {
+			continue
+		}
+		id, err := r.peel(tip)
+		if err != nil {
+			return err
+		}
+		if id != "" {
+			if err := push(id); err != nil {
+				return err
+			}
+		}
+	}
+	for q.Len() > 0 {
+		c := heap.Pop(&q).(*queuedCommit).commitObject
+		if visit != nil {
+			if err := visit(c); err != nil {
+				return err
+			}
+		}
+		for _, p := range c.parents {
+			if err := push(p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// reachable returns the ids of the commits reachable from tips.
+func (r *repository) reachable(tips []string) (map[string]bool, error) {
+	ids := make(map[string]bool)
+	err := r.walk(tips, nil, func(c *commitObject) error {
+		ids[c.id] = true
+		return nil
+	})
+	return ids, err
+}
+
+// prefix returns the slash-separated path of dir relative to the top of
+// the working tree, "" for the top itself.
+func (r *repository) prefix(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(r.workTree, abs)
+	if err != nil {
+		return "", err
+	}
+	if rel == "." {
+		return "", nil
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// head returns the commit HEAD points to, or "" in a repository without
+// commits.
+func (r *repository) head() (string, error) {
+	return r.resolveRef("HEAD")
+}
+
+// record converts a commit into a commitRecord. Changes are relative to
+// the first parent (the empty tree for root commits) and restricted to
+// the files below prefix, with paths relative to it. Merges have no
+// changes unless diffMerges is set, like `git log` without -m.
+func (r *repository) record(c *commitObject, prefix string, diffMerges bool) (commitRecord, error) {
+	rec, err := r.header(c)
+	if err != nil || len(c.parents) > 1 && !diffMerges {
+		return rec, err
+	}
+
+	var parentTree string
+	if len(c.parents) > 0 {
+		parent, err := r.readCommit(c.parents[0])
+		if err != nil {
+			return rec, err
+		}
+		parentTree = parent.tree
+	}
+	oldTree, err := r.subtree(parentTree, prefix)
+	if err != nil {
+		return rec, err
+	}
+	newTree, err := r.subtree(c.tree, prefix)
+	if err != nil {
+		return rec, err
+	}
+	rec.Changes, err = r.changes(oldTree, newTree)
+	return rec, err
+}
+
+// changes diffs two trees with rename detection and line counts.
+func (r *repository) changes(oldTree, newTree string) ([]fileChange, error) {
+	var changes []fileChange
+	if err := r.diffTrees(oldTree, newTree, "", &changes); err != nil {
+		return nil, err
+	}
+	changes, err := r.detectRenames(changes)
+	if err != nil {
+		return nil, err
+	}
+	for i := range changes {
+		ch := &changes[i]
+		if ch.Added, ch.Deleted, err = r.numstat(ch.OldBlob, ch.NewBlob); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+// header fills the commit fields of a record: author after .mailmap,
+// author date and HCP-Signature trailers.
+func (r *repository) header(c *commitObject) (commitRecord, error) {
+	if r.mailmap == nil {
+		m, err := loadMailmap(filepath.Join(r.workTree, ".mailmap"))
+		if err != nil {
+			return commitRecord{}, err
+		}
+		r.mailmap = m
+	}
+	name, email := r.mailmap.resolve(c.authorName, c.authorEmail)
+	return commitRecord{
+		Hash:        c.id,
+		Date:        c.authorTime.Format("2006-01-02"),
+		Time:        c.authorTime.Unix(),
+		AuthorName:  name,
+		AuthorEmail: email,
+		Payload: identity.CommitPayload{
+			Tree:    c.tree,
+			Parents: c.parents,
+			Author:  c.authorName + " <" + c.authorEmail + ">",
+		},
+		Signatures: trailers(c.message, identity.CommitTrailer),
+	}, nil
+}
+
+// log returns the commits reachable from HEAD, newest first, with their
+// changes below prefix (`git log -M --relative`).
+func (r *repository) log(prefix string) ([]commitRecord, error) {
+	head, err := r.head()
+	if err != nil || head == "" {
+		return nil, err
+	}
+	var records []commitRecord
+	err = r.walk([]string{head}, nil, func(c *commitObject) error {
+		rec, err := r.record(c, prefix, false)
+		records = append(records, rec)
+		return err
+	})
+	return records, err
+}
+
+// followLog returns the commits reachable from HEAD that changed the file
+// at p, newest first, following renames (`git log --follow -M`). Each
+// record holds the single change to the file.
+func (r *repository) followLog(p string) ([]commitRecord, error) {
+	head, err := r.head()
+	if err != nil || head == "" {
+		return nil, err
+	}
+	var records []commitRecord
+	err = r.walk([]string{head}, nil, func(c *commitObject) error {
+		if len(c.parents) > 1 {
+			return nil
+		}
+		var parentTree string
+		if len(c.parents) == 1 {
+			parent, err := r.readCommit(c.parents[0])
+			if err != nil {
+				return err
+			}
+			parentTree = parent.tree
+		}
+
+		// Skip the full diff unless the file changed.
+		cur, inCur, err := r.lookup(c.tree, p)
+		if err != nil {
+			return err
+		}
+		old, inOld, err := r.lookup(parentTree, p)
+		if err != nil {
+			return err
+		}
+		if inCur && inOld && cur == old || !inCur && !inOld {
+			return nil
+		}
+		changes, err := r.changes(parentTree, c.tree)
+		if err != nil {
+			return err
+		}
+		for _, ch := range changes {
+			if ch.Path != p {
+				continue
+			}
+			rec, err := r.header(c)
+			if err != nil {
+				return err
+			}
+			rec.Changes = []fileChange{ch}
+			records = append(records, rec)
+			if ch.Status == 'R' {
+				p = ch.OldPath
+			}
+			break
+		}
+		return nil
+	})
+	return records, err
+}
+
+// trailers returns the values of the key trailers in the trailer block
+// (last paragraph) of a commit message, like
+// `%(trailers:key=<key>,valueonly)`.
+func trailers(message, key string) []string {
+	paragraphs := strings.Split(strings.TrimRight(message, "\n \t"), "\n\n")
+	if len(paragraphs) < 2 {
+		return nil // A lone title is not a trailer block.
+	}
+	lines := strings.Split(strings.Trim(paragraphs[len(paragraphs)-1], "\n"), "\n")
+
+	type trailer struct{ key, value string }
+	var found []trailer
+	others, gitGenerated := 0, false
+	for _, line := range lines {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(found) > 0 {
+			found[len(found)-1].value += " " + strings.TrimSpace(line)
+			continue
+		}
+		k, v, ok := strings.Cut(line, ":")
+		k = strings.TrimRight(k, " \t")
+		if !ok || k == "" || strings.IndexFunc(k, func(c rune) bool {
+			return !(c == '-' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z')
+		}) >= 0 {
+			others++
+			gitGenerated = gitGenerated || strings.HasPrefix(line, "(cherry picked from commit ")
+			continue
+		}
+		gitGenerated = gitGenerated || strings.EqualFold(k, "Signed-off-by")
+		found = append(found, trailer{k, strings.TrimSpace(v)})
+	}
+	// Every line must be a trailer, unless git wrote some of them and they
+	// make up at least a quarter of the block.
+	if len(found) == 0 || others > 0 && !(gitGenerated && 3*len(found) >= others) {
+		return nil
+	}
+	var values []string
+	for _, t := range found {
+		if strings.EqualFold(t.key, key) && t.value != "" {
+			values = append(values, t.value)
+		}
+	}
+	return values
+}
+
+// mailmap maps commit identities to canonical ones (gitmailmap(5)).
+type mailmap struct {
+	byEmail map[string]mailmapEntry            // Lowercase commit email
+	byName  map[string]map[string]mailmapEntry // Lowercase commit email, then lowercase commit name
+}
+
+type mailmapEntry struct {
+	name, email string // Replacements; empty keeps the original
+}
+
+func loadMailmap(file string) (*mailmap, error) {
+	m := &mailmap{byEmail: make(map[string]mailmapEntry), byName: make(map[string]map[string]mailmapEntry)}
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .mailmap: %w", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		// Up to two "Name <email>" pairs: proper one, then commit one.
+		var names, emails []string
+		for {
+			open := strings.IndexByte(line, '<')
+			end := strings.IndexByte(line, '>')
+			if open < 0 || end < open {
+				break
+			}
+			names = append(names, strings.TrimSpace(line[:open]))
+			emails = append(emails, line[open+1:end])
+			line = line[end+1:]
+		}
+		var e mailmapEntry
+		var commitName, commitEmail string
+		switch len(emails) {
+		case 1:
+			e.name, commitEmail = names[0], emails[0]
+		case 2:
+			e.name, e.email, commitName, commitEmail = names[0], emails[0], names[1], emails[1]
+		default:
+			continue
+		}
+		commitEmail = strings.ToLower(commitEmail)
+		if commitName == "" {
+			prev := m.byEmail[commitEmail]
+			m.byEmail[commitEmail] = mailmapEntry{name: firstNonEmpty(e.name, prev.name), email: firstNonEmpty(e.email, prev.email)}
+			continue
+		}
+		if m.byName[commitEmail] == nil {
+			m.byName[commitEmail] = make(map[string]mailmapEntry)
+		}
+		m.byName[commitEmail][strings.ToLower(commitName)] = e
+	}
+	return m, nil
+}
+
+func (m *mailmap) resolve(name, email string) (string, string) {
+	key := strings.ToLower(email)
+	e, ok := m.byName[key][strings.ToLower(name)]
+	if !ok {
+		if e, ok = m.byEmail[key]; !ok {
+			return name, email
+		}
+	}
+	return firstNonEmpty(e.name, name), firstNonEmpty(e.email, email)
+}
+
+func firstNonEmpty(a, b string) string {
+	if a != "" {
+		return a
+	}
+	return b
+}
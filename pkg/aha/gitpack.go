@@ -0,0 +1,348 @@
+package aha
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Pack entry types
+const (
+	packCommit   = 1
+	packTree     = 2
+	packBlob     = 3
+	packTag      = 4
+	packOfsDelta = 6
+	packRefDelta = 7
+)
+
+var packTypeNames = map[int]string{packCommit: "commit", packTree: "tree", packBlob: "blob", packTag: "tag"}
+
+// maxCachedBases bounds the cache of delta bases per packfile.
+const maxCachedBases = 256
+
+// packFile reads objects from a packfile through its version 2 index.
+type packFile struct {
+	file    *os.File
+	size    int64
+	fanout  [256]uint32
+	names   []byte // Sorted object ids, 20 bytes each
+	offsets []byte // 4-byte offsets; the high bit selects a large offset
+	large   []byte // 8-byte offsets
+	bases   map[int64]rawObject
+	zlib    inflater
+}
+
+func openPack(idxPath string) (*packFile, error) {
+	idx, err := os.ReadFile(idxPath)
+	if err != nil {
+		return nil, err
+	}
+	// 1. Index: magic, version, fanout, names, CRCs, offsets, large offsets
+	if len(idx) < 8+256*4 || !bytes.Equal(idx[:4], []byte("\xfftOc")) || binary.BigEndian.Uint32(idx[4:8]) != 2 {
+		return nil, fmt.Errorf("%w: %s is not a version 2 pack index", ErrUnsupportedRepository, idxPath)
+	}
+	p := &packFile{bases: make(map[int64]rawObject)}
+	for i := range p.fanout {
+		p.fanout[i] = binary.BigEndian.Uint32(idx[8+4*i:])
+	}
+	n := int(p.fanout[255])
+	pos := 8 + 256*4
+	if len(idx) < pos+n*(sha1.Size+4+4) {
+		return nil, fmt.Errorf("truncated pack index %s", idxPath)
+	}
+	p.names = idx[pos : pos+n*sha1.Size]
+	pos += n*sha1.Size + n*4
+	p.offsets = idx[pos : pos+n*4]
+	p.large = idx[pos+n*4:]
+
+	// 2. Pack: "PACK", version 2 or 3
+	packPath := strings.TrimSuffix(idxPath, ".idx") + ".pack"
+	if p.file, err = os.Open(packPath); err != nil {
+		return nil, err
+	}
+	info, err := p.file.Stat()
+	if err != nil {
+		p.file.Close()
+		return nil, err
+	}
+	p.size = info.Size()
+	header := make([]byte, 8)
+	if _, err := p.file.ReadAt(header, 0); err != nil || string(header[:4]) != "PACK" {
+		p.file.Close()
+		return nil, fmt.Errorf("corrupt packfile %s", packPath)
+	}
+	if v := binary.BigEndian.Uint32(header[4:]); v != 2 && v != 3 {
+		p.file.Close()
+		return nil, fmt.Errorf("%w: pack version %d", ErrUnsupportedRepository, v)
+	}
+	return p, nil
+}
+
+func (p *packFile) Close() error {
+	return p.file.Close()
+}
+
+func (p *packFile) count() int {
+	return len(p.names) / sha1.Size
+}
+
+func (p *packFile) name(i int) []byte {
+	return p.names[i*sha1.Size : (i+1)*sha1.Size]
+}
+
+// find returns the pack offset of an object.
+func (p *packFile) find(id []byte) (int64, bool) {
+	lo := 0
+	if id[0] > 0 {
+		lo = int(p.fanout[id[0]-1])
+	}
+	hi := int(p.fanout[id[0]])
+	i := lo + sort.Search(hi-lo, func(i int) bool {
+		return bytes.Compare(p.name(lo+i), id) >= 0
+	})
+	if i >= hi || !bytes.Equal(p.name(i), id) {
+		return 0, false
+	}
+	off := binary.BigEndian.Uint32(p.offsets[i*4:])
+	if off&0x80000000 == 0 {
+		return int64(off), true
+	}
+	j := int(off&0x7fffffff) * 8
+	if j+8 > len(p.large) {
+		return 0, false
+	}
+	return int64(binary.BigEndian.Uint64(p.large[j:])), true
+}
+
+// entry is the header of a pack entry.
+type entry struct {
+	typ     int
+	size    int64  // Inflated size of the entry data
+	data    int64  // Offset of the compressed data
+	baseOfs int64  // Offset of the base of an OFS_DELTA
+	baseID  string // Object id of the base of a REF_DELTA
+}
+
+func (p *packFile) entryAt(offset int64) (entry, error) {
+	buf := make([]byte, 32)
+	n, err := p.file.ReadAt(buf, offset)
+	if n == 0 {
+		return entry{}, fmt.Errorf("corrupt packfile: entry at %d: %w", offset, err)
+	}
+	buf = buf[:n]
+
+	// Type and size: 3 bits and 4 bits, then 7 bits per byte
+	i := 0
+	next := func() (byte, bool) {
+		if i >= len(buf) {
+			return 0, false
+		}
+		i++
+		return buf[i-1], true
+	}
+	b, _ := next()
+	e := entry{typ: int(b>>4) & 7, size: int64(b & 15)}
+	for shift := 4; b&0x80 != 0; shift += 7 {
+		var ok bool
+		if b, ok = next(); !ok {
+			return entry{}, fmt.Errorf("corrupt packfile: entry at %d", offset)
+		}
+		e.size |= int64(b&0x7f) << shift
+	}
+
+	switch e.typ {
+	case packOfsDelta:
+		// Big-endian base-128 distance with an offset per extra byte
+		b, _ = next()
+		dist := int64(b & 0x7f)
+		for b&0x80 != 0 {
+			var ok bool
+			if b, ok = next(); !ok {
+				return entry{}, fmt.Errorf("corrupt packfile: entry at %d", offset)
+			}
+			dist = (dist+1)<<7 | int64(b&0x7f)
+		}
+		e.baseOfs = offset - dist
+	case packRefDelta:
+		if i+sha1.Size > len(buf) {
+			return entry{}, fmt.Errorf("corrupt packfile: entry at %d", offset)
+		}
+		e.baseID = hex.EncodeToString(buf[i : i+sha1.Size])
+		i += sha1.Size
+	}
+	e.data = offset + int64(i)
+	return e, nil
+}
+
+func (p *packFile) inflate(e entry) ([]byte, error) {
+	z, err := p.zlib.reset(io.NewSectionReader(p.file, e.data, p.size-e.data))
+	if err != nil {
+		return nil, fmt.Errorf("corrupt packfile: %w", err)
+	}
+	data := make([]byte, e.size)
+	if _, err := io.ReadFull(z, data); err != nil {
+		return nil, fmt.Errorf("corrupt packfile: %w", err)
+	}
+	return data, nil
+}
+
+// inflater reuses a zlib reader and its buffers across objects.
+type inflater struct {
+	buf *bufio.Reader
+	z   io.ReadCloser
+}
+
+func (f *inflater) reset(r io.Reader) (io.Reader, error) {
+	if f.buf == nil {
+		f.buf = bufio.NewReader(r)
+	} else {
+		f.buf.Reset(r)
+	}
+	if f.z == nil {
+		z, err := zlib.NewReader(f.buf)
+		if err != nil {
+			return nil, err
+		}
+		f.z = z
+		return z, nil
+	}
+	return f.z, f.z.(zlib.Resetter).Reset(f.buf, nil)
+}
+
+// read returns the object at offset, applying deltas. r resolves the bases
+// of REF_DELTA entries, which may live elsewhere.
+func (p *packFile) read(r *repository, offset int64) (rawObject, error) {
+	if o, ok := p.bases[offset]; ok {
+		return o, nil
+	}
+	e, err := p.entryAt(offset)
+	if err != nil {
+		return rawObject{}, err
+	}
+	data, err := p.inflate(e)
+	if err != nil {
+		return rawObject{}, err
+	}
+
+	var base rawObject
+	switch e.typ {
+	case packCommit, packTree, packBlob, packTag:
+		return rawObject{packTypeNames[e.typ], data}, nil
+	case packOfsDelta:
+		if base, err = p.read(r, e.baseOfs); err != nil {
+			return rawObject{}, err
+		}
+		p.cacheBase(e.baseOfs, base)
+	case packRefDelta:
+		if base.typ, base.data, err = r.readObject(e.baseID); err != nil {
+			return rawObject{}, err
+		}
+	default:
+		return rawObject{}, fmt.Errorf("corrupt packfile: entry type %d at %d", e.typ, offset)
+	}
+	patched, err := applyDelta(base.data, data)
+	if err != nil {
+		return rawObject{}, err
+	}
+	return rawObject{base.typ, patched}, nil
+}
+
+func (p *packFile) cacheBase(offset int64, o rawObject) {
+	if len(p.bases) >= maxCachedBases {
+		clear(p.bases)
+	}
+	p.bases[offset] = o
+}
+
+// typeAt returns the object type at offset without inflating it.
+func (p *packFile) typeAt(r *repository, offset int64) (string, error) {
+	for {
+		e, err := p.entryAt(offset)
+		if err != nil {
+			return "", err
+		}
+		switch e.typ {
+		case packOfsDelta:
+			offset = e.baseOfs
+		case packRefDelta:
+			typ, _, err := r.readObject(e.baseID)
+			return typ, err
+		default:
+			return packTypeNames[e.typ], nil
+		}
+	}
+}
+
+var errBadDelta = errors.New("corrupt packfile: bad delta")
+
+// applyDelta rebuilds an object from its delta base: two sizes, then copy
+// (from base) and insert (literal) instructions.
+func applyDelta(base, delta []byte) ([]byte, error) {
+	pos := 0
+	varint := func() int {
+		v, shift := 0, 0
+		for pos < len(delta) {
+			b := delta[pos]
+			pos++
+			v |= int(b&0x7f) << shift
+			shift += 7
+			if b&0x80 == 0 {
+				break
+			}
+		}
+		return v
+	}
+	if varint() != len(base) {
+		return nil, errBadDelta
+	}
+	out := make([]byte, 0, varint())
+
+	for pos < len(delta) {
+		op := delta[pos]
+		pos++
+		if op&0x80 == 0 {
+			if op == 0 || pos+int(op) > len(delta) {
+				return nil, errBadDelta
+			}
+			out = append(out, delta[pos:pos+int(op)]...)
+			pos += int(op)
+			continue
+		}
+		var offset, size int
+		for bit := 0; bit < 7; bit++ {
+			if op&(1<<bit) == 0 {
+				continue
+			}
+			if pos >= len(delta) {
+				return nil, errBadDelta
+			}
+			if bit < 4 {
+				offset |= int(delta[pos]) << (8 * bit)
+			} else {
+				size |= int(delta[pos]) << (8 * (bit - 4))
+			}
+			pos++
+		}
+		if size == 0 {
+			size = 0x10000
+		}
+		if offset+size > len(base) {
+			return nil, errBadDelta
+		}
+		out = append(out, base[offset:offset+size]...)
+	}
+	if len(out) != cap(out) {
+		return nil, errBadDelta
+	}
+	return out, nil
+}
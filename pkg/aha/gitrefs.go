@@ -0,0 +1,230 @@
+package aha
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// resolveRef returns the object id a ref (or HEAD) points to, following
+// symbolic refs. It returns "" for refs that do not exist, such as the
+// branch of a repository without commits.
+func (r *repository) resolveRef(name string) (string, error) {
+	for depth := 0; depth < 10; depth++ {
+		data, err := os.ReadFile(r.refPath(name))
+		if os.IsNotExist(err) {
+			return r.packedRef(name)
+		}
+		if err != nil {
+			return "", err
+		}
+		content := strings.TrimSpace(string(data))
+		target, symbolic := strings.CutPrefix(content, "ref:")
+		if !symbolic {
+			if !isObjectID(content) {
+				return "", fmt.Errorf("invalid ref %s: %q", name, content)
+			}
+			return content, nil
+		}
+		name = strings.TrimSpace(target)
+	}
+	return "", fmt.Errorf("symbolic ref loop at %s", name)
+}
+
+// refPath returns the file of a loose ref. HEAD and a few ref namespaces
+// are private to each worktree.
+func (r *repository) refPath(name string) string {
+	for _, private := range []string{"refs/bisect/", "refs/worktree/", "refs/rewritten/"} {
+		if strings.HasPrefix(name, private) {
+			return filepath.Join(r.gitDir, filepath.FromSlash(name))
+		}
+	}
+	if !strings.Contains(name, "/") {
+		return filepath.Join(r.gitDir, name)
+	}
+	return filepath.Join(r.commonDir, filepath.FromSlash(name))
+}
+
+// packedRefs parses the packed-refs file into ref name -> object id.
+func (r *repository) packedRefs() (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(r.commonDir, "packed-refs"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	refs := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		// "<id> <name>", optionally followed by "^<peeled id>"
+		id, name, ok := strings.Cut(scanner.Text(), " ")
+		if ok && isObjectID(id) {
+			refs[name] = id
+		}
+	}
+	return refs, scanner.Err()
+}
+
+func (r *repository) packedRef(name string) (string, error) {
+	refs, err := r.packedRefs()
+	if err != nil {
+		return "", err
+	}
+	return refs[name], nil
+}
+
+// refTips returns the object ids of every ref and of the HEAD of every
+// worktree: what git considers reachable, reflogs aside.
+func (r *repository) refTips() ([]string, error) {
+	var tips []string
+	packed, err := r.packedRefs()
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range packed {
+		tips = append(tips, id)
+	}
+
+	err = filepath.WalkDir(filepath.Join(r.commonDir, "refs"), func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(r.commonDir, p)
+		if err != nil {
+			return err
+		}
+		id, err := r.resolveRef(filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		if id != "" {
+			tips = append(tips, id)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read refs: %w", err)
+	}
+
+	heads := []string{filepath.Join(r.commonDir, "HEAD"), filepath.Join(r.gitDir, "HEAD")}
+	worktrees, _ := filepath.Glob(filepath.Join(r.commonDir, "worktrees", "*", "HEAD"))
+	for _, head := range append(heads, worktrees...) {
+		data, err := os.ReadFile(head)
+		if err != nil {
+			continue
+		}
+		content := strings.TrimSpace(string(data))
+		if target, ok := strings.CutPrefix(content, "ref:"); ok {
+			if content, err = r.resolveRef(strings.TrimSpace(target)); err != nil {
+				return nil, err
+			}
+		}
+		if isObjectID(content) {
+			tips = append(tips, content)
+		}
+	}
+	return tips, nil
+}
+
+// ownReflogs returns the reflogs of the work being analyzed: the HEAD of
+// this worktree, the branch it has checked out and refs/stash. Reflogs of
+// other branches, remotes and worktrees record other work.
+func (r *repository) ownReflogs() []string {
+	logs := []string{filepath.Join(r.gitDir, "logs", "HEAD"), filepath.Join(r.commonDir, "logs", "refs", "stash")}
+	if data, err := os.ReadFile(filepath.Join(r.gitDir, "HEAD")); err == nil {
+		if branch, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "ref:"); ok {
+			logs = append(logs, filepath.Join(r.commonDir, "logs", filepath.FromSlash(strings.TrimSpace(branch))))
+		}
+	}
+	return logs
+}
+
+// allReflogs returns the reflogs of all refs and of the HEAD of every
+// worktree.
+func (r *repository) allReflogs() ([]string, error) {
+	logs := []string{filepath.Join(r.commonDir, "logs", "HEAD")}
+	if r.gitDir != r.commonDir {
+		logs = append(logs, filepath.Join(r.gitDir, "logs", "HEAD"))
+	}
+	worktrees, _ := filepath.Glob(filepath.Join(r.commonDir, "worktrees", "*", "logs", "HEAD"))
+	logs = append(logs, worktrees...)
+	err := filepath.WalkDir(filepath.Join(r.commonDir, "logs", "refs"), func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			logs = append(logs, p)
+		}
+		return err
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read reflogs: %w", err)
+	}
+	return logs, nil
+}
+
+// reflogIDs returns every object id recorded in the reflogs, old and new
+// values alike. Missing reflogs are skipped.
+func reflogIDs(logs []string) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, log := range logs {
+		data, err := os.ReadFile(log)
+		if err != nil {
+			continue
+		}
+		// "<old id> <new id> <identity> <time> <zone>\t<message>"
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				continue
+			}
+			for _, id := range fields[:2] {
+				if isObjectID(id) && id != nullObject && !seen[id] {
+					seen[id] = true
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+	return ids
+}
+
+// peel follows tags to the commit they point to. It returns "" for
+// objects that are not commits, such as tags of trees.
+func (r *repository) peel(id string) (string, error) {
+	for depth := 0; depth < 10; depth++ {
+		typ, data, err := r.readObject(id)
+		if err != nil {
+			return "", err
+		}
+		switch typ {
+		case "commit":
+			return id, nil
+		case "tag":
+			header, _, _ := strings.Cut(string(data), "\n")
+			target, ok := strings.CutPrefix(header, "object ")
+			if !ok {
+				return "", fmt.Errorf("invalid tag %s", id)
+			}
+			id = target
+		default:
+			return "", nil
+		}
+	}
+	return "", nil
+}
+
+func isObjectID(s string) bool {
+	if len(s) != len(nullObject) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,412 @@
+package aha
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Errors returned instead of empty metrics when history cannot be read.
+var (
+	// ErrNotRepository is returned for paths outside a git working tree.
+	// Callers may treat it as "no history".
+	ErrNotRepository = errors.New("not a git repository")
+	// ErrShallowClone is returned for shallow clones, whose truncated
+	// history would understate every metric.
+	ErrShallowClone = errors.New("shallow clone")
+	// ErrPartialClone is returned for partial clones, whose file contents
+	// are fetched on demand and may be missing.
+	ErrPartialClone = errors.New("partial clone")
+	// ErrUnsupportedRepository is returned for repository formats the
+	// object reader does not implement (SHA-256 objects, reftables).
+	ErrUnsupportedRepository = errors.New("unsupported git repository format")
+	// ErrObjectNotFound is returned when a referenced object is missing
+	// from the object database.
+	ErrObjectNotFound = errors.New("git object not found")
+)
+
+// nullObject is the all-zeros object id git uses for "no file".
+const nullObject = "0000000000000000000000000000000000000000"
+
+// maxCachedObjects bounds the cache of decoded trees and commits.
+const maxCachedObjects = 50000
+
+// repository reads a git repository directly from its object database
+// (loose objects and packfiles) and refs, without the git binary.
+type repository struct {
+	workTree   string // Top-level directory of the working tree
+	gitDir     string // .git, or .git/worktrees/<name> for linked worktrees
+	commonDir  string // Objects, refs and config shared by all worktrees
+	objectDirs []string
+	packs      []*packFile
+	config     gitConfig
+	cache      map[string]rawObject
+	mailmap    *mailmap
+	zlib       inflater
+}
+
+// rawObject is a decoded object: its type and content.
+type rawObject struct {
+	typ  string
+	data []byte
+}
+
+// openRepository opens the repository whose working tree contains path.
+func openRepository(path string) (*repository, error) {
+	top, err := FindRepoRoot(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &repository{workTree: top, gitDir: filepath.Join(top, ".git"), cache: make(map[string]rawObject)}
+
+	// 1. Linked worktrees and submodules have a ".git" file pointing to
+	// their git directory, which may share a common directory.
+	if info, err := os.Stat(r.gitDir); err == nil && !info.IsDir() {
+		data, err := os.ReadFile(r.gitDir)
+		if err != nil {
+			return nil, err
+		}
+		target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+		if !ok {
+			return nil, fmt.Errorf("%s: %w", r.gitDir, ErrNotRepository)
+		}
+		r.gitDir = resolvePath(top, strings.TrimSpace(target))
+	}
+	r.commonDir = r.gitDir
+	if data, err := os.ReadFile(filepath.Join(r.gitDir, "commondir")); err == nil {
+		r.commonDir = resolvePath(r.gitDir, strings.TrimSpace(string(data)))
+	}
+	if info, err := os.Stat(filepath.Join(r.commonDir, "objects")); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%s: %w", top, ErrNotRepository)
+	}
+
+	// 2. Configuration, and the format extensions we cannot read
+	if r.config, err = loadGitConfig(filepath.Join(r.commonDir, "config")); err != nil {
+		return nil, err
+	}
+	if v := r.config.get("core.repositoryformatversion"); v != "" && v != "0" && v != "1" {
+		return nil, fmt.Errorf("%w: repository format version %s", ErrUnsupportedRepository, v)
+	}
+	if f := r.config.get("extensions.objectformat"); f != "" && !strings.EqualFold(f, "sha1") {
+		return nil, fmt.Errorf("%w: %s object ids", ErrUnsupportedRepository, f)
+	}
+	if s := r.config.get("extensions.refstorage"); s != "" && !strings.EqualFold(s, "files") {
+		return nil, fmt.Errorf("%w: %s ref storage", ErrUnsupportedRepository, s)
+	}
+
+	// 3. Object directories: our own and its alternates
+	if err := r.addObjectDir(filepath.Join(r.commonDir, "objects"), 0); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func resolvePath(base, p string) string {
+	if filepath.IsAbs(p) {
+		return filepath.Clean(p)
+	}
+	return filepath.Join(base, p)
+}
+
+// addObjectDir registers an object directory, its packfiles and, up to
+// git's nesting limit, its alternates.
+func (r *repository) addObjectDir(dir string, depth int) error {
+	if depth > 5 {
+		return nil
+	}
+	r.objectDirs = append(r.objectDirs, dir)
+	idxFiles, err := filepath.Glob(filepath.Join(dir, "pack", "*.idx"))
+	if err != nil {
+		return err
+	}
+	for _, idx := range idxFiles {
+		p, err := openPack(idx)
+		if err != nil {
+			return err
+		}
+		r.packs = append(r.packs, p)
+	}
+
+	alternates, err := os.ReadFile(filepath.Join(dir, "info", "alternates"))
+	if err != nil {
+		return nil
+	}
+	for _, line := range strings.Split(string(alternates), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			if err := r.addObjectDir(resolvePath(dir, line), depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// requireFullHistory reports shallow and partial clones, which are missing
+// history or file contents.
+func (r *repository) requireFullHistory() error {
+	if data, err := os.ReadFile(filepath.Join(r.commonDir, "shallow")); err == nil && len(bytes.TrimSpace(data)) > 0 {
+		n := bytes.Count(bytes.TrimSpace(data), []byte("\n")) + 1
+		return fmt.Errorf("%w with %d boundary commit(s), run `git fetch --unshallow`", ErrShallowClone, n)
+	}
+	if r.config.get("extensions.partialclone") != "" {
+		return fmt.Errorf("%w: objects are fetched on demand, run `git fetch --refetch`", ErrPartialClone)
+	}
+	return nil
+}
+
+// Close releases the packfiles.
+func (r *repository) Close() error {
+	var first error
+	for _, p := range r.packs {
+		if err := p.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// readObject returns the type and content of an object.
+func (r *repository) readObject(id string) (string, []byte, error) {
+	if o, ok := r.cache[id]; ok {
+		return o.typ, o.data, nil
+	}
+	raw, err := hex.DecodeString(id)
+	if err != nil || len(raw) != sha1.Size {
+		return "", nil, fmt.Errorf("invalid object id %q", id)
+	}
+
+	typ, data, err := r.readPacked(raw)
+	if errors.Is(err, ErrObjectNotFound) {
+		typ, data, err = r.readLoose(id)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	if typ == "tree" || typ == "commit" || typ == "tag" {
+		if len(r.cache) >= maxCachedObjects {
+			clear(r.cache)
+		}
+		r.cache[id] = rawObject{typ, data}
+	}
+	return typ, data, nil
+}
+
+func (r *repository) readPacked(id []byte) (string, []byte, error) {
+	for _, p := range r.packs {
+		if offset, ok := p.find(id); ok {
+			o, err := p.read(r, offset)
+			return o.typ, o.data, err
+		}
+	}
+	return "", nil, fmt.Errorf("%w: %x", ErrObjectNotFound, id)
+}
+
+func (r *repository) readLoose(id string) (string, []byte, error) {
+	for _, dir := range r.objectDirs {
+		f, err := os.Open(filepath.Join(dir, id[:2], id[2:]))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		defer f.Close()
+
+		// "<type> <size>\0<content>", zlib-compressed
+		z, err := r.zlib.reset(f)
+		if err != nil {
+			return "", nil, fmt.Errorf("corrupt object %s: %w", id, err)
+		}
+		br := bufio.NewReader(z)
+		header, err := br.ReadString(0)
+		if err != nil {
+			return "", nil, fmt.Errorf("corrupt object %s: %w", id, err)
+		}
+		typ, sizeStr, _ := strings.Cut(strings.TrimSuffix(header, "\x00"), " ")
+		size, err := strconv.Atoi(sizeStr)
+		if err != nil {
+			return "", nil, fmt.Errorf("corrupt object %s: bad size %q", id, sizeStr)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(br, data); err != nil {
+			return "", nil, fmt.Errorf("corrupt object %s: %w", id, err)
+		}
+		return typ, data, nil
+	}
+	return "", nil, fmt.Errorf("%w: %s", ErrObjectNotFound, id)
+}
+
+// readBlob returns the content of a blob.
+func (r *repository) readBlob(id string) ([]byte, error) {
+	typ, data, err := r.readObject(id)
+	if err != nil {
+		return nil, err
+	}
+	if typ != "blob" {
+		return nil, fmt.Errorf("object %s is a %s, not a blob", id, typ)
+	}
+	return data, nil
+}
+
+// objectType returns the type of an object, without inflating packed
+// objects.
+func (r *repository) objectType(id string) (string, error) {
+	if raw, err := hex.DecodeString(id); err == nil && len(raw) == sha1.Size {
+		for _, p := range r.packs {
+			if offset, ok := p.find(raw); ok {
+				return p.typeAt(r, offset)
+			}
+		}
+	}
+	typ, _, err := r.readObject(id)
+	return typ, err
+}
+
+// objectIDs lists every object id in the object database.
+func (r *repository) objectIDs() []string {
+	seen := make(map[string]bool)
+	var ids []string
+	add := func(id string) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, p := range r.packs {
+		for i := 0; i < p.count(); i++ {
+			add(hex.EncodeToString(p.name(i)))
+		}
+	}
+	for _, dir := range r.objectDirs {
+		fanout, _ := os.ReadDir(dir)
+		for _, d := range fanout {
+			if !d.IsDir() || len(d.Name()) != 2 {
+				continue
+			}
+			objects, _ := os.ReadDir(filepath.Join(dir, d.Name()))
+			for _, o := range objects {
+				if id := d.Name() + o.Name(); len(id) == 2*sha1.Size {
+					add(id)
+				}
+			}
+		}
+	}
+	return ids
+}
+
+// blobID computes the object id git gives to a file with this content.
+func blobID(content []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// gitConfig holds configuration values keyed by "section.key" or
+// "section.subsection.key"; section and key are lowercase. Later files
+// override earlier ones.
+type gitConfig map[string]string
+
+func (c gitConfig) get(key string) string {
+	return c[strings.ToLower(key)]
+}
+
+// loadGitConfig reads the system, global and repository configuration,
+// in increasing precedence. Missing files are skipped.
+func loadGitConfig(repoConfig string) (gitConfig, error) {
+	files := []string{"/etc/gitconfig"}
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	home, _ := os.UserHomeDir()
+	if xdg == "" && home != "" {
+		xdg = filepath.Join(home, ".config")
+	}
+	if xdg != "" {
+		files = append(files, filepath.Join(xdg, "git", "config"))
+	}
+	if home != "" {
+		files = append(files, filepath.Join(home, ".gitconfig"))
+	}
+	files = append(files, repoConfig)
+
+	c := make(gitConfig)
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read git config: %w", err)
+		}
+		c.parse(string(data))
+	}
+	return c, nil
+}
+
+// parse reads the INI-like git config syntax. Includes are not followed.
+func (c gitConfig) parse(text string) {
+	section := ""
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' {
+			end := strings.LastIndexByte(line, ']')
+			if end < 0 {
+				continue
+			}
+			name, sub, quoted := strings.Cut(line[1:end], " ")
+			section = strings.ToLower(name)
+			if quoted {
+				section += "." + strings.Trim(strings.TrimSpace(sub), `"`)
+			} else if dot := strings.IndexByte(name, '.'); dot >= 0 {
+				// Deprecated [section.subsection] syntax
+				section = strings.ToLower(name[:dot]) + "." + name[dot+1:]
+			}
+			continue
+		}
+		key, value, hasValue := strings.Cut(line, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !hasValue {
+			c[section+"."+key] = "true"
+			continue
+		}
+		c[section+"."+key] = configValue(value)
+	}
+}
+
+// configValue unquotes a config value and strips trailing comments.
+func configValue(s string) string {
+	var b strings.Builder
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case ch == '"':
+			quoted = !quoted
+		case ch == '\\' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(s[i])
+			}
+		case (ch == '#' || ch == ';') && !quoted:
+			return strings.TrimSpace(b.String())
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
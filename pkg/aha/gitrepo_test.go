@@ -0,0 +1,166 @@
+package aha
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// historyRepo builds a small history with edits, a rename and a deletion.
+func historyRepo(t *testing.T) *gitRepo {
+	r := newGitRepo(t)
+	r.commit("src/a.go", goFuncs(3), "2026-01-01T10:00:00")
+	r.commit("src/b.txt", lines(40, "b"), "2026-01-02T10:00:00")
+	r.commit("src/a.go", goFuncs(4), "2026-01-03T10:00:00")
+	if err := os.Mkdir(filepath.Join(r.dir, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	r.git("mv", "src/b.txt", "docs/b.txt")
+	r.commit("docs/b.txt", lines(42, "b"), "2026-01-04T10:00:00")
+	r.commit("tmp.txt", "scratch\n", "2026-01-05T10:00:00")
+	r.git("rm", "-q", "tmp.txt")
+	r.git("commit", "-q", "-m", "drop tmp", "--date", "2026-01-06T10:00:00")
+	return r
+}
+
+func TestAnalyzeRepoPackfiles(t *testing.T) {
+	r := historyRepo(t)
+	loose, err := AnalyzeRepo(r.dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loose) != 3 || loose["docs/b.txt"].Commits != 2 || loose["src/a.go"].Commits != 2 {
+		t.Fatalf("loose objects: %v", keys(loose))
+	}
+
+	// Aggressive repacking stores most objects as deltas.
+	r.git("gc", "-q", "--aggressive", "--prune=now")
+	if objects, _ := filepath.Glob(filepath.Join(r.dir, ".git", "objects", "??", "*")); len(objects) != 0 {
+		t.Fatalf("%d loose objects left after gc", len(objects))
+	}
+	packed, err := AnalyzeRepo(r.dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(packed, loose) {
+		t.Errorf("packed history differs:\n%+v\nwant\n%+v", packed["src/a.go"], loose["src/a.go"])
+	}
+}
+
+func TestAnalyzeRepoWorktree(t *testing.T) {
+	r := historyRepo(t)
+	want, err := AnalyzeRepo(r.dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt := filepath.Join(t.TempDir(), "wt")
+	r.git("worktree", "add", "-q", wt)
+	got, err := AnalyzeRepo(wt, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("linked worktree: %v, want %v", keys(got), keys(want))
+	}
+}
+
+func TestHistoryErrors(t *testing.T) {
+	r := historyRepo(t)
+
+	// A shallow clone must not pass for a file with one commit.
+	clone := filepath.Join(t.TempDir(), "clone")
+	r.git("clone", "-q", "--depth", "1", "file://"+r.dir, clone)
+	if _, err := AnalyzeRepo(clone, Options{}); !errors.Is(err, ErrShallowClone) {
+		t.Errorf("shallow AnalyzeRepo: %v, want ErrShallowClone", err)
+	}
+	if _, err := AnalyzeFile(filepath.Join(clone, "src", "a.go"), clone); !errors.Is(err, ErrShallowClone) {
+		t.Errorf("shallow AnalyzeFile: %v, want ErrShallowClone", err)
+	}
+	if _, err := BlameFile(filepath.Join(clone, "src", "a.go"), clone); !errors.Is(err, ErrShallowClone) {
+		t.Errorf("shallow BlameFile: %v, want ErrShallowClone", err)
+	}
+
+	plain := t.TempDir()
+	if _, err := AnalyzeRepo(plain, Options{}); !errors.Is(err, ErrNotRepository) {
+		t.Errorf("plain directory: %v, want ErrNotRepository", err)
+	}
+
+	// Files without commits have zero metrics; that is not an error.
+	if err := os.WriteFile(filepath.Join(r.dir, "new.txt"), []byte("new\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if m, err := AnalyzeFile(filepath.Join(r.dir, "new.txt"), r.dir); err != nil || m.Commits != 0 {
+		t.Errorf("uncommitted file: %+v, %v", m, err)
+	}
+
+	// A missing blob is reported, not skipped.
+	blob := strings.TrimSpace(r.git("rev-parse", "HEAD~2:docs/b.txt"))
+	if err := os.Remove(filepath.Join(r.dir, ".git", "objects", blob[:2], blob[2:])); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AnalyzeRepo(r.dir, Options{}); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("missing object: %v, want ErrObjectNotFound", err)
+	}
+
+	r.git("config", "extensions.objectFormat", "sha256")
+	if _, err := AnalyzeRepo(r.dir, Options{}); !errors.Is(err, ErrUnsupportedRepository) {
+		t.Errorf("sha256 repository: %v, want ErrUnsupportedRepository", err)
+	}
+}
+
+func TestTrailers(t *testing.T) {
+	tests := []struct {
+		message string
+		want    []string
+	}{
+		{"subject\n\nHCP-Signature: abc\n", []string{"abc"}},
+		{"subject\n\nbody\n\nhcp-signature: abc\nOther: x\n", []string{"abc"}},
+		{"HCP-Signature: abc\n", nil}, // Title only
+		{"subject\n\nHCP-Signature: abc\nfree text\n", nil},
+		{"subject\n\nHCP-Signature: abc\n  def\nSigned-off-by: A <a@b>\nnote\n", []string{"abc def"}},
+	}
+	for _, tt := range tests {
+		if got := trailers(tt.message, "HCP-Signature"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("trailers(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreMatcher(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	m := &ignoreMatcher{}
+	m.load(write("top", "*.log\n!keep.log\n/build/\ndocs/**/*.tmp\n"), "")
+	m.load(write("sub", "local.txt\n"), "sub")
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"a.log", false, true},
+		{"deep/a.log", false, true},
+		{"keep.log", false, false},
+		{"build", true, true},
+		{"build/out.bin", false, true},
+		{"src/build", true, false},
+		{"docs/a/b/x.tmp", false, true},
+		{"docs/x.tmp", false, true},
+		{"sub/local.txt", false, true},
+		{"local.txt", false, false},
+	}
+	for _, tt := range tests {
+		if got := m.ignored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("ignored(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
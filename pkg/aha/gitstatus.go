@@ -0,0 +1,219 @@
+package aha
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// worktreeChanges returns the working-tree files whose content differs
+// from headTree, and the untracked files not ignored by .gitignore, in path
+// order, like `git status --untracked-files=all`. Deleted files, nested
+// repositories and the .hcp directory are left out.
+func (r *repository) worktreeChanges(headTree string) ([]string, error) {
+	tracked := make(map[string]string)
+	trackedDirs := make(map[string]bool)
+	err := r.listFiles(headTree, "", func(p string, e treeEntry) {
+		tracked[p] = e.id
+		for dir := path.Dir(p); dir != "."; dir = path.Dir(dir) {
+			trackedDirs[dir] = true
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ignore := &ignoreMatcher{}
+	if excludes := r.config.get("core.excludesfile"); excludes != "" {
+		ignore.load(expandHome(excludes), "")
+	} else if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		ignore.load(filepath.Join(xdg, "git", "ignore"), "")
+	} else if home, err := os.UserHomeDir(); err == nil {
+		ignore.load(filepath.Join(home, ".config", "git", "ignore"), "")
+	}
+	ignore.load(filepath.Join(r.commonDir, "info", "exclude"), "")
+
+	var paths []string
+	err = filepath.WalkDir(r.workTree, func(full string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(r.workTree, full)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel == "." {
+				ignore.load(filepath.Join(full, ".gitignore"), "")
+				return nil
+			}
+			if d.Name() == ".git" || rel == ".hcp" {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(full, ".git")); err == nil {
+				return filepath.SkipDir // Nested repository or submodule
+			}
+			if !trackedDirs[rel] && ignore.ignored(rel, true) {
+				return filepath.SkipDir
+			}
+			ignore.load(filepath.Join(full, ".gitignore"), rel)
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if id, ok := tracked[rel]; ok {
+			if data, err := os.ReadFile(full); err == nil && blobID(data) != id {
+				paths = append(paths, rel)
+			}
+			return nil
+		}
+		if !ignore.ignored(rel, false) {
+			paths = append(paths, rel)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+func expandHome(p string) string {
+	if rest, ok := strings.CutPrefix(p, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return p
+}
+
+// ignoreMatcher evaluates gitignore(5) patterns. Later rules take
+// precedence, so files are loaded from the lowest priority (global
+// excludes) to the highest (the deepest .gitignore).
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	base     string // Directory of the .gitignore, "" for the top
+	re       *regexp.Regexp
+	negate   bool
+	dirOnly  bool
+	basename bool // No slash in the pattern: match the name at any depth
+}
+
+// load adds the patterns of a file, relative to base. Missing files are
+// skipped.
+func (m *ignoreMatcher) load(file, base string) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if !strings.HasSuffix(line, `\ `) {
+			line = strings.TrimRight(line, " ")
+		}
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		rule := ignoreRule{base: base}
+		if line[0] == '!' {
+			rule.negate, line = true, line[1:]
+		} else if line[0] == '\\' {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly, line = true, strings.TrimSuffix(line, "/")
+		}
+		rule.basename = !strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+		re, err := regexp.Compile("^" + globToRegexp(line) + "$")
+		if err != nil {
+			continue
+		}
+		rule.re = re
+		m.rules = append(m.rules, rule)
+	}
+}
+
+// globToRegexp translates a gitignore glob: "*" and "?" stay within a
+// path segment, "**" spans segments.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// excluded reports whether the last rule matching p excludes it.
+func (m *ignoreMatcher) excluded(p string, isDir bool) bool {
+	for i := len(m.rules) - 1; i >= 0; i-- {
+		rule := m.rules[i]
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		rel := p
+		if rule.base != "" {
+			var ok bool
+			if rel, ok = strings.CutPrefix(p, rule.base+"/"); !ok {
+				continue
+			}
+		}
+		if rule.basename {
+			rel = path.Base(rel)
+		}
+		if rule.re.MatchString(rel) {
+			return !rule.negate
+		}
+	}
+	return false
+}
+
+// ignored reports whether p or one of its parent directories is excluded;
+// files in an excluded directory cannot be re-included.
+func (m *ignoreMatcher) ignored(p string, isDir bool) bool {
+	for i := 0; i < len(p); i++ {
+		if p[i] == '/' && m.excluded(p[:i], true) {
+			return true
+		}
+	}
+	return m.excluded(p, isDir)
+}
@@ -0,0 +1,115 @@
+package aha
+
+import "github.com/windgeek/HCP/pkg/identity"
+
+// commitRecord is one commit with the files it changed (see repository.record).
+type commitRecord struct {
+	Hash        string
+	Date        string                 // Author date, YYYY-MM-DD
+	Time        int64                  // Author date, Unix seconds
+	AuthorName  string                 // Mailmap-resolved author name
+	AuthorEmail string                 // Mailmap-resolved author email
+	Payload     identity.CommitPayload // Signed by HCP-Signature trailers
+	Signatures  []string               // Values of HCP-Signature trailers
+	Changes     []fileChange
+}
+
+// fileChange is one file touched by a commit.
+type fileChange struct {
+	Status  byte   // A, M, D, R, T ...
+	OldPath string // Source path of a rename, empty otherwise
+	Path    string
+	OldBlob string // Blob before the change, all zeros for additions
+	NewBlob string // Blob after the change, all zeros for deletions
+	Added   int
+	Deleted int
+}
+
+// metricsBuilder accumulates the commits of one file into AHAMetrics.
+// Every commit is credited to its contributor; only counted commits feed
+// the scored metrics.
+type metricsBuilder struct {
+	metrics      AHAMetrics
+	commits      map[string]bool
+	days         map[string]bool
+	contributors map[string]*Contribution
+	revisions    []revision      // Newest first
+	versions     map[string]bool // Blob ids of every revision seen
+}
+
+// revision is one version of a file written by a commit.
+type revision struct {
+	commit, author, blob string
+}
+
+func newMetricsBuilder() *metricsBuilder {
+	return &metricsBuilder{
+		commits:      make(map[string]bool),
+		days:         make(map[string]bool),
+		contributors: make(map[string]*Contribution),
+		versions:     make(map[string]bool),
+	}
+}
+
+func (b *metricsBuilder) add(rec *commitRecord, change fileChange, contributor string, counted bool) {
+	c, ok := b.contributors[contributor]
+	if !ok {
+		c = &Contribution{Counted: counted}
+		b.contributors[contributor] = c
+	}
+	c.Counted = c.Counted || counted
+	if !b.commits[rec.Hash] {
+		c.Commits++
+	}
+	c.LinesAdded += change.Added
+	c.LinesDeleted += change.Deleted
+	b.revisions = append(b.revisions, revision{commit: rec.Hash, author: contributor, blob: change.NewBlob})
+	b.versions[change.NewBlob] = true
+
+	if counted && !b.commits[rec.Hash] {
+		b.metrics.Commits++
+		b.days[rec.Date] = true
+	}
+	b.commits[rec.Hash] = true
+	if counted {
+		b.metrics.addChange(change.Added, change.Deleted)
+	}
+}
+
+// addMicro credits a revision from outside the history of HEAD. Only
+// versions of the file not seen before count: a rebased commit or the
+// index half of a stash adds no evidence beyond its counterpart.
+func (b *metricsBuilder) addMicro(rec *commitRecord, change fileChange) {
+	if isNullObject(change.NewBlob) || b.versions[change.NewBlob] {
+		return
+	}
+	b.versions[change.NewBlob] = true
+	b.metrics.MicroRevisions++
+	b.days[rec.Date] = true
+}
+
+// build finalizes the metrics. With repo, the revisions are replayed to
+// find the iterated share of the surviving lines.
+func (b *metricsBuilder) build(repo *repository) *AHAMetrics {
+	m := b.metrics
+	m.EditingDays = len(b.days)
+	if repo != nil {
+		var t lineTracker
+		for i := len(b.revisions) - 1; i >= 0; i-- {
+			r := b.revisions[i]
+			if t.replay(repo, r.blob, r.commit, r.author) != nil {
+				// Unreadable revision: restart the lineage from the next one.
+				t = lineTracker{}
+			}
+		}
+		m.IteratedShare = t.blame().IteratedShare
+	}
+	if len(b.contributors) > 0 {
+		m.Contributors = make(map[string]Contribution, len(b.contributors))
+		for who, c := range b.contributors {
+			m.Contributors[who] = *c
+		}
+	}
+	m.finish()
+	return &m
+}
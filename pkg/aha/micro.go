@@ -0,0 +1,76 @@
+package aha
+
+import (
+	"fmt"
+	"sort"
+)
+
+// microHistory returns the commits that are not part of the history of
+// HEAD but left traces in the repository: commits only reachable from the
+// reflogs of HEAD and its branch (amended, rebased or reset away, including
+// squashed branches), stashes, and dangling commits such as dropped
+// stashes. Other branches and remotes hold other work and are left out. Stashes and other
+// merges are diffed against their first parent. Changes are restricted to
+// the files below prefix.
+func (r *repository) microHistory(prefix string) ([]commitRecord, error) {
+	head, err := r.head()
+	if err != nil {
+		return nil, err
+	}
+	inHead, err := r.reachable([]string{head})
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Reflogs of HEAD, its branch and refs/stash, like
+	// `git log --reflog --not HEAD` restricted to these reflogs
+	var records []commitRecord
+	seen := make(map[string]bool)
+	err = r.walk(reflogIDs(r.ownReflogs()), inHead, func(c *commitObject) error {
+		seen[c.id] = true
+		rec, err := r.record(c, prefix, true)
+		records = append(records, rec)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reflog history: %w", err)
+	}
+
+	// 2. Commits unreachable from any ref or reflog, like
+	// `git fsck --unreachable`, newest first
+	tips, err := r.refTips()
+	if err != nil {
+		return nil, err
+	}
+	logs, err := r.allReflogs()
+	if err != nil {
+		return nil, err
+	}
+	reachable, err := r.reachable(append(tips, reflogIDs(logs)...))
+	if err != nil {
+		return nil, err
+	}
+	var unreachable []*commitObject
+	for _, id := range r.objectIDs() {
+		if reachable[id] || seen[id] || inHead[id] {
+			continue
+		}
+		if typ, err := r.objectType(id); err != nil || typ != "commit" {
+			continue
+		}
+		c, err := r.readCommit(id)
+		if err != nil {
+			return nil, err
+		}
+		unreachable = append(unreachable, c)
+	}
+	sort.SliceStable(unreachable, func(i, j int) bool { return unreachable[i].commitTime > unreachable[j].commitTime })
+	for _, c := range unreachable {
+		rec, err := r.record(c, prefix, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dangling commit %s: %w", c.id, err)
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
@@ -0,0 +1,116 @@
+package aha
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/windgeek/HCP/pkg/identity"
+)
+
+func TestAnalyzeRepoMicroHistory(t *testing.T) {
+	r := newGitRepo(t)
+	r.git("config", "user.email", "alice@example.com")
+	r.commit("a.txt", lines(10, "a"), "2026-01-01T10:00:00")
+	main := r.git("rev-parse", "--abbrev-ref", "HEAD")
+	main = main[:len(main)-1]
+
+	// A feature branch, squash-merged and deleted
+	r.git("checkout", "-q", "-b", "feature")
+	r.commit("a.txt", lines(12, "a"), "2026-01-02T10:00:00")
+	r.commit("a.txt", lines(14, "a"), "2026-01-03T10:00:00")
+	r.git("checkout", "-q", main)
+	r.git("merge", "-q", "--squash", "feature")
+	r.git("commit", "-q", "-m", "feature", "--date", "2026-01-03T12:00:00")
+	r.git("branch", "-q", "-D", "feature")
+
+	// A kept and a dropped stash
+	r.write("a.txt", lines(15, "a"))
+	r.git("stash", "-q")
+	r.write("a.txt", lines(16, "a"))
+	r.git("stash", "-q")
+	r.git("stash", "drop", "-q")
+
+	// Two working-tree snapshots
+	r.write("a.txt", lines(14, "a")+"wip\n")
+	if s, err := TakeSnapshot(r.dir); err != nil || s == nil {
+		t.Fatalf("TakeSnapshot = %v, %v", s, err)
+	}
+	r.write("a.txt", lines(14, "a")+"more wip\n")
+	s, err := TakeSnapshot(r.dir)
+	if err != nil || s == nil {
+		t.Fatalf("TakeSnapshot = %v, %v", s, err)
+	}
+	if f := s.Files[0]; f.Path != "a.txt" || f.Added != 1 || f.Deleted != 1 {
+		t.Errorf("snapshot file = %+v, want one line replaced since the previous snapshot", f)
+	}
+	if s, err := TakeSnapshot(r.dir); err != nil || s != nil {
+		t.Errorf("unchanged tree: TakeSnapshot = %v, %v, want nil", s, err)
+	}
+	if err := os.WriteFile(filepath.Join(r.dir, "a.txt"), []byte(lines(14, "a")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	plain, err := AnalyzeRepo(r.dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	micro, err := AnalyzeRepo(r.dir, Options{MicroHistory: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain["a.txt"].Commits != 2 || plain["a.txt"].MicroRevisions != 0 {
+		t.Errorf("plain = %d commits, %d micro revisions, want 2 and 0", plain["a.txt"].Commits, plain["a.txt"].MicroRevisions)
+	}
+	// The first squashed commit (the second matches the squash), 2 stashes
+	// and 2 snapshots
+	if got := micro["a.txt"].MicroRevisions; got != 5 {
+		t.Errorf("micro revisions = %d, want 5", got)
+	}
+	if micro["a.txt"].AHAScore <= plain["a.txt"].AHAScore {
+		t.Errorf("micro-history score %v not above plain score %v", micro["a.txt"].AHAScore, plain["a.txt"].AHAScore)
+	}
+}
+
+func TestMicroHistoryScope(t *testing.T) {
+	r := newGitRepo(t)
+	r.commit("a.txt", lines(10, "a"), "2026-01-01T10:00:00")
+	head := strings.TrimSpace(r.git("rev-parse", "HEAD"))
+
+	// Commits of other branches and remotes, made without checking them out
+	other := func(n int) string {
+		r.write("a.txt", lines(n, "a"))
+		r.git("add", "a.txt")
+		tree := strings.TrimSpace(r.git("write-tree"))
+		return strings.TrimSpace(r.git("commit-tree", tree, "-p", head, "-m", "other work"))
+	}
+	r.git("update-ref", "--create-reflog", "refs/heads/topic", other(20))
+	r.git("update-ref", "--create-reflog", "refs/remotes/origin/main", other(21))
+	r.git("update-ref", "refs/remotes/origin/main", head) // Force-pushed away
+	r.write("a.txt", lines(10, "a"))
+	r.git("add", "a.txt")
+
+	m, err := AnalyzeRepo(r.dir, Options{MicroHistory: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m["a.txt"].MicroRevisions; got != 0 {
+		t.Errorf("micro revisions = %d, want 0 from other branches and remotes", got)
+	}
+
+	// A stash counts even when only signed commits do
+	key, err := identity.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.write("a.txt", lines(11, "a"))
+	r.git("stash", "-q")
+	m, err = AnalyzeRepo(r.dir, Options{MicroHistory: true, SigningKey: key.PubKey()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m["a.txt"]; got.Commits != 0 || got.MicroRevisions != 1 {
+		t.Errorf("signed commits only: %d commits, %d micro revisions, want 0 and 1", got.Commits, got.MicroRevisions)
+	}
+}
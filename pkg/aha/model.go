@@ -0,0 +1,206 @@
+package aha
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/windgeek/HCP/pkg/cognitive"
+)
+
+// ScoringModel turns the metrics of a file into its 0-100 AHA score.
+// Manifests record the model's Spec so verifiers can recompute scores.
+type ScoringModel interface {
+	Name() string
+	Spec() ModelSpec
+	Score(m *AHAMetrics) float64
+}
+
+// ModelSpec identifies a scoring model and its parameters. Models with
+// features are weighted models; the others are looked up by name among
+// the built-in ones.
+type ModelSpec struct {
+	Name     string    `json:"name"`
+	Features []Feature `json:"features,omitempty"`
+}
+
+// Feature is one weighted term of a weighted model: it contributes
+// Weight * min(value / Cap, 1) points.
+type Feature struct {
+	Metric string  `json:"metric"`
+	Weight float64 `json:"weight"`
+	Cap    float64 `json:"cap"`
+}
+
+// featureMetrics are the metrics a Feature can weigh.
+var featureMetrics = map[string]func(m *AHAMetrics) float64{
+	"commits":             func(m *AHAMetrics) float64 { return float64(m.Commits) },
+	"micro_revisions":     func(m *AHAMetrics) float64 { return float64(m.MicroRevisions) },
+	"iterations":          func(m *AHAMetrics) float64 { return float64(m.Commits + m.MicroRevisions) },
+	"editing_days":        func(m *AHAMetrics) float64 { return float64(m.EditingDays) },
+	"modification_ratio":  func(m *AHAMetrics) float64 { return m.ModificationRatio },
+	"rewritten_lines":     func(m *AHAMetrics) float64 { return float64(m.RewrittenLines) },
+	"refactorings":        func(m *AHAMetrics) float64 { return float64(m.Refactorings.Refactorings()) },
+	"iterated_share":      func(m *AHAMetrics) float64 { return m.IteratedShare },
+	"halstead_volume":     func(m *AHAMetrics) float64 { return halstead(m).Volume },
+	"halstead_difficulty": func(m *AHAMetrics) float64 { return halstead(m).Difficulty },
+	"halstead_effort":     func(m *AHAMetrics) float64 { return halstead(m).Effort },
+	// 1 - largest_insertion / lines_added: lines arrived over many commits
+	"granularity": func(m *AHAMetrics) float64 {
+		if m.LinesAdded == 0 {
+			return 0
+		}
+		return 1 - float64(m.LargestInsertion)/float64(m.LinesAdded)
+	},
+	// refactorings / (refactorings + pure_insertions) of Go functions
+	"refactor_share": func(m *AHAMetrics) float64 {
+		r := m.Refactorings.Refactorings()
+		if edits := r + m.Refactorings.pureInsertions(); edits > 0 {
+			return float64(r) / float64(edits)
+		}
+		return 0
+	},
+}
+
+// halstead returns the Halstead measures of m, zero when unknown.
+func halstead(m *AHAMetrics) cognitive.Halstead {
+	if m.Halstead == nil {
+		return cognitive.Halstead{}
+	}
+	return *m.Halstead
+}
+
+// UsesHalstead reports whether the model weighs Halstead measures, which
+// are not recorded with the metrics and must be measured on the file.
+func (s ModelSpec) UsesHalstead() bool {
+	for _, f := range s.Features {
+		if strings.HasPrefix(f.Metric, "halstead_") {
+			return true
+		}
+	}
+	return false
+}
+
+// FeatureMetrics lists the metric names a Feature accepts.
+func FeatureMetrics() []string {
+	names := make([]string, 0, len(featureMetrics))
+	for name := range featureMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultModel is the model behind AHAMetrics.AHAScore (see Score).
+var DefaultModel ScoringModel = ahaV4{}
+
+type ahaV4 struct{}
+
+func (ahaV4) Name() string                { return ScoreVersion }
+func (ahaV4) Spec() ModelSpec             { return ModelSpec{Name: ScoreVersion} }
+func (ahaV4) Score(m *AHAMetrics) float64 { return Score(m) }
+
+// builtinModels are the models selectable by name. Weighted ones carry
+// their features into the manifest like config-defined models.
+var builtinModels = map[string]ScoringModel{
+	ScoreVersion: DefaultModel,
+	// Documentation: sustained editing over time
+	"persistence-v1": mustWeighted("persistence-v1", []Feature{
+		{Metric: "editing_days", Weight: 50, Cap: 10},
+		{Metric: "iterations", Weight: 30, Cap: 10},
+		{Metric: "granularity", Weight: 20, Cap: 1},
+	}),
+	// Code: restructuring rather than accretion
+	"refactoring-v1": mustWeighted("refactoring-v1", []Feature{
+		{Metric: "refactor_share", Weight: 40, Cap: 1},
+		{Metric: "modification_ratio", Weight: 20, Cap: 1},
+		{Metric: "iterated_share", Weight: 20, Cap: 1},
+		{Metric: "iterations", Weight: 20, Cap: 10},
+	}),
+}
+
+// BuiltinModel returns the built-in model with the given name.
+func BuiltinModel(name string) (ScoringModel, bool) {
+	m, ok := builtinModels[name]
+	return m, ok
+}
+
+// BuiltinModels lists the names of the built-in models.
+func BuiltinModels() []string {
+	names := make([]string, 0, len(builtinModels))
+	for name := range builtinModels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ModelFromSpec rebuilds the model a manifest was scored with.
+func ModelFromSpec(spec ModelSpec) (ScoringModel, error) {
+	if len(spec.Features) > 0 {
+		return NewWeightedModel(spec.Name, spec.Features)
+	}
+	if m, ok := BuiltinModel(spec.Name); ok {
+		return m, nil
+	}
+	return nil, fmt.Errorf("unknown scoring model %q (built-in: %s)", spec.Name, strings.Join(BuiltinModels(), ", "))
+}
+
+// weightedModel scores the sum of its capped, weighted features.
+type weightedModel struct {
+	name     string
+	features []Feature
+}
+
+// NewWeightedModel builds a model from weighted features. Weights are the
+// points each feature contributes at its cap and must add up to 100.
+func NewWeightedModel(name string, features []Feature) (ScoringModel, error) {
+	if name == "" {
+		return nil, fmt.Errorf("scoring model needs a name")
+	}
+	if len(features) == 0 {
+		return nil, fmt.Errorf("scoring model %s has no features", name)
+	}
+	total := 0.0
+	for _, f := range features {
+		if _, ok := featureMetrics[f.Metric]; !ok {
+			return nil, fmt.Errorf("scoring model %s: unknown metric %q (known: %s)", name, f.Metric, strings.Join(FeatureMetrics(), ", "))
+		}
+		if f.Weight <= 0 || f.Cap <= 0 {
+			return nil, fmt.Errorf("scoring model %s: %s needs a positive weight and cap", name, f.Metric)
+		}
+		total += f.Weight
+	}
+	if math.Abs(total-100) > 1e-9 {
+		return nil, fmt.Errorf("scoring model %s: weights add up to %g, want 100", name, total)
+	}
+	return &weightedModel{name: name, features: append([]Feature(nil), features...)}, nil
+}
+
+func mustWeighted(name string, features []Feature) ScoringModel {
+	m, err := NewWeightedModel(name, features)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func (w *weightedModel) Name() string { return w.name }
+
+func (w *weightedModel) Spec() ModelSpec {
+	return ModelSpec{Name: w.name, Features: append([]Feature(nil), w.features...)}
+}
+
+// Score sums Weight * min(value / Cap, 1) over the features. Files
+// without history score 0.
+func (w *weightedModel) Score(m *AHAMetrics) float64 {
+	if m.Commits == 0 {
+		return 0
+	}
+	score := 0.0
+	for _, f := range w.features {
+		score += f.Weight * min(featureMetrics[f.Metric](m)/f.Cap, 1)
+	}
+	return roundTo(min(max(score, 0), 100), 1)
+}
@@ -0,0 +1,91 @@
+package aha
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/windgeek/HCP/pkg/cognitive"
+)
+
+func TestWeightedModel(t *testing.T) {
+	m := &AHAMetrics{Commits: 10, LinesAdded: 300, LinesDeleted: 100, LargestInsertion: 60, EditingDays: 5, RewrittenLines: 50}
+
+	custom, err := NewWeightedModel("docs", []Feature{
+		{Metric: "editing_days", Weight: 60, Cap: 20},
+		{Metric: "rewritten_lines", Weight: 40, Cap: 100},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 60 * 5/20 + 40 * 50/100
+	if s := custom.Score(m); s != 35 {
+		t.Errorf("custom score = %v, want 35", s)
+	}
+
+	persistence, _ := BuiltinModel("persistence-v1")
+	// 50 * 5/10 + 30 * 1 (capped) + 20 * (1 - 60/300)
+	if s := persistence.Score(m); s != 71 {
+		t.Errorf("persistence-v1 score = %v, want 71", s)
+	}
+	if s := persistence.Score(&AHAMetrics{}); s != 0 {
+		t.Errorf("untracked score = %v, want 0", s)
+	}
+
+	// Files with no Halstead measures score 0 on them.
+	effort, err := NewWeightedModel("effort", []Feature{{Metric: "halstead_effort", Weight: 100, Cap: 1000}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := effort.Score(m); s != 0 {
+		t.Errorf("effort score without measures = %v, want 0", s)
+	}
+	m.Halstead = &cognitive.Halstead{Effort: 250}
+	if s := effort.Score(m); s != 25 {
+		t.Errorf("effort score = %v, want 25", s)
+	}
+
+	m.finish()
+	if s := DefaultModel.Score(m); s != m.AHAScore || DefaultModel.Name() != ScoreVersion {
+		t.Errorf("default model score = %v, want %v", s, m.AHAScore)
+	}
+}
+
+func TestModelFromSpec(t *testing.T) {
+	for _, name := range BuiltinModels() {
+		model, _ := BuiltinModel(name)
+		// Specs travel through the manifest as JSON.
+		data, err := json.Marshal(model.Spec())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var spec ModelSpec
+		if err := json.Unmarshal(data, &spec); err != nil {
+			t.Fatal(err)
+		}
+		rebuilt, err := ModelFromSpec(spec)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(rebuilt.Spec(), model.Spec()) {
+			t.Errorf("%s: rebuilt spec %+v", name, rebuilt.Spec())
+		}
+	}
+
+	tests := []struct {
+		spec ModelSpec
+		want string
+	}{
+		{ModelSpec{Name: "aha-v9"}, "unknown scoring model"},
+		{ModelSpec{Name: "m", Features: []Feature{{Metric: "stars", Weight: 100, Cap: 1}}}, "unknown metric"},
+		{ModelSpec{Name: "m", Features: []Feature{{Metric: "commits", Weight: 100}}}, "positive weight and cap"},
+		{ModelSpec{Name: "m", Features: []Feature{{Metric: "commits", Weight: 90, Cap: 10}}}, "add up to 90"},
+		{ModelSpec{Features: []Feature{{Metric: "commits", Weight: 100, Cap: 10}}}, "needs a name"},
+	}
+	for _, tt := range tests {
+		if _, err := ModelFromSpec(tt.spec); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ModelFromSpec(%+v) = %v, want %q", tt.spec, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package aha
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/windgeek/HCP/pkg/cognitive"
+)
+
+// Human range of the cognitive velocity (RFC-002 §3.2): cyclomatic
+// complexity added to a file per minute since the author's previous commit.
+const (
+	MinHumanVelocity = 0.1
+	MaxHumanVelocity = 10.0
+)
+
+// PasteFlag kinds.
+const (
+	FlagPaste = "paste" // High complexity / low time: suspected AI paste
+	FlagPause = "pause" // Low complexity / high time: cognitive pause
+)
+
+// PasteFlag marks a commit whose cognitive velocity on a file is outside
+// the human range.
+type PasteFlag struct {
+	Commit          string  `json:"commit"`
+	Author          string  `json:"author"`
+	ComplexityAdded int     `json:"complexity_added"` // Cyclomatic complexity after minus before
+	ElapsedMinutes  float64 `json:"elapsed_minutes"`  // Since the author's previous commit
+	Velocity        float64 `json:"velocity"`         // complexity_added / elapsed_minutes
+	Kind            string  `json:"kind"`
+}
+
+// pasteDetector computes the cognitive velocity of file changes.
+type pasteDetector struct {
+	repo       *repository
+	complexity map[string]int // Cyclomatic complexity by blob id, -1 if not analyzable
+	previous   []int64        // Per record: time of the author's previous commit, 0 if none
+}
+
+func newPasteDetector(repo *repository, records []commitRecord, opts Options) *pasteDetector {
+	d := &pasteDetector{
+		repo:       repo,
+		complexity: make(map[string]int),
+		previous:   make([]int64, len(records)),
+	}
+	// Records are newest first; walk them oldest first.
+	last := make(map[string]int64)
+	for i := len(records) - 1; i >= 0; i-- {
+		who := opts.contributor(&records[i])
+		d.previous[i] = last[who]
+		last[who] = records[i].Time
+	}
+	return d
+}
+
+// check returns a flag if change, made by record i, adds complexity at a
+// velocity outside the human range. An author's first commit has no
+// reference time and is never flagged.
+func (d *pasteDetector) check(i int, rec *commitRecord, change fileChange, author string) (PasteFlag, bool) {
+	if d.previous[i] == 0 || change.Added == 0 {
+		return PasteFlag{}, false
+	}
+
+	after, ok := d.cyclomatic(change.Path, change.NewBlob)
+	if !ok {
+		return PasteFlag{}, false
+	}
+	before := 0
+	if !isNullObject(change.OldBlob) {
+		oldPath := change.Path
+		if change.OldPath != "" {
+			oldPath = change.OldPath
+		}
+		if before, ok = d.cyclomatic(oldPath, change.OldBlob); !ok {
+			return PasteFlag{}, false
+		}
+	}
+	added := after - before
+	if added <= 0 {
+		return PasteFlag{}, false
+	}
+
+	elapsed := float64(max(rec.Time-d.previous[i], 1)) / 60
+	velocity := float64(added) / elapsed
+
+	flag := PasteFlag{
+		Commit:          rec.Hash,
+		Author:          author,
+		ComplexityAdded: added,
+		ElapsedMinutes:  roundTo(elapsed, 2),
+		Velocity:        roundTo(velocity, 3),
+	}
+	switch {
+	case velocity > MaxHumanVelocity:
+		flag.Kind = FlagPaste
+	case velocity < MinHumanVelocity:
+		flag.Kind = FlagPause
+	default:
+		return PasteFlag{}, false
+	}
+	return flag, true
+}
+
+func (d *pasteDetector) cyclomatic(path, blob string) (int, bool) {
+	if c, ok := d.complexity[blob]; ok {
+		return c, c >= 0
+	}
+	c := -1
+	if data, err := d.repo.readBlob(blob); err == nil && bytes.IndexByte(data, 0) < 0 {
+		if stats, err := cognitive.AnalyzeSource(path, data); err == nil {
+			c = stats.Cyclomatic
+		}
+	}
+	d.complexity[blob] = c
+	return c, c >= 0
+}
+
+func isNullObject(id string) bool {
+	return strings.Trim(id, "0") == ""
+}
@@ -0,0 +1,51 @@
+package aha
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// goFuncs returns a Go file with n functions of cyclomatic complexity 2.
+func goFuncs(n int) string {
+	var sb strings.Builder
+	sb.WriteString("package p\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "\nfunc f%d(x int) int {\n\tif x > %d {\n\t\treturn x\n\t}\n\treturn %d\n}\n", i, i, i)
+	}
+	return sb.String()
+}
+
+func TestAnalyzeRepoPasteFlags(t *testing.T) {
+	r := newGitRepo(t)
+	r.commit("notes.txt", "start\n", "2026-01-01T09:00:00")
+	r.commit("p.go", goFuncs(40), "2026-01-01T09:01:00") // +80 in 1 minute
+	r.commit("p.go", goFuncs(43), "2026-01-01T09:21:00") // +6 in 20 minutes
+	r.commit("p.go", goFuncs(44), "2026-01-02T09:21:00") // +2 in a day
+	r.commit("p.go", goFuncs(42), "2026-01-02T09:30:00") // removes complexity
+
+	repo, err := AnalyzeRepo(r.dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	flags := repo["p.go"].PasteFlags
+	if len(flags) != 2 {
+		t.Fatalf("flags = %+v, want a pause and a paste", flags)
+	}
+
+	pause, paste := flags[0], flags[1] // newest first
+	if paste.Kind != FlagPaste || paste.ComplexityAdded != 80 || paste.ElapsedMinutes != 1 || paste.Velocity != 80 {
+		t.Errorf("paste flag = %+v", paste)
+	}
+	if pause.Kind != FlagPause || pause.ComplexityAdded != 2 || pause.ElapsedMinutes != 1440 {
+		t.Errorf("pause flag = %+v", pause)
+	}
+	if paste.Author != "alice@example.com" {
+		t.Errorf("author = %q", paste.Author)
+	}
+
+	// The author's first commit has no reference time.
+	if f := repo["notes.txt"].PasteFlags; len(f) != 0 {
+		t.Errorf("first commit flagged: %+v", f)
+	}
+}
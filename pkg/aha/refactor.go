@@ -0,0 +1,337 @@
+package aha
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strings"
+)
+
+// RefactoringCounts classifies the function-level edits of a file's Go
+// history (RFC-002 §3.1 Refactoring Vectors).
+type RefactoringCounts struct {
+	ExtractFunction int `json:"extract_function"` // New function made of statements taken from another
+	Rename          int `json:"rename"`           // Function or local identifiers renamed, logic unchanged
+	Inline          int `json:"inline"`           // Function removed, its statements merged into a caller
+	SignatureChange int `json:"signature_change"` // Parameters or results changed
+	Move            int `json:"move"`             // Function reordered or moved to another file
+	PureInsertion   int `json:"pure_insertion"`   // New function unrelated to existing code
+}
+
+// Refactorings is the number of edits that restructure existing code, i.e.
+// everything but pure insertions.
+func (c *RefactoringCounts) Refactorings() int {
+	if c == nil {
+		return 0
+	}
+	return c.ExtractFunction + c.Rename + c.Inline + c.SignatureChange + c.Move
+}
+
+func (c *RefactoringCounts) pureInsertions() int {
+	if c == nil {
+		return 0
+	}
+	return c.PureInsertion
+}
+
+func (c *RefactoringCounts) add(o RefactoringCounts) {
+	c.ExtractFunction += o.ExtractFunction
+	c.Rename += o.Rename
+	c.Inline += o.Inline
+	c.SignatureChange += o.SignatureChange
+	c.Move += o.Move
+	c.PureInsertion += o.PureInsertion
+}
+
+// addRefactorings counts the edits o of one commit. Files without any keep
+// a nil Refactorings, which manifests omit.
+func (m *AHAMetrics) addRefactorings(o RefactoringCounts) {
+	if o == (RefactoringCounts{}) {
+		return
+	}
+	if m.Refactorings == nil {
+		m.Refactorings = &RefactoringCounts{}
+	}
+	m.Refactorings.add(o)
+}
+
+// funcDecl is one function of one revision of a Go file.
+type funcDecl struct {
+	path   string
+	key    string   // "Recv.Name" for methods, "Name" otherwise
+	sig    string   // Printed receiver, parameters and results
+	body   string   // Printed body
+	shape  string   // Printed body with every identifier blanked
+	idents []string // Identifiers of the body in source order
+	stmts  []string // Printed statements at any depth
+}
+
+// refactorDetector diffs the Go functions of consecutive revisions.
+type refactorDetector struct {
+	repo  *repository
+	funcs map[string][]funcDecl // By blob id, nil if not parseable
+}
+
+func newRefactorDetector(repo *repository) *refactorDetector {
+	return &refactorDetector{repo: repo, funcs: make(map[string][]funcDecl)}
+}
+
+// commit classifies the function edits of one commit across all its Go
+// files, so functions moved between files are recognized. Counts are keyed
+// by the path a change has in the commit.
+func (d *refactorDetector) commit(rec *commitRecord) map[string]RefactoringCounts {
+	var before, after []funcDecl
+	for _, ch := range rec.Changes {
+		if !strings.HasSuffix(ch.Path, ".go") || ch.Added+ch.Deleted == 0 {
+			continue
+		}
+		oldPath := ch.Path
+		if ch.OldPath != "" {
+			oldPath = ch.OldPath
+		}
+		// Credit both sides to the current path of the change.
+		for _, f := range d.decls(oldPath, ch.OldBlob) {
+			f.path = ch.Path
+			before = append(before, f)
+		}
+		after = append(after, d.decls(ch.Path, ch.NewBlob)...)
+	}
+	if len(before) == 0 && len(after) == 0 {
+		return nil
+	}
+	return classifyEdits(before, after)
+}
+
+func (d *refactorDetector) decls(path, blob string) []funcDecl {
+	if isNullObject(blob) {
+		return nil
+	}
+	if fs, ok := d.funcs[blob]; ok {
+		return fs
+	}
+	var fs []funcDecl
+	if data, err := d.repo.readBlob(blob); err == nil {
+		fs = parseFuncs(path, data)
+	}
+	d.funcs[blob] = fs
+	return fs
+}
+
+// classifyEdits matches the functions before and after a commit.
+func classifyEdits(before, after []funcDecl) map[string]RefactoringCounts {
+	counts := make(map[string]RefactoringCounts)
+	credit := func(path string, f func(c *RefactoringCounts)) {
+		c := counts[path]
+		f(&c)
+		counts[path] = c
+	}
+
+	// 1. Functions kept under the same name in the same file
+	beforeIdx := make(map[string]int)
+	for i, f := range before {
+		beforeIdx[f.path+"\x00"+f.key] = i
+	}
+	keptBefore := make([]bool, len(before))
+	keptAfter := make([]bool, len(after))
+	kept := make(map[string][][2]int) // path -> (before, after) index pairs in after order
+	for j, a := range after {
+		i, ok := beforeIdx[a.path+"\x00"+a.key]
+		if !ok {
+			continue
+		}
+		b := before[i]
+		keptBefore[i], keptAfter[j] = true, true
+		kept[a.path] = append(kept[a.path], [2]int{i, j})
+		if a.sig != b.sig {
+			credit(a.path, func(c *RefactoringCounts) { c.SignatureChange++ })
+		} else if a.body != b.body && a.shape == b.shape && consistentRenaming(b.idents, a.idents) {
+			credit(a.path, func(c *RefactoringCounts) { c.Rename++ })
+		}
+	}
+
+	// 2. Reordered functions: kept ones outside the longest run in order
+	for path, pairs := range kept {
+		if moved := len(pairs) - longestIncreasing(pairs); moved > 0 {
+			credit(path, func(c *RefactoringCounts) { c.Move += moved })
+		}
+	}
+
+	// 3. Renamed or moved functions: same logic, different name or file
+	for j, a := range after {
+		if keptAfter[j] {
+			continue
+		}
+		for i, b := range before {
+			if keptBefore[i] || (a.body != b.body && a.key != b.key) {
+				continue
+			}
+			keptBefore[i], keptAfter[j] = true, true
+			if a.path != b.path {
+				credit(a.path, func(c *RefactoringCounts) { c.Move++ })
+			} else {
+				credit(a.path, func(c *RefactoringCounts) { c.Rename++ })
+			}
+			break
+		}
+	}
+
+	// Statements that left or joined the bodies of kept functions
+	removed := make(map[string]bool)
+	added := make(map[string]bool)
+	for _, pairs := range kept {
+		for _, p := range pairs {
+			b, a := before[p[0]], after[p[1]]
+			for s := range subtract(b.stmts, a.stmts) {
+				removed[s] = true
+			}
+			for s := range subtract(a.stmts, b.stmts) {
+				added[s] = true
+			}
+		}
+	}
+
+	// 4. New functions: extracted from a kept function, or inserted
+	for j, a := range after {
+		if keptAfter[j] {
+			continue
+		}
+		if mostlyIn(a.stmts, removed) {
+			credit(a.path, func(c *RefactoringCounts) { c.ExtractFunction++ })
+		} else {
+			credit(a.path, func(c *RefactoringCounts) { c.PureInsertion++ })
+		}
+	}
+
+	// 5. Removed functions whose statements were merged into a kept one
+	for i, b := range before {
+		if !keptBefore[i] && mostlyIn(b.stmts, added) {
+			credit(b.path, func(c *RefactoringCounts) { c.Inline++ })
+		}
+	}
+	return counts
+}
+
+// consistentRenaming reports whether the identifiers of two bodies of the
+// same shape map one to one, i.e. the edit only renamed identifiers rather
+// than, say, swapping one variable for another that already existed.
+func consistentRenaming(before, after []string) bool {
+	if len(before) != len(after) {
+		return false
+	}
+	forward := make(map[string]string)
+	backward := make(map[string]string)
+	for i := range before {
+		b, a := before[i], after[i]
+		if m, ok := forward[b]; ok && m != a {
+			return false
+		}
+		if m, ok := backward[a]; ok && m != b {
+			return false
+		}
+		forward[b], backward[a] = a, b
+	}
+	return true
+}
+
+// longestIncreasing returns the length of the longest run of pairs whose
+// before indexes increase, i.e. the functions that kept their order.
+func longestIncreasing(pairs [][2]int) int {
+	best := make([]int, len(pairs))
+	longest := 0
+	for j := range pairs {
+		best[j] = 1
+		for k := 0; k < j; k++ {
+			if pairs[k][0] < pairs[j][0] && best[k]+1 > best[j] {
+				best[j] = best[k] + 1
+			}
+		}
+		longest = max(longest, best[j])
+	}
+	return longest
+}
+
+// subtract returns the statements of a that are not in b.
+func subtract(a, b []string) map[string]bool {
+	in := make(map[string]bool, len(b))
+	for _, s := range b {
+		in[s] = true
+	}
+	out := make(map[string]bool)
+	for _, s := range a {
+		if !in[s] {
+			out[s] = true
+		}
+	}
+	return out
+}
+
+// mostlyIn reports whether at least half of stmts (and at least one) are in set.
+func mostlyIn(stmts []string, set map[string]bool) bool {
+	if len(stmts) == 0 || len(set) == 0 {
+		return false
+	}
+	n := 0
+	for _, s := range stmts {
+		if set[s] {
+			n++
+		}
+	}
+	return n > 0 && 2*n >= len(stmts)
+}
+
+// parseFuncs extracts the functions of a Go source file. Unparseable
+// sources yield nil.
+func parseFuncs(path string, src []byte) []funcDecl {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, 0)
+	if err != nil {
+		return nil
+	}
+
+	var fs []funcDecl
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		f := funcDecl{path: path, key: fn.Name.Name}
+		if fn.Recv != nil && len(fn.Recv.List) > 0 {
+			recv := render(fset, fn.Recv.List[0].Type)
+			f.key = strings.TrimLeft(recv, "*") + "." + f.key
+			f.sig = recv
+		}
+		f.sig += render(fset, fn.Type)
+		f.body = render(fset, fn.Body)
+
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			if s, ok := n.(ast.Stmt); ok {
+				if _, block := s.(*ast.BlockStmt); !block {
+					f.stmts = append(f.stmts, render(fset, s))
+				}
+			}
+			return true
+		})
+
+		// Blank identifiers last: the AST is not used afterwards.
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok {
+				f.idents = append(f.idents, id.Name)
+				id.Name = "_"
+			}
+			return true
+		})
+		f.shape = render(fset, fn.Body)
+		fs = append(fs, f)
+	}
+	return fs
+}
+
+func render(fset *token.FileSet, node any) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
@@ -0,0 +1,170 @@
+package aha
+
+import (
+	"strings"
+	"testing"
+)
+
+const sumFunc = `func Sum(xs []int) int {
+	total := 0
+	for _, x := range xs {
+		total += x
+	}
+	return total
+}
+`
+
+const reportFunc = `func Report(xs []int) string {
+	total := 0
+	for _, x := range xs {
+		total += x
+	}
+	if total > 100 {
+		return "big"
+	}
+	return "small"
+}
+`
+
+const refactorBase = "package p\n\n" + sumFunc + "\n" + reportFunc
+
+func classify(t *testing.T, before, after map[string]string) map[string]RefactoringCounts {
+	t.Helper()
+	var b, a []funcDecl
+	for path, src := range before {
+		b = append(b, parseFuncs(path, []byte(src))...)
+	}
+	for path, src := range after {
+		a = append(a, parseFuncs(path, []byte(src))...)
+	}
+	return classifyEdits(b, a)
+}
+
+func TestClassifyEdits(t *testing.T) {
+	tests := []struct {
+		name  string
+		after map[string]string
+		want  map[string]RefactoringCounts
+	}{
+		{
+			name: "local rename",
+			after: map[string]string{"p.go": replaceAll(refactorBase, map[string]string{
+				"total := 0\n\tfor _, x := range xs {\n\t\ttotal += x\n\t}\n\treturn total": "sum := 0\n\tfor _, x := range xs {\n\t\tsum += x\n\t}\n\treturn sum",
+			})},
+			want: map[string]RefactoringCounts{"p.go": {Rename: 1}},
+		},
+		{
+			name:  "function rename",
+			after: map[string]string{"p.go": replaceAll(refactorBase, map[string]string{"func Sum(": "func Total("})},
+			want:  map[string]RefactoringCounts{"p.go": {Rename: 1}},
+		},
+		{
+			name:  "signature change",
+			after: map[string]string{"p.go": replaceAll(refactorBase, map[string]string{"func Report(xs []int) string": "func Report(xs []int, limit int) string"})},
+			want:  map[string]RefactoringCounts{"p.go": {SignatureChange: 1}},
+		},
+		{
+			name: "extract function",
+			after: map[string]string{"p.go": replaceAll(refactorBase, map[string]string{
+				"func Report(xs []int) string {\n\ttotal := 0\n\tfor _, x := range xs {\n\t\ttotal += x\n\t}\n\tif": "func Report(xs []int) string {\n\ttotal := add(xs)\n\tif",
+			}) + "\nfunc add(xs []int) int {\n\ttotal := 0\n\tfor _, x := range xs {\n\t\ttotal += x\n\t}\n\treturn total\n}\n"},
+			want: map[string]RefactoringCounts{"p.go": {ExtractFunction: 1}},
+		},
+		{
+			name:  "move to another file",
+			after: map[string]string{"p.go": "package p\n\n" + reportFunc, "sum.go": "package p\n\n" + sumFunc},
+			want:  map[string]RefactoringCounts{"sum.go": {Move: 1}},
+		},
+		{
+			name:  "pure insertion",
+			after: map[string]string{"p.go": refactorBase + "\nfunc Max(a, b int) int {\n\tif a > b {\n\t\treturn a\n\t}\n\treturn b\n}\n"},
+			want:  map[string]RefactoringCounts{"p.go": {PureInsertion: 1}},
+		},
+		{
+			name:  "logic edit is not a rename",
+			after: map[string]string{"p.go": replaceAll(refactorBase, map[string]string{"total += x\n\t}\n\treturn total": "total += x\n\t}\n\treturn len(xs)"})},
+			want:  map[string]RefactoringCounts{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classify(t, map[string]string{"p.go": refactorBase}, tt.after)
+			for path, c := range got {
+				if c == (RefactoringCounts{}) {
+					delete(got, path)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			for path, want := range tt.want {
+				if got[path] != want {
+					t.Errorf("%s: got %+v, want %+v", path, got[path], want)
+				}
+			}
+		})
+	}
+}
+
+func TestClassifyInline(t *testing.T) {
+	before := map[string]string{"p.go": `package p
+
+func Area(w, h int) int {
+	a := mul(w, h)
+	return a
+}
+
+func mul(w, h int) int {
+	r := w * h
+	return r
+}
+`}
+	after := map[string]string{"p.go": `package p
+
+func Area(w, h int) int {
+	r := w * h
+	return r
+}
+`}
+	got := classify(t, before, after)
+	if got["p.go"].Inline != 1 {
+		t.Errorf("got %+v, want one inline", got["p.go"])
+	}
+}
+
+func TestClassifyReorder(t *testing.T) {
+	after := "package p\n\n" + reportFunc + "\n" + sumFunc
+	got := classify(t, map[string]string{"p.go": refactorBase}, map[string]string{"p.go": after})
+	if got["p.go"] != (RefactoringCounts{Move: 1}) {
+		t.Errorf("got %+v, want one move", got["p.go"])
+	}
+}
+
+func replaceAll(s string, repl map[string]string) string {
+	for old, new := range repl {
+		if !strings.Contains(s, old) {
+			panic("replaceAll: missing " + old)
+		}
+		s = strings.ReplaceAll(s, old, new)
+	}
+	return s
+}
+
+func TestAnalyzeRepoRefactorings(t *testing.T) {
+	r := newGitRepo(t)
+	r.commit("p.go", refactorBase, "2026-01-01T10:00:00")
+	r.write("sum.go", "package p\n\n"+sumFunc)
+	r.commit("p.go", "package p\n\n"+reportFunc, "2026-01-02T10:00:00")
+
+	repo, err := AnalyzeRepo(r.dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := repo["p.go"].Refactorings; got == nil || *got != (RefactoringCounts{PureInsertion: 2}) {
+		t.Errorf("p.go refactorings = %+v, want the two initial insertions", got)
+	}
+	if got := repo["sum.go"].Refactorings; got == nil || *got != (RefactoringCounts{Move: 1}) {
+		t.Errorf("sum.go refactorings = %+v, want one move", got)
+	}
+}
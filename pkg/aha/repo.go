@@ -0,0 +1,176 @@
+package aha
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// AnalyzeRepo calculates AHA metrics for every file under root with a
+// single pass over the history, instead of one pass per file. Keys are
+// slash-separated paths relative to root. Renames are followed: the history
+// of a renamed file is credited to its current path.
+//
+// root may be a subdirectory of the repository; only history below it is
+// considered. Paths without history are absent from the result.
+//
+// opts restricts which commits count towards the metrics; every commit is
+// still listed in the per-contributor breakdown. Counted commits are also
+// checked for AI pastes by their cognitive velocity (see PasteFlag) and
+// their Go changes classified into refactoring vectors.
+//
+// A history that cannot be read completely is an error (ErrShallowClone,
+// ErrPartialClone, ErrObjectNotFound ...) rather than zero metrics.
+//
+// With opts.VimUndo, each file's Vim undofile is summarized as well.
+// opts.Model replaces the default scoring model; it sees the Halstead
+// measures of the files in root.
+func AnalyzeRepo(root string, opts Options) (map[string]*AHAMetrics, error) {
+	repo, err := openRepository(root)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.Close()
+	if err := repo.requireFullHistory(); err != nil {
+		return nil, err
+	}
+	prefix, err := repo.prefix(root)
+	if err != nil {
+		return nil, err
+	}
+	records, err := repo.log(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	var micro []commitRecord
+	if opts.MicroHistory {
+		if micro, err = repo.microHistory(prefix); err != nil {
+			return nil, err
+		}
+		snapshots, err := snapshotRecords(repo.workTree, prefix)
+		if err != nil {
+			return nil, err
+		}
+		micro = append(micro, snapshots...)
+	}
+
+	result := buildRepoMetrics(records, micro, opts, repo)
+	for p, m := range result {
+		m.AttachHalstead(filepath.Join(root, filepath.FromSlash(p)))
+		if opts.Model != nil {
+			m.AHAScore = opts.Model.Score(m)
+		}
+	}
+	if opts.VimUndo {
+		for p, m := range result {
+			undo, err := ImportVimUndo(filepath.Join(root, filepath.FromSlash(p)), opts.VimUndoDirs)
+			if errors.Is(err, ErrUnreadableVimUndo) {
+				// Evidence only: the file is scored without it
+				if opts.Warn != nil {
+					opts.Warn(p, err)
+				}
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			m.VimUndo = undo
+		}
+	}
+	return result, nil
+}
+
+// buildRepoMetrics walks commits newest first and credits each change to
+// the path the file has today. micro holds commits outside the history of
+// HEAD; they only count as micro revisions of files that still exist.
+// repo may be nil to skip the analyses that need file contents (pastes,
+// refactorings, line lineage).
+func buildRepoMetrics(records, micro []commitRecord, opts Options, repo *repository) map[string]*AHAMetrics {
+	var pastes *pasteDetector
+	var refactors *refactorDetector
+	if repo != nil {
+		pastes = newPasteDetector(repo, records, opts)
+		refactors = newRefactorDetector(repo)
+	}
+
+	// alias maps a historical path to the current path of the same file.
+	// Older history of a path that was created (or renamed away) is a
+	// different file; it is parked under a tombstone key and dropped.
+	alias := make(map[string]string)
+	resolve := func(p string) string {
+		if a, ok := alias[p]; ok {
+			return a
+		}
+		return p
+	}
+
+	builders := make(map[string]*metricsBuilder)
+	for i := range records {
+		rec := &records[i]
+		contributor := opts.contributor(rec)
+		counted := opts.counts(rec, contributor)
+		var edits map[string]RefactoringCounts
+		if counted && refactors != nil {
+			edits = refactors.commit(rec)
+		}
+		for _, ch := range rec.Changes {
+			current := resolve(ch.Path)
+			b, ok := builders[current]
+			if !ok {
+				b = newMetricsBuilder()
+				builders[current] = b
+			}
+			b.add(rec, ch, contributor, counted)
+			b.metrics.addRefactorings(edits[ch.Path])
+			if counted && pastes != nil {
+				if flag, ok := pastes.check(i, rec, ch, contributor); ok {
+					b.metrics.PasteFlags = append(b.metrics.PasteFlags, flag)
+				}
+			}
+
+			switch ch.Status {
+			case 'R':
+				alias[ch.OldPath] = current
+				alias[ch.Path] = tombstone(rec.Hash, ch.Path)
+			case 'A':
+				alias[ch.Path] = tombstone(rec.Hash, ch.Path)
+			}
+		}
+	}
+
+	// Micro records are local to the author's repository and never carry
+	// HCP-Signature trailers: only the author filter applies to them.
+	microOpts := opts
+	microOpts.SigningKey = nil
+	for i := range micro {
+		rec := &micro[i]
+		if !microOpts.counts(rec, microOpts.contributor(rec)) {
+			continue
+		}
+		for _, ch := range rec.Changes {
+			key := ch.Path
+			if _, ok := builders[key]; !ok {
+				// A path renamed away since: credit its current name.
+				key = resolve(key)
+			}
+			if b, ok := builders[key]; ok && !strings.HasPrefix(key, "\x00") {
+				b.addMicro(rec, ch)
+			}
+		}
+	}
+
+	result := make(map[string]*AHAMetrics, len(builders))
+	for p, b := range builders {
+		if strings.HasPrefix(p, "\x00") {
+			continue
+		}
+		result[p] = b.build(repo)
+	}
+	return result
+}
+
+func tombstone(hash, path string) string {
+	return "\x00" + hash + ":" + path
+}
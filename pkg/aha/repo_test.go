@@ -0,0 +1,139 @@
+package aha
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeRepoMatchesAnalyzeFile(t *testing.T) {
+	r := newGitRepo(t)
+	r.commit("src/a.go", lines(10, "a"), "2026-01-01T10:00:00")
+	r.commit("src/b.go", lines(5, "b"), "2026-01-02T10:00:00")
+	r.commit("src/a.go", lines(12, "a"), "2026-01-03T10:00:00")
+	r.git("mv", "src/a.go", "src/renamed.go")
+	r.commit("src/renamed.go", lines(12, "a")+"tail\n", "2026-01-04T10:00:00")
+	r.commit("src/b.go", lines(3, "b"), "2026-01-05T10:00:00")
+
+	repo, err := AnalyzeRepo(r.dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := repo["src/a.go"]; ok {
+		t.Error("history reported under the old name of a renamed file")
+	}
+
+	for _, p := range []string{"src/renamed.go", "src/b.go"} {
+		want, err := AnalyzeFile(filepath.Join(r.dir, p), r.dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := repo[p]
+		if !ok {
+			t.Fatalf("%s missing from AnalyzeRepo result", p)
+		}
+		// Only AnalyzeRepo detects pastes.
+		churn := *got
+		churn.PasteFlags = nil
+		if !reflect.DeepEqual(&churn, want) {
+			t.Errorf("%s: AnalyzeRepo = %+v, AnalyzeFile = %+v", p, *got, *want)
+		}
+	}
+	if repo["src/renamed.go"].Commits != 3 {
+		t.Errorf("renamed file commits = %d, want 3", repo["src/renamed.go"].Commits)
+	}
+
+	// Analysis of a subdirectory reports paths relative to it.
+	sub, err := AnalyzeRepo(filepath.Join(r.dir, "src"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sub["b.go"]; !ok {
+		t.Errorf("expected paths relative to subdirectory, got %v", keys(sub))
+	}
+}
+
+func keys(m map[string]*AHAMetrics) []string {
+	var out []string
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
+
+// syntheticRepo builds a repository with the given number of files, each
+// touched by several of the commits, using git fast-import for speed.
+func syntheticRepo(b *testing.B, files, commits int) string {
+	b.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		b.Skip("git not installed")
+	}
+	dir := b.TempDir()
+	if out, err := exec.Command("git", "-C", dir, "init", "-q").CombinedOutput(); err != nil {
+		b.Fatalf("git init: %v\n%s", err, out)
+	}
+
+	var stream bytes.Buffer
+	for c := 0; c < commits; c++ {
+		msg := fmt.Sprintf("commit %d", c)
+		fmt.Fprintf(&stream, "commit refs/heads/main\nmark :%d\n", c+1)
+		fmt.Fprintf(&stream, "author Bench <bench@example.com> %d +0000\n", 1767225600+c*86400)
+		fmt.Fprintf(&stream, "committer Bench <bench@example.com> %d +0000\n", 1767225600+c*86400)
+		fmt.Fprintf(&stream, "data %d\n%s\n", len(msg), msg)
+		if c > 0 {
+			fmt.Fprintf(&stream, "from :%d\n", c)
+		}
+		for f := 0; f < files; f++ {
+			// Every file is created in the first commit and then edited in
+			// roughly a third of the later ones.
+			if c > 0 && (f+c)%3 != 0 {
+				continue
+			}
+			content := fmt.Sprintf("package p%d\n\n// revision %d\nvar V = %d\n", f%50, c, f*c)
+			fmt.Fprintf(&stream, "M 644 inline dir%02d/file%05d.go\ndata %d\n%s\n", f%50, f, len(content), content)
+		}
+	}
+
+	cmd := exec.Command("git", "-C", dir, "fast-import", "--quiet")
+	cmd.Stdin = &stream
+	if out, err := cmd.CombinedOutput(); err != nil {
+		b.Fatalf("git fast-import: %v\n%s", err, out)
+	}
+	if out, err := exec.Command("git", "-C", dir, "checkout", "-q", "main").CombinedOutput(); err != nil {
+		b.Fatalf("git checkout: %v\n%s", err, out)
+	}
+	return dir
+}
+
+func BenchmarkAnalyzeRepo(b *testing.B) {
+	dir := syntheticRepo(b, 3000, 20)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m, err := AnalyzeRepo(dir, Options{})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(m) != 3000 {
+			b.Fatalf("got metrics for %d files, want 3000", len(m))
+		}
+	}
+}
+
+// BenchmarkAnalyzeFilePerFile analyzes the same repository one file at a
+// time, opening the repository and walking its history once per file, for
+// comparison with the single pass of AnalyzeRepo.
+func BenchmarkAnalyzeFilePerFile(b *testing.B) {
+	dir := syntheticRepo(b, 3000, 20)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for f := 0; f < 3000; f++ {
+			p := filepath.Join(dir, fmt.Sprintf("dir%02d/file%05d.go", f%50, f))
+			if _, err := AnalyzeFile(p, dir); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
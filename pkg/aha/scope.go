@@ -0,0 +1,83 @@
+package aha
+
+import (
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/windgeek/HCP/pkg/identity"
+)
+
+// Contribution is one contributor's share of a file's history.
+type Contribution struct {
+	Commits      int  `json:"commits"`
+	LinesAdded   int  `json:"lines_added"`
+	LinesDeleted int  `json:"lines_deleted"`
+	Counted      bool `json:"counted"` // Whether these commits feed the AHA score
+}
+
+// Options scopes history analysis to the signing identity.
+//
+// Author emails are taken after .mailmap resolution, then mapped through
+// Aliases and compared case-insensitively.
+type Options struct {
+	// Authors lists the canonical emails whose commits count towards the
+	// AHA metrics. Empty counts every author.
+	Authors []string
+	// Aliases maps alternate emails to canonical ones.
+	Aliases map[string]string
+	// SigningKey, if set, only counts commits carrying an HCP-Signature
+	// trailer made with this key (see identity.SignCommit). It does not
+	// apply to MicroHistory, whose records are never signed.
+	SigningKey *btcec.PublicKey
+	// MicroHistory also mines iteration evidence outside the history of
+	// HEAD: reflogs, stashes, dangling commits and `hcp snapshot` records.
+	MicroHistory bool
+	// VimUndo attaches a summary of each file's Vim undo history, looked up
+	// next to the file and in VimUndoDirs (see ImportVimUndo).
+	VimUndo     bool
+	VimUndoDirs []string
+	// Warn receives the files whose evidence was skipped and why, e.g.
+	// unreadable undofiles. Nil discards the warnings.
+	Warn func(path string, err error)
+	// Model scores the metrics; nil keeps DefaultModel.
+	Model ScoringModel
+}
+
+// contributor returns the canonical email of a commit's author.
+func (o Options) contributor(rec *commitRecord) string {
+	email := strings.ToLower(strings.TrimSpace(rec.AuthorEmail))
+	for alias, canonical := range o.Aliases {
+		if strings.EqualFold(alias, email) {
+			return strings.ToLower(canonical)
+		}
+	}
+	if email == "" {
+		return rec.AuthorName
+	}
+	return email
+}
+
+// counts reports whether a commit by contributor feeds the AHA metrics.
+func (o Options) counts(rec *commitRecord, contributor string) bool {
+	if len(o.Authors) > 0 {
+		found := false
+		for _, a := range o.Authors {
+			if strings.EqualFold(a, contributor) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if o.SigningKey != nil {
+		for _, sig := range rec.Signatures {
+			if identity.VerifyCommit(o.SigningKey, rec.Payload, sig) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package aha
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/windgeek/HCP/pkg/identity"
+)
+
+func TestAnalyzeRepoAuthorScope(t *testing.T) {
+	r := newGitRepo(t)
+	r.commit("main.go", lines(10, "a"), "2026-01-01T10:00:00")
+	r.write("main.go", lines(10, "a")+lines(40, "bot"))
+	r.git("commit", "-q", "-m", "bump", "--date", "2026-01-02T10:00:00",
+		"--author", "dependabot[bot] <49699333+dependabot[bot]@users.noreply.github.com>")
+	r.write("main.go", lines(10, "a")+lines(40, "bot")+lines(5, "old"))
+	r.git("commit", "-q", "-m", "laptop", "--date", "2026-01-03T10:00:00",
+		"--author", "A. Liddell <alice@old-laptop.local>")
+	r.write("main.go", lines(10, "a")+lines(40, "bot")+lines(5, "old")+lines(2, "work"))
+	r.git("commit", "-q", "-m", "work", "--date", "2026-01-04T10:00:00",
+		"--author", "Alice <ALICE@corp.example>")
+
+	// .mailmap folds the laptop identity; the config alias folds the work one.
+	r.commit(".mailmap", "Alice <alice@example.com> <alice@old-laptop.local>\n", "2026-01-05T10:00:00")
+
+	opts := Options{
+		Authors: []string{"alice@example.com"},
+		Aliases: map[string]string{"alice@corp.example": "Alice@Example.com"},
+	}
+	repo, err := AnalyzeRepo(r.dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := repo["main.go"]
+	if m == nil {
+		t.Fatal("main.go missing from result")
+	}
+	if m.Commits != 3 {
+		t.Errorf("commits = %d, want 3 (bot excluded)", m.Commits)
+	}
+	if m.LinesAdded != 17 {
+		t.Errorf("lines added = %d, want 17", m.LinesAdded)
+	}
+
+	alice, ok := m.Contributors["alice@example.com"]
+	if !ok || !alice.Counted || alice.Commits != 3 {
+		t.Errorf("alice contribution = %+v (present %v), want 3 counted commits", alice, ok)
+	}
+	var bot Contribution
+	for who, c := range m.Contributors {
+		if strings.Contains(who, "dependabot") {
+			bot = c
+		}
+	}
+	if bot.Commits != 1 || bot.LinesAdded != 40 || bot.Counted {
+		t.Errorf("bot contribution = %+v, want 1 uncounted commit of 40 lines", bot)
+	}
+	if len(m.Contributors) != 2 {
+		t.Errorf("contributors = %v, want alice and the bot", m.Contributors)
+	}
+}
+
+func TestAnalyzeRepoSignedCommitsOnly(t *testing.T) {
+	key, err := identity.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := identity.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := newGitRepo(t)
+	commitSigned := func(content, date string, signer *btcec.PrivateKey) {
+		r.write("main.go", content)
+		args := []string{"commit", "-q", "-m", "edit", "--date", date}
+		if signer != nil {
+			p := identity.CommitPayload{Tree: strings.TrimSpace(r.git("write-tree")), Author: "Alice <alice@example.com>"}
+			if head, err := exec.Command("git", "-C", r.dir, "rev-parse", "-q", "--verify", "HEAD").Output(); err == nil {
+				p.Parents = []string{strings.TrimSpace(string(head))}
+			}
+			args = append(args, "--trailer", identity.CommitTrailer+": "+identity.SignCommit(signer, p))
+		}
+		r.git(args...)
+	}
+	commitSigned(lines(10, "a"), "2026-01-01T10:00:00", key)
+	commitSigned(lines(30, "a"), "2026-01-02T10:00:00", nil)
+	commitSigned(lines(35, "a"), "2026-01-03T10:00:00", other)
+	commitSigned(lines(36, "a"), "2026-01-04T10:00:00", key)
+
+	repo, err := AnalyzeRepo(r.dir, Options{SigningKey: key.PubKey()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := repo["main.go"]
+	if m.Commits != 2 || m.LinesAdded != 11 {
+		t.Errorf("got %d commits adding %d lines, want 2 signed commits adding 11", m.Commits, m.LinesAdded)
+	}
+	alice := m.Contributors["alice@example.com"]
+	if alice.Commits != 4 || !alice.Counted {
+		t.Errorf("contribution = %+v, want all 4 commits listed", alice)
+	}
+}
@@ -0,0 +1,210 @@
+package aha
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HistoryDir is where `hcp snapshot` records working-tree snapshots,
+// relative to the repository root. File contents are kept under objects/
+// so the next snapshot can be diffed against them.
+const HistoryDir = ".hcp/history"
+
+// Snapshot records which files changed in the working tree since the
+// previous snapshot (or HEAD).
+type Snapshot struct {
+	Time   int64          `json:"time"` // Unix seconds
+	Author string         `json:"author"`
+	Head   string         `json:"head"`
+	Files  []SnapshotFile `json:"files"`
+}
+
+// SnapshotFile is one changed file of a snapshot. Paths are relative to
+// the repository root.
+type SnapshotFile struct {
+	Path    string `json:"path"`
+	SHA256  string `json:"sha256"`
+	Added   int    `json:"added"`
+	Deleted int    `json:"deleted"`
+}
+
+// TakeSnapshot records the working-tree files of the repository at
+// repoRoot whose content changed since they were last snapshotted. It
+// returns nil if nothing changed.
+func TakeSnapshot(repoRoot string) (*Snapshot, error) {
+	repo, err := openRepository(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.Close()
+	head, err := repo.head()
+	if err != nil {
+		return nil, err
+	}
+	var headTree string
+	if head != "" {
+		c, err := repo.readCommit(head)
+		if err != nil {
+			return nil, err
+		}
+		headTree = c.tree
+	}
+
+	// 1. Files that differ from HEAD, including untracked ones
+	paths, err := repo.worktreeChanges(headTree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan working tree: %w", err)
+	}
+
+	// 2. Latest recorded version of each path
+	previous, err := LoadSnapshots(repo.workTree)
+	if err != nil {
+		return nil, err
+	}
+	last := make(map[string]string)
+	for _, s := range previous {
+		for _, f := range s.Files {
+			last[f.Path] = f.SHA256
+		}
+	}
+
+	historyDir := filepath.Join(repo.workTree, HistoryDir)
+	snap := &Snapshot{
+		Time:   time.Now().Unix(),
+		Author: repo.config.get("user.email"),
+		Head:   head,
+	}
+	for _, p := range paths {
+		content, err := os.ReadFile(filepath.Join(repo.workTree, filepath.FromSlash(p)))
+		if err != nil {
+			continue // Vanished or a directory (submodule)
+		}
+		sum := sha256.Sum256(content)
+		digest := hex.EncodeToString(sum[:])
+		if last[p] == digest {
+			continue
+		}
+
+		// 3. Diff against the previous snapshot, or HEAD
+		var before []byte
+		if prev, ok := last[p]; ok {
+			before, _ = os.ReadFile(filepath.Join(historyDir, "objects", prev))
+		} else if e, ok, err := repo.lookup(headTree, p); err == nil && ok && e.isFile() {
+			before, _ = repo.readBlob(e.id)
+		}
+		oldLines, newLines := splitLines(before), splitLines(content)
+		common := len(matchLines(oldLines, newLines))
+
+		objPath := filepath.Join(historyDir, "objects", digest)
+		if err := os.MkdirAll(filepath.Dir(objPath), 0700); err != nil {
+			return nil, fmt.Errorf("failed to create history directory: %w", err)
+		}
+		if err := os.WriteFile(objPath, content, 0600); err != nil {
+			return nil, fmt.Errorf("failed to store snapshot object: %w", err)
+		}
+		snap.Files = append(snap.Files, SnapshotFile{
+			Path:    p,
+			SHA256:  digest,
+			Added:   len(newLines) - common,
+			Deleted: len(oldLines) - common,
+		})
+	}
+	if len(snap.Files) == 0 {
+		return nil, nil
+	}
+
+	// 4. Save, named by time so snapshots sort chronologically
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	name := filepath.Join(historyDir, fmt.Sprintf("%d.json", time.Now().UnixNano()))
+	if err := os.WriteFile(name, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return snap, nil
+}
+
+// LoadSnapshots returns the snapshots recorded for the repository at
+// repoRoot, oldest first.
+func LoadSnapshots(repoRoot string) ([]Snapshot, error) {
+	dir := filepath.Join(repoRoot, HistoryDir)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history directory: %w", err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		// Equal-length numeric names sort like numbers.
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) < len(names[j])
+		}
+		return names[i] < names[j]
+	})
+
+	snapshots := make([]Snapshot, 0, len(names))
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		var s Snapshot
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("invalid snapshot %s: %w", name, err)
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, nil
+}
+
+// snapshotRecords converts the snapshots of the repository at top into
+// commit records for the files below prefix, with paths relative to it.
+func snapshotRecords(top, prefix string) ([]commitRecord, error) {
+	snapshots, err := LoadSnapshots(top)
+	if err != nil {
+		return nil, err
+	}
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	var records []commitRecord
+	for i, s := range snapshots {
+		rec := commitRecord{
+			Hash:        fmt.Sprintf("snapshot:%d", i),
+			Date:        time.Unix(s.Time, 0).Format("2006-01-02"),
+			Time:        s.Time,
+			AuthorEmail: s.Author,
+		}
+		for _, f := range s.Files {
+			if !strings.HasPrefix(f.Path, prefix) {
+				continue
+			}
+			rec.Changes = append(rec.Changes, fileChange{
+				Status:  'M',
+				Path:    strings.TrimPrefix(f.Path, prefix),
+				NewBlob: "sha256:" + f.SHA256,
+				Added:   f.Added,
+				Deleted: f.Deleted,
+			})
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
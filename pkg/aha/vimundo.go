@@ -0,0 +1,276 @@
+package aha
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// UndoSummary summarizes a Vim undo tree. It holds counts and timestamps
+// only; no text of the buffer is retained.
+type UndoSummary struct {
+	Changes     int   `json:"changes"`      // Undo states kept in the undofile
+	Sequences   int   `json:"sequences"`    // Changes ever made, including states dropped by 'undolevels'
+	Branches    int   `json:"branches"`     // Alternate branches: an undo followed by a different change
+	Saves       int   `json:"saves"`        // Times the buffer was written
+	FirstEdit   int64 `json:"first_edit"`   // Unix time of the oldest undo state
+	LastEdit    int64 `json:"last_edit"`    // Unix time of the newest undo state
+	EditingDays int   `json:"editing_days"` // Distinct days with undo states
+	MatchesFile bool  `json:"matches_file"` // The undo tree ends at the current file content
+}
+
+// Vim undofile constants (src/undo.c). All numbers are big-endian.
+const (
+	vimUndoMagic        = "Vim\x9fUnDo\xe5"
+	vimUndoVersion      = 2
+	vimUndoVersionCrypt = 0x8002
+	vimUndoHashSize     = 32
+	vimHeaderMagic      = 0x5fd0
+	vimHeaderEndMagic   = 0xe7aa
+	vimEntryMagic       = 0xf518
+	vimEntryEndMagic    = 0x3581
+	vimNamedMarks       = 26
+)
+
+var (
+	// ErrNotVimUndo is returned for files that are not Vim undofiles.
+	ErrNotVimUndo = errors.New("not a Vim undofile")
+	// ErrEncryptedVimUndo is returned for undofiles of encrypted buffers.
+	ErrEncryptedVimUndo = errors.New("encrypted Vim undofile")
+	// ErrUnreadableVimUndo wraps the errors of ImportVimUndo for undofiles
+	// that exist but cannot be parsed: foreign, encrypted, corrupt or of an
+	// unsupported version.
+	ErrUnreadableVimUndo = errors.New("unreadable Vim undofile")
+)
+
+// DefaultVimUndoDirs returns the usual 'undodir' locations of Vim and
+// Neovim.
+func DefaultVimUndoDirs() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	return []string{
+		filepath.Join(home, ".vim", "undo"),
+		filepath.Join(home, ".local", "state", "nvim", "undo"),
+		filepath.Join(home, ".local", "share", "nvim", "undo"),
+	}
+}
+
+// FindVimUndoFile returns the undofile of filePath: ".<name>.un~" next to
+// the file, or the full path with '/' replaced by '%' in one of undoDirs.
+func FindVimUndoFile(filePath string, undoDirs []string) (string, bool) {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", false
+	}
+	candidates := []string{filepath.Join(filepath.Dir(abs), "."+filepath.Base(abs)+".un~")}
+	for _, dir := range undoDirs {
+		candidates = append(candidates, filepath.Join(dir, escapeUndoPath(abs)))
+	}
+	for _, c := range candidates {
+		if info, err := os.Stat(c); err == nil && info.Mode().IsRegular() {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+// escapeUndoPath names the undofile of an absolute path in an undo
+// directory, like Vim does.
+func escapeUndoPath(abs string) string {
+	return strings.ReplaceAll(filepath.ToSlash(abs), "/", "%")
+}
+
+// ImportVimUndo summarizes the Vim undo history of filePath. It returns
+// nil without error if the file has no undofile, and an
+// ErrUnreadableVimUndo if the undofile cannot be parsed.
+func ImportVimUndo(filePath string, undoDirs []string) (*UndoSummary, error) {
+	undoPath, ok := FindVimUndoFile(filePath, undoDirs)
+	if !ok {
+		return nil, nil
+	}
+	f, err := os.Open(undoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	summary, hash, err := ParseVimUndo(f)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %w", ErrUnreadableVimUndo, undoPath, err)
+	}
+	if content, err := os.ReadFile(filePath); err == nil {
+		summary.MatchesFile = vimBufferHash(content) == hash
+	}
+	return summary, nil
+}
+
+// ParseVimUndo reads a Vim undofile (format version 2). It also returns
+// the SHA-256 Vim recorded for the buffer text when writing it.
+func ParseVimUndo(r io.Reader) (*UndoSummary, [vimUndoHashSize]byte, error) {
+	u := &undoReader{r: bufio.NewReader(r)}
+	var hash [vimUndoHashSize]byte
+
+	// 1. Start magic and version
+	if magic := u.bytes(len(vimUndoMagic)); u.err != nil || string(magic) != vimUndoMagic {
+		return nil, hash, ErrNotVimUndo
+	}
+	switch version := u.uint(2); {
+	case u.err != nil:
+		return nil, hash, u.err
+	case version == vimUndoVersionCrypt:
+		return nil, hash, ErrEncryptedVimUndo
+	case version != vimUndoVersion:
+		return nil, hash, fmt.Errorf("unsupported Vim undofile version %d", version)
+	}
+	copy(hash[:], u.bytes(vimUndoHashSize))
+
+	// 2. Buffer data: line count, 'U' line, its position
+	u.uint(4)
+	u.skip(int(u.uint(4)))
+	u.skip(4 + 4)
+
+	// 3. Tree data: old/new/current head, header count, sequences, time
+	u.skip(3 * 4)
+	u.uint(4)
+	summary := &UndoSummary{Sequences: int(u.uint(4))}
+	u.skip(4 + 8)
+	u.optionalFields(func(what byte, data []byte) {
+		if what == 1 && len(data) == 4 { // UF_LAST_SAVE_NR
+			summary.Saves = int(binary.BigEndian.Uint32(data))
+		}
+	})
+
+	// 4. Undo headers
+	days := make(map[string]bool)
+	for u.err == nil {
+		magic := u.uint(2)
+		if magic == vimHeaderEndMagic {
+			break
+		}
+		if magic != vimHeaderMagic {
+			return nil, hash, fmt.Errorf("corrupt Vim undofile: header magic %#x", magic)
+		}
+
+		u.skip(2 * 4) // next, prev
+		altNext := u.uint(4)
+		u.skip(4 + 4)                          // alt_prev, seq
+		u.skip(12 + 4 + 2)                     // cursor, cursor vcol, flags
+		u.skip(vimNamedMarks*12 + 12 + 12 + 8) // named marks, visual area, mode
+		editTime := int64(u.uint(8))
+		u.optionalFields(nil)
+
+		// Entries: changed lines, skipped unread
+		for u.err == nil {
+			m := u.uint(2)
+			if m == vimEntryEndMagic {
+				break
+			}
+			if m != vimEntryMagic {
+				return nil, hash, fmt.Errorf("corrupt Vim undofile: entry magic %#x", m)
+			}
+			u.skip(3 * 4) // top, bottom, line count
+			for n := u.uint(4); n > 0 && u.err == nil; n-- {
+				u.skip(int(u.uint(4)))
+			}
+		}
+
+		summary.Changes++
+		if altNext != 0 {
+			summary.Branches++
+		}
+		if editTime > 0 {
+			if summary.FirstEdit == 0 || editTime < summary.FirstEdit {
+				summary.FirstEdit = editTime
+			}
+			summary.LastEdit = max(summary.LastEdit, editTime)
+			days[time.Unix(editTime, 0).Format("2006-01-02")] = true
+		}
+	}
+	if u.err != nil {
+		return nil, hash, fmt.Errorf("truncated Vim undofile: %w", u.err)
+	}
+	summary.EditingDays = len(days)
+	return summary, hash, nil
+}
+
+// vimBufferHash computes the hash Vim stores in undofiles: SHA-256 over
+// every line followed by a NUL byte.
+func vimBufferHash(content []byte) [vimUndoHashSize]byte {
+	h := sha256.New()
+	text := strings.TrimSuffix(string(content), "\n")
+	if len(content) > 0 {
+		for _, line := range strings.Split(text, "\n") {
+			h.Write([]byte(line))
+			h.Write([]byte{0})
+		}
+	} else {
+		// An empty buffer still has one empty line.
+		h.Write([]byte{0})
+	}
+	var sum [vimUndoHashSize]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// undoReader reads big-endian fields, remembering the first error.
+type undoReader struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (u *undoReader) bytes(n int) []byte {
+	if u.err != nil {
+		return nil
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(u.r, buf); err != nil {
+		u.err = err
+		return nil
+	}
+	return buf
+}
+
+func (u *undoReader) skip(n int) {
+	if u.err != nil {
+		return
+	}
+	if n < 0 {
+		u.err = errors.New("negative length")
+		return
+	}
+	if _, err := u.r.Discard(n); err != nil {
+		u.err = err
+	}
+}
+
+func (u *undoReader) uint(n int) uint64 {
+	var v uint64
+	for _, b := range u.bytes(n) {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
+
+// optionalFields reads "<len> <what> <data>" records up to a zero length.
+func (u *undoReader) optionalFields(fn func(what byte, data []byte)) {
+	for u.err == nil {
+		n := int(u.uint(1))
+		if n == 0 {
+			return
+		}
+		what := byte(u.uint(1))
+		data := u.bytes(n)
+		if fn != nil && u.err == nil {
+			fn(what, data)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package aha
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestImportVimUndo(t *testing.T) {
+	vim, err := exec.LookPath("vim")
+	if err != nil {
+		t.Skip("vim not installed")
+	}
+	dir := t.TempDir()
+	file := filepath.Join(dir, "f.txt")
+	if err := os.WriteFile(file, []byte("one\ntwo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Add a line, undo it and add two others instead: three changes on
+	// two branches of the undo tree. Resetting 'undolevels' closes an undo
+	// sequence, which Ex mode would otherwise keep open.
+	cmd := exec.Command(vim, "-u", "NONE", "-i", "NONE", "-N", "-n", "-es",
+		"-c", "set undofile undodir=.",
+		"-c", "normal! Gothree", "-c", "undo",
+		"-c", "normal! Gofour", "-c", "let &undolevels = &undolevels", "-c", "normal! Gofive",
+		"-c", "wq", "f.txt")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("vim: %v\n%s", err, out)
+	}
+
+	got, err := ImportVimUndo(file, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil {
+		t.Fatal("undofile not found")
+	}
+	if got.Changes != 3 || got.Sequences != 3 || got.Branches != 1 || got.Saves != 1 {
+		t.Errorf("summary = %+v, want 3 changes, 3 sequences, 1 branch, 1 save", got)
+	}
+	if got.FirstEdit == 0 || got.LastEdit < got.FirstEdit || got.EditingDays != 1 {
+		t.Errorf("edit times = %+v", got)
+	}
+	if !got.MatchesFile {
+		t.Error("undo tree should end at the saved content")
+	}
+
+	if err := os.WriteFile(file, []byte("edited elsewhere\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ImportVimUndo(file, nil); err != nil || got.MatchesFile {
+		t.Errorf("after external edit: %+v, %v, want MatchesFile false", got, err)
+	}
+
+	// Undo directories name files by their full path.
+	undoDir := t.TempDir()
+	abs, _ := filepath.Abs(file)
+	mangled := filepath.Join(undoDir, escapeUndoPath(abs))
+	if err := os.Rename(filepath.Join(dir, ".f.txt.un~"), mangled); err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := FindVimUndoFile(file, []string{undoDir}); !ok || p != mangled {
+		t.Errorf("FindVimUndoFile = %q, %v, want %q", p, ok, mangled)
+	}
+	if got, err := ImportVimUndo(filepath.Join(dir, "other.txt"), []string{undoDir}); got != nil || err != nil {
+		t.Errorf("no undofile: %+v, %v, want nil", got, err)
+	}
+}
+
+func TestParseVimUndoErrors(t *testing.T) {
+	if _, _, err := ParseVimUndo(bytes.NewReader([]byte("hello"))); !errors.Is(err, ErrNotVimUndo) {
+		t.Errorf("plain text: %v, want ErrNotVimUndo", err)
+	}
+	crypt := append([]byte(vimUndoMagic), 0x80, 0x02)
+	if _, _, err := ParseVimUndo(bytes.NewReader(crypt)); !errors.Is(err, ErrEncryptedVimUndo) {
+		t.Errorf("encrypted: %v, want ErrEncryptedVimUndo", err)
+	}
+	truncated := append([]byte(vimUndoMagic), 0x00, 0x02, 0x01)
+	if _, _, err := ParseVimUndo(bytes.NewReader(truncated)); err == nil {
+		t.Error("truncated undofile parsed without error")
+	}
+	unsupported := append([]byte(vimUndoMagic), 0x00, 0x09)
+	if _, _, err := ParseVimUndo(bytes.NewReader(unsupported)); err == nil {
+		t.Error("unsupported version parsed without error")
+	}
+}
+
+func TestAnalyzeRepoSkipsUnreadableVimUndo(t *testing.T) {
+	r := newGitRepo(t)
+	r.commit("a.txt", "one\n", "2026-01-01T10:00:00")
+	r.commit("b.txt", "two\n", "2026-01-01T11:00:00")
+	r.write(".a.txt.un~", "not an undofile")
+	r.write(".b.txt.un~", vimUndoMagic+"\x00\x09")
+
+	warned := make(map[string]error)
+	opts := Options{VimUndo: true, Warn: func(path string, err error) { warned[path] = err }}
+	repo, err := AnalyzeRepo(r.dir, opts)
+	if err != nil {
+		t.Fatalf("AnalyzeRepo failed: %v", err)
+	}
+	for _, p := range []string{"a.txt", "b.txt"} {
+		if repo[p] == nil || repo[p].VimUndo != nil || repo[p].Commits != 1 {
+			t.Errorf("%s: %+v", p, repo[p])
+		}
+		if !errors.Is(warned[p], ErrUnreadableVimUndo) {
+			t.Errorf("%s: warning %v, want ErrUnreadableVimUndo", p, warned[p])
+		}
+	}
+	if !errors.Is(warned["a.txt"], ErrNotVimUndo) {
+		t.Errorf("a.txt: warning %v, want ErrNotVimUndo", warned["a.txt"])
+	}
+}
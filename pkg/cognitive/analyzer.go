@@ -0,0 +1,79 @@
+package cognitive
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Analyzer measures the complexity of one family of languages.
+type Analyzer interface {
+	// Name identifies the analyzer in ComplexityStats (e.g. "go-ast").
+	Name() string
+	// Version must be bumped whenever the counting rules change.
+	Version() int
+	// Analyze measures content. path only serves error messages.
+	Analyze(path string, content []byte) (*ComplexityStats, error)
+}
+
+var (
+	analyzersMu sync.RWMutex
+	analyzers   = make(map[string]Analyzer)
+)
+
+// RegisterAnalyzer registers a as the Analyzer for files with the given
+// extension (e.g. ".py"), replacing any previous registration. Extensions
+// are matched case-insensitively.
+func RegisterAnalyzer(ext string, a Analyzer) {
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	analyzersMu.Lock()
+	defer analyzersMu.Unlock()
+	analyzers[strings.ToLower(ext)] = a
+}
+
+// LookupAnalyzer returns the Analyzer for the extension of path, or the
+// line-based generic analyzer if none is registered.
+func LookupAnalyzer(path string) Analyzer {
+	analyzersMu.RLock()
+	defer analyzersMu.RUnlock()
+	if a, ok := analyzers[strings.ToLower(filepath.Ext(path))]; ok {
+		return a
+	}
+	return genericAnalyzer{}
+}
+
+// AnalyzerID returns the "name@version" identifier recorded in
+// ComplexityStats.Analyzer.
+func AnalyzerID(a Analyzer) string {
+	return fmt.Sprintf("%s@%d", a.Name(), a.Version())
+}
+
+type goAnalyzer struct{}
+
+func (goAnalyzer) Name() string { return "go-ast" }
+func (goAnalyzer) Version() int { return 1 }
+func (goAnalyzer) Analyze(path string, content []byte) (*ComplexityStats, error) {
+	return analyzeGoFile(path, content)
+}
+
+// genericAnalyzer is the fallback for files without a language analyzer.
+type genericAnalyzer struct{}
+
+func (genericAnalyzer) Name() string { return "generic" }
+func (genericAnalyzer) Version() int { return 1 }
+func (genericAnalyzer) Analyze(path string, content []byte) (*ComplexityStats, error) {
+	return analyzeGenericFile(content), nil
+}
+
+func init() {
+	RegisterAnalyzer(".go", goAnalyzer{})
+	for ext, lang := range cFamilyLanguages {
+		RegisterAnalyzer(ext, cFamilyAnalyzer{lang})
+	}
+	for _, ext := range []string{".py", ".pyw", ".pyi"} {
+		RegisterAnalyzer(ext, pythonAnalyzer{})
+	}
+}
@@ -0,0 +1,149 @@
+package cognitive
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLanguageAnalyzers(t *testing.T) {
+	tests := []struct {
+		path       string
+		src        string
+		analyzer   string
+		cyclomatic int
+		cognitive  int
+		funcs      []FunctionStats
+	}{
+		{
+			path: "check.js",
+			src: `const s = "if (x) { for (;;) {} }"; // if (a) {
+function check(a, b) {
+  if (a && b) {              // +1, +1 for &&
+    for (const x of a) {     // +2
+      if (x) { continue; }   // +3
+    }
+  } else if (b || a) {       // +1, +1 for ||
+    return a ? 1 : 2;        // +2 (nesting 1)
+  } else {                   // +1
+  }
+}
+
+const f = (x) => {
+  return x.map(y => y * 2);
+};
+`,
+			analyzer:   "c-family@1",
+			cyclomatic: 7 + 2,
+			cognitive:  12,
+			funcs: []FunctionStats{
+				{Name: "check", Line: 2, Lines: 10, Params: 2, Cyclomatic: 8, Cognitive: 12, MaxNesting: 3},
+				{Name: "f", Line: 13, Lines: 3, Params: 1, Cyclomatic: 1},
+			},
+		},
+		{
+			path: "walk.py",
+			src: `import os
+
+def walk(paths, depth=0):
+    """Docstring with if and for."""
+    for p in paths:                 # +1
+        if p and depth > 1:         # +2, +1 for and
+            continue
+        elif p.startswith("x"):     # +1
+            pass
+        else:                       # +1
+            x = 1 if p else 2       # +3 (nesting 2)
+    def inner():
+        while True:                 # +2 (nested function)
+            break
+    return inner
+
+
+class A:
+    def m(self):
+        try:
+            pass
+        except ValueError:          # +1
+            pass
+`,
+			analyzer:   "python@1",
+			cyclomatic: 7 + 2,
+			cognitive:  12,
+			funcs: []FunctionStats{
+				{Name: "walk", Line: 3, Lines: 13, Params: 2, Cyclomatic: 7, Cognitive: 11, MaxNesting: 3},
+				{Name: "m", Line: 19, Lines: 5, Params: 1, Cyclomatic: 2, Cognitive: 1, MaxNesting: 1},
+			},
+		},
+		{
+			path: "lib.rs",
+			src: `fn classify(n: i32, f: fn(i32) -> i32) -> &'static str {
+    match n {                        // +1
+        0 => "zero",
+        _ if f(n) > 0 => "pos",      // +2 (nesting 1)
+        _ => "neg",
+    }
+}
+
+impl Display for Thing {
+    fn fmt(&self) {
+        'outer: loop {               // +1
+            break 'outer;            // +1
+        }
+    }
+}
+`,
+			analyzer:   "c-family@1",
+			cyclomatic: 5 + 2,
+			cognitive:  5,
+			funcs: []FunctionStats{
+				{Name: "classify", Line: 1, Lines: 7, Params: 2, Cyclomatic: 5, Cognitive: 3, MaxNesting: 2},
+				{Name: "fmt", Line: 10, Lines: 5, Params: 1, Cyclomatic: 2, Cognitive: 2, MaxNesting: 1},
+			},
+		},
+		{
+			path: "sum.c",
+			src: `#include <stdio.h>
+static int sum(const int *xs, int n) {
+    int total = 0;
+    for (int i = 0; i < n; i++) {                          /* +1 */
+        if (xs[i] > 0 && xs[i] < 100) total += xs[i];      /* +2, +1 for && */
+    }
+    do { n--; } while (n > 0);                             /* +1 */
+    return total;
+}
+int prototype(int x);
+`,
+			analyzer:   "c-family@1",
+			cyclomatic: 4 + 1,
+			cognitive:  5,
+			funcs: []FunctionStats{
+				{Name: "sum", Line: 2, Lines: 8, Params: 2, Cyclomatic: 5, Cognitive: 5, MaxNesting: 2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		stats, err := AnalyzeSource(tt.path, []byte(tt.src))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.path, err)
+		}
+		if stats.Analyzer != tt.analyzer {
+			t.Errorf("%s: analyzer %s, want %s", tt.path, stats.Analyzer, tt.analyzer)
+		}
+		if stats.Cyclomatic != tt.cyclomatic || stats.Cognitive != tt.cognitive || stats.Functions != len(tt.funcs) {
+			t.Errorf("%s: cyclomatic %d, cognitive %d, %d functions; want %d, %d, %d", tt.path,
+				stats.Cyclomatic, stats.Cognitive, stats.Functions, tt.cyclomatic, tt.cognitive, len(tt.funcs))
+		}
+		if !reflect.DeepEqual(stats.PerFunction, tt.funcs) {
+			t.Errorf("%s: PerFunction =\n%+v\nwant\n%+v", tt.path, stats.PerFunction, tt.funcs)
+		}
+		if stats.Halstead.Volume == 0 {
+			t.Errorf("%s: Halstead %+v", tt.path, stats.Halstead)
+		}
+	}
+
+	for path, want := range map[string]string{"main.go": "go-ast@1", "README.md": "generic@1", "App.TSX": "c-family@1"} {
+		if got := AnalyzerID(LookupAnalyzer(path)); got != want {
+			t.Errorf("LookupAnalyzer(%s) = %s, want %s", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package cognitive
+
+import "github.com/windgeek/HCP/pkg/lexer"
+
+// cLanguage configures the c-family analyzer for one language.
+type cLanguage struct {
+	lexer        lexer.Lexer
+	funcKeywords map[string]bool // Keywords that start a function ("fn", "function" ...)
+	arrow        string          // Lambda operator whose block body is a function ("=>", "->")
+	arm          string          // Operator of match/when arms, each a decision
+	ternary      bool            // "?" is the conditional operator
+	cLikeFuncs   bool            // name(params) { starts a function
+}
+
+var (
+	cLang     = &cLanguage{lexer: lexer.C, ternary: true, cLikeFuncs: true}
+	javaLang  = &cLanguage{lexer: lexer.C, arrow: "->", ternary: true, cLikeFuncs: true}
+	csLang    = &cLanguage{lexer: lexer.C, arrow: "=>", ternary: true, cLikeFuncs: true}
+	jsLang    = &cLanguage{lexer: lexer.JavaScript, funcKeywords: keywordSet("function"), arrow: "=>", ternary: true, cLikeFuncs: true}
+	rustLang  = &cLanguage{lexer: lexer.Rust, funcKeywords: keywordSet("fn"), arm: "=>"}
+	swiftLang = &cLanguage{lexer: lexer.C, funcKeywords: keywordSet("func"), ternary: true}
+	ktLang    = &cLanguage{lexer: lexer.C, funcKeywords: keywordSet("fun"), arm: "->"}
+	scalaLang = &cLanguage{lexer: lexer.C, funcKeywords: keywordSet("def")}
+	phpLang   = &cLanguage{lexer: lexer.PHP, funcKeywords: keywordSet("function", "fn"), ternary: true}
+)
+
+// cFamilyLanguages maps extensions to languages.
+var cFamilyLanguages = map[string]*cLanguage{
+	".c": cLang, ".h": cLang, ".cc": cLang, ".cpp": cLang, ".cxx": cLang, ".hpp": cLang, ".hh": cLang, ".dart": cLang,
+	".java": javaLang, ".cs": csLang,
+	".js": jsLang, ".mjs": jsLang, ".cjs": jsLang, ".jsx": jsLang, ".ts": jsLang, ".tsx": jsLang,
+	".rs": rustLang, ".swift": swiftLang, ".kt": ktLang, ".kts": ktLang, ".scala": scalaLang, ".php": phpLang,
+}
+
+// cKeywords are the words counted as Halstead operators.
+var cKeywords = keywordSet(
+	"if", "else", "elseif", "for", "foreach", "while", "do", "loop", "switch", "match", "when", "case", "default",
+	"break", "continue", "return", "goto", "try", "catch", "finally", "throw", "throws", "yield", "await", "async",
+	"new", "delete", "sizeof", "typeof", "instanceof", "in", "is", "as", "guard",
+	"fn", "func", "fun", "function", "def", "let", "var", "val", "const", "static", "mut", "pub", "use", "mod",
+	"class", "struct", "enum", "union", "interface", "trait", "impl", "extends", "implements", "where", "type",
+	"public", "private", "protected", "internal", "abstract", "final", "override", "virtual", "import", "package",
+	"namespace", "using", "typedef", "extern", "volatile", "unsafe",
+)
+
+// cControlNames are words that take parentheses like a function name.
+var cControlNames = keywordSet(
+	"if", "for", "foreach", "while", "switch", "catch", "return", "sizeof", "typeof", "using", "lock", "fixed",
+	"synchronized", "try", "when", "else", "do", "match",
+)
+
+func keywordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// cFamilyAnalyzer measures brace languages from their tokens: if, for,
+// while, switch and catch are control structures whose braces nest, else
+// and else if add one, case labels and match arms are decisions, && and ||
+// logical operators. Function literals nest inside the function declaring
+// them.
+type cFamilyAnalyzer struct {
+	lang *cLanguage
+}
+
+func (cFamilyAnalyzer) Name() string { return "c-family" }
+func (cFamilyAnalyzer) Version() int { return 1 }
+
+type braceKind int
+
+const (
+	braceOther braceKind = iota
+	braceControl
+	braceSwitch
+	braceDo
+	braceFunction
+)
+
+// pendingFunc is a function seen before its body.
+type pendingFunc struct {
+	depth        int // Bracket depth of the function keyword
+	name         string
+	line, params int
+}
+
+func (a cFamilyAnalyzer) Analyze(path string, content []byte) (*ComplexityStats, error) {
+	lang := a.lang
+	lx := lang.lexer.Lex(string(content))
+	t := newTally()
+
+	type brace struct {
+		kind      braceKind
+		outermost bool
+	}
+	var braces []brace
+	nesting := func() int {
+		n, funcs := 0, 0
+		for _, b := range braces {
+			switch b.kind {
+			case braceControl, braceSwitch, braceDo:
+				n++
+			case braceFunction:
+				funcs++
+			}
+		}
+		return n + max(funcs-1, 0)
+	}
+	text := func(i int) string {
+		if i < 0 || i >= len(lx) {
+			return ""
+		}
+		return lx[i].Text
+	}
+
+	depth := 0    // Bracket depth
+	control := -1 // Depth of a control structure awaiting its block
+	controlKind := braceControl
+	var fn *pendingFunc // Function awaiting its body
+	closedDo := -1      // Index of the brace closing the last do block
+
+	for i, tok := range lx {
+		word := tok.Kind == lexer.Word
+		switch {
+		case tok.Text == "(" || tok.Text == "[":
+			depth++
+		case tok.Text == ")" || tok.Text == "]":
+			depth--
+			if fn != nil && depth < fn.depth {
+				fn = nil
+			}
+			if depth < control {
+				control = -1
+			}
+		case tok.Text == ";" || tok.Text == ",":
+			t.endExpression()
+			if fn != nil && depth == fn.depth {
+				fn = nil // Declaration without a body, or a function type
+			}
+			if depth == control {
+				control = -1 // Body without braces, or a match guard
+			}
+		case tok.Text == "{":
+			t.endExpression()
+			b := brace{kind: braceOther}
+			switch {
+			case fn != nil && depth == fn.depth:
+				b.kind = braceFunction
+			case depth == control:
+				b.kind = controlKind
+				control = -1
+			case lang.cLikeFuncs:
+				if name, line, params, ok := cLikeFunction(lx, i); ok {
+					b.kind = braceFunction
+					fn = &pendingFunc{depth: depth, name: name, line: line, params: params}
+				}
+			}
+			if b.kind == braceFunction {
+				if t.current < 0 {
+					t.open(fn.name, fn.line, fn.params)
+					b.outermost = true
+				}
+				fn = nil
+			}
+			braces = append(braces, b)
+			depth++
+		case tok.Text == "}":
+			t.endExpression()
+			depth = max(depth-1, 0)
+			if fn != nil && depth < fn.depth {
+				fn = nil
+			}
+			if depth < control {
+				control = -1
+			}
+			if len(braces) > 0 {
+				b := braces[len(braces)-1]
+				braces = braces[:len(braces)-1]
+				if b.outermost {
+					t.close(tok.Line)
+				}
+				if b.kind == braceDo {
+					closedDo = i
+				}
+			}
+		case tok.Text == "&&" || tok.Text == "||":
+			t.logical(tok.Text)
+		case tok.Text == "?" && lang.ternary:
+			t.structure(nesting())
+			t.decision()
+		case tok.Text == lang.arm && len(braces) > 0 && braces[len(braces)-1].kind == braceSwitch:
+			t.decision()
+			if depth == control {
+				control = -1 // Guard of this arm
+			}
+		case tok.Text == lang.arrow && text(i+1) == "{" && fn == nil:
+			fn = arrowFunction(lx, i, depth)
+		case word && lang.funcKeywords[tok.Text] && fn == nil:
+			fn = &pendingFunc{depth: depth, name: "<anonymous>", line: tok.Line}
+			if next := i + 1; next < len(lx) && lx[next].Kind == lexer.Word {
+				fn.name = lx[next].Text
+			}
+			for j := i + 1; j < len(lx) && j < i+8; j++ {
+				if lx[j].Text == "(" {
+					fn.params = countArgs(lx, j)
+					break
+				}
+			}
+		case word && (tok.Text == "if" || tok.Text == "guard"):
+			if text(i-1) == "else" {
+				t.increment(1)
+			} else {
+				t.structure(nesting())
+			}
+			t.decision()
+			control, controlKind = depth, braceControl
+		case word && tok.Text == "elseif":
+			t.increment(1)
+			t.decision()
+			control, controlKind = depth, braceControl
+		case word && tok.Text == "else":
+			if text(i+1) != "if" && (lang.arm == "" || text(i+1) != lang.arm) {
+				t.increment(1)
+				control, controlKind = depth, braceControl
+			}
+		case word && tok.Text == "while" && closedDo == i-1:
+			// The condition of a do ... while loop
+		case word && tok.Text == "for" && i > 0 && (text(i-1) == ">" || lx[i-1].Kind == lexer.Word && !cKeywords[text(i-1)]):
+			// impl Trait for Type
+		case word && (tok.Text == "for" || tok.Text == "foreach" || tok.Text == "while" || tok.Text == "loop" || tok.Text == "catch"):
+			t.structure(nesting())
+			t.decision()
+			control, controlKind = depth, braceControl
+		case word && tok.Text == "do":
+			t.structure(nesting())
+			t.decision()
+			control, controlKind = depth, braceDo
+		case word && (tok.Text == "switch" || tok.Text == "match" || tok.Text == "when"):
+			t.structure(nesting())
+			control, controlKind = depth, braceSwitch
+		case word && tok.Text == "case":
+			t.decision()
+		case word && tok.Text == "goto":
+			t.increment(1)
+		case word && (tok.Text == "break" || tok.Text == "continue"):
+			if i+1 < len(lx) && lx[i+1].Kind == lexer.Word && lx[i+1].Line == tok.Line {
+				t.increment(1) // Jump to a label
+			}
+		}
+	}
+	if t.current >= 0 && len(lx) > 0 {
+		t.close(lx[len(lx)-1].Line) // Unbalanced braces
+	}
+	return t.finish(lx, cKeywords), nil
+}
+
+// cLikeFunction reports whether the brace at lx[open] starts the body of a
+// function declared as name(params), possibly followed by qualifiers, a
+// throws clause or a trailing return type.
+func cLikeFunction(lx []lexer.Token, open int) (name string, line, params int, ok bool) {
+	j := open - 1
+	for j >= 0 && (lx[j].Text == "const" || lx[j].Text == "override" || lx[j].Text == "final" ||
+		lx[j].Text == "noexcept" || lx[j].Text == "mutable" || lx[j].Text == "async") {
+		j--
+	}
+	for k := j; k > j-10 && k > 0; k-- {
+		if t := lx[k].Text; t == ";" || t == "{" || t == "}" {
+			break
+		}
+		if (lx[k].Text == "throws" || lx[k].Text == "->") && lx[k-1].Text == ")" {
+			j = k - 1
+			break
+		}
+	}
+	if j < 1 || lx[j].Text != ")" {
+		return "", 0, 0, false
+	}
+	m, depth := j, 0
+	for ; m >= 0; m-- {
+		switch lx[m].Text {
+		case ")":
+			depth++
+		case "(":
+			depth--
+		}
+		if depth == 0 {
+			break
+		}
+	}
+	if m < 1 || lx[m-1].Kind != lexer.Word || cControlNames[lx[m-1].Text] {
+		return "", 0, 0, false
+	}
+	if m >= 2 && (lx[m-2].Text == "new" || lx[m-2].Text == ".") {
+		return "", 0, 0, false
+	}
+	name = lx[m-1].Text
+	if m >= 3 && lx[m-2].Text == "::" && lx[m-3].Kind == lexer.Word {
+		name = lx[m-3].Text + "::" + name
+	}
+	return name, lx[m-1].Line, countArgs(lx, m), true
+}
+
+// arrowFunction describes the lambda whose arrow is at lx[arrow]: (a, b) =>
+// or a =>, named after the variable it is assigned to, if any.
+func arrowFunction(lx []lexer.Token, arrow, depth int) *pendingFunc {
+	fn := &pendingFunc{depth: depth, name: "<anonymous>", line: lx[arrow].Line}
+	start := arrow - 1
+	switch {
+	case start >= 0 && lx[start].Text == ")":
+		d := 0
+		for ; start >= 0; start-- {
+			switch lx[start].Text {
+			case ")":
+				d++
+			case "(":
+				d--
+			}
+			if d == 0 {
+				break
+			}
+		}
+		if start >= 0 {
+			fn.params = countArgs(lx, start)
+		}
+	case start >= 0 && lx[start].Kind == lexer.Word:
+		fn.params = 1
+	}
+	if start >= 1 && lx[start-1].Text == "async" {
+		start--
+	}
+	if start >= 2 && (lx[start-1].Text == "=" || lx[start-1].Text == ":") && lx[start-2].Kind == lexer.Word {
+		fn.name, fn.line = lx[start-2].Text, lx[start-2].Line
+	}
+	return fn
+}
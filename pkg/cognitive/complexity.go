@@ -6,43 +6,70 @@ import (
 	"go/parser"
 	"go/token"
 	"os"
-	"path/filepath"
 	"strings"
 )
 
 // ComplexityStats holds the structural metrics of a file.
 type ComplexityStats struct {
-	NodeCount       int     `json:"node_count"`
-	Cyclomatic      int     `json:"cyclomatic_complexity"`
-	HalsteadVolume  float64 `json:"halstead_volume"`
-	Functions       int     `json:"function_count"`
+	NodeCount  int      `json:"node_count"`
+	Cyclomatic int      `json:"cyclomatic_complexity"`
+	Functions  int      `json:"function_count"`
+	Halstead   Halstead `json:"halstead"`
+	Cognitive  int      `json:"cognitive_complexity"`
+	Analyzer   string   `json:"analyzer"` // Analyzer ID, e.g. "go-ast@1"
+	Lines      int      `json:"lines"`    // Non-blank lines
+
+	// Maintainability is the maintainability index (0-100) of the file.
+	Maintainability float64 `json:"maintainability_index"`
+
+	// PerFunction measures each function (Go, C-family and Python).
+	PerFunction []FunctionStats `json:"per_function,omitempty"`
 }
 
 // AnalyzeComplexity calculates complexity metrics for a given file.
-// Currently supports detailed AST analysis for Go, and line-based heuristics for others.
+// Go files are analyzed from their AST, C-family and Python files from
+// their tokens, other files with line-based heuristics (see LookupAnalyzer).
 func AnalyzeComplexity(path string) (*ComplexityStats, error) {
-	ext := filepath.Ext(path)
-	if ext == ".go" {
-		return analyzeGoFile(path)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
 	}
-	return analyzeGenericFile(path)
+	return AnalyzeSource(path, content)
 }
 
-func analyzeGoFile(path string) (*ComplexityStats, error) {
+// AnalyzeSource calculates complexity metrics for in-memory content, such as
+// a historical revision of a file. path only selects the analyzer.
+func AnalyzeSource(path string, content []byte) (*ComplexityStats, error) {
+	a := LookupAnalyzer(path)
+	stats, err := a.Analyze(path, content)
+	if err != nil {
+		return nil, err
+	}
+	stats.Analyzer = AnalyzerID(a)
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) != "" {
+			stats.Lines++
+		}
+	}
+	stats.Maintainability = MaintainabilityIndex(stats.Halstead.Volume, stats.Cyclomatic, stats.Lines)
+	return stats, nil
+}
+
+func analyzeGoFile(path string, content []byte) (*ComplexityStats, error) {
 	fset := token.NewFileSet()
-	node, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+	node, err := parser.ParseFile(fset, path, content, parser.ParseComments)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse go file: %w", err)
 	}
 
 	stats := &ComplexityStats{}
-	
+
 	ast.Inspect(node, func(n ast.Node) bool {
 		if n == nil {
 			return true
 		}
 		stats.NodeCount++
-		
+
 		switch t := n.(type) {
 		case *ast.FuncDecl:
 			stats.Functions++
@@ -57,20 +84,16 @@ func analyzeGoFile(path string) (*ComplexityStats, error) {
 		return true
 	})
 
-	// Simple Halstead approximation: Volume = Length * log2(Vocabulary)
-	// We'll use NodeCount as Length and distinct node types as Vocabulary approximation
-	// For MVP, just scaling NodeCount is a proxy.
-	stats.HalsteadVolume = float64(stats.NodeCount) * 5.0 // Placeholder multiplier
+	stats.PerFunction = goFunctions(fset, node)
+	for _, f := range stats.PerFunction {
+		stats.Cognitive += f.Cognitive
+	}
+	stats.Halstead = goHalstead(fset, node, content)
 
 	return stats, nil
 }
 
-func analyzeGenericFile(path string) (*ComplexityStats, error) {
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-	
+func analyzeGenericFile(content []byte) *ComplexityStats {
 	lines := strings.Split(string(content), "\n")
 	nonEmptyLines := 0
 	for _, l := range lines {
@@ -80,10 +103,11 @@ func analyzeGenericFile(path string) (*ComplexityStats, error) {
 	}
 
 	// Heuristic for generic files
+	halstead := genericHalstead(content)
 	return &ComplexityStats{
-		NodeCount:      nonEmptyLines,
-		Cyclomatic:     1 + (nonEmptyLines / 10), // Rough proxy
-		HalsteadVolume: float64(len(content)),
-		Functions:      0,
-	}, nil
+		NodeCount:  nonEmptyLines,
+		Cyclomatic: 1 + (nonEmptyLines / 10), // Rough proxy
+		Functions:  0,
+		Halstead:   halstead,
+	}
 }
@@ -0,0 +1,195 @@
+package cognitive
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// FunctionStats holds the metrics of one function or method.
+type FunctionStats struct {
+	Name       string `json:"name"`       // "F", "T.M" or "(*T).M"
+	Line       int    `json:"line"`       // Line of the func keyword
+	Lines      int    `json:"lines"`      // Lines from the func keyword to the closing brace
+	Params     int    `json:"params"`     // Parameters, including the receiver's
+	Cyclomatic int    `json:"cyclomatic"` // 1 + branches and logical operators
+	Cognitive  int    `json:"cognitive"`  // SonarSource cognitive complexity
+	MaxNesting int    `json:"max_nesting"`
+}
+
+// goFunctions measures every function declaration of a file. Function
+// literals belong to the declaration they appear in.
+func goFunctions(fset *token.FileSet, file *ast.File) []FunctionStats {
+	var funcs []FunctionStats
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		start, end := fset.Position(fn.Pos()), fset.Position(fn.End())
+		stats := FunctionStats{
+			Name:       funcName(fn),
+			Line:       start.Line,
+			Lines:      end.Line - start.Line + 1,
+			Params:     countParams(fn.Recv) + countParams(fn.Type.Params),
+			Cyclomatic: 1,
+		}
+		ast.Inspect(fn, func(n ast.Node) bool {
+			switch t := n.(type) {
+			case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt, *ast.CaseClause, *ast.CommClause:
+				stats.Cyclomatic++
+			case *ast.BinaryExpr:
+				if t.Op == token.LAND || t.Op == token.LOR {
+					stats.Cyclomatic++
+				}
+			}
+			return true
+		})
+		if fn.Body != nil {
+			w := cognitiveWalker{stats: &stats}
+			w.visit(fn.Body, 0)
+		}
+		funcs = append(funcs, stats)
+	}
+	return funcs
+}
+
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	typ := fn.Recv.List[0].Type
+	pointer := false
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ, pointer = star.X, true
+	}
+	// Drop type parameters: T[K] -> T
+	switch t := typ.(type) {
+	case *ast.IndexExpr:
+		typ = t.X
+	case *ast.IndexListExpr:
+		typ = t.X
+	}
+	recv := "?"
+	if id, ok := typ.(*ast.Ident); ok {
+		recv = id.Name
+	}
+	if pointer {
+		return "(*" + recv + ")." + fn.Name.Name
+	}
+	return recv + "." + fn.Name.Name
+}
+
+func countParams(fields *ast.FieldList) int {
+	if fields == nil {
+		return 0
+	}
+	n := 0
+	for _, f := range fields.List {
+		n += max(len(f.Names), 1)
+	}
+	return n
+}
+
+// cognitiveWalker computes the cognitive complexity of a function body
+// following the SonarSource specification:
+//   - if, switch, select, for and range add 1 plus their nesting level;
+//   - else and else if add 1 without a nesting increment;
+//   - each sequence of like logical operators adds 1;
+//   - goto and labeled break or continue add 1;
+//   - the bodies of these structures and of function literals are nested.
+//
+// Recursion is not counted.
+type cognitiveWalker struct {
+	stats *FunctionStats
+}
+
+// structure counts a control structure at the given nesting level.
+func (w *cognitiveWalker) structure(nesting int) {
+	w.stats.Cognitive += 1 + nesting
+	w.stats.MaxNesting = max(w.stats.MaxNesting, nesting+1)
+}
+
+func (w *cognitiveWalker) visit(n ast.Node, nesting int) {
+	switch t := n.(type) {
+	case *ast.IfStmt:
+		w.structure(nesting)
+		w.ifChain(t, nesting)
+		return
+	case *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+		w.structure(nesting)
+		w.children(n, nesting+1)
+		return
+	case *ast.FuncLit:
+		w.children(n, nesting+1)
+		return
+	case *ast.BranchStmt:
+		if t.Tok == token.GOTO || t.Label != nil {
+			w.stats.Cognitive++
+		}
+	case *ast.BinaryExpr:
+		if t.Op == token.LAND || t.Op == token.LOR {
+			var ops []token.Token
+			var operands []ast.Expr
+			flattenLogical(t, &ops, &operands)
+			for i, op := range ops {
+				if i == 0 || op != ops[i-1] {
+					w.stats.Cognitive++
+				}
+			}
+			for _, x := range operands {
+				w.visit(x, nesting)
+			}
+			return
+		}
+	}
+	w.children(n, nesting)
+}
+
+// ifChain walks an if statement and its else branches, which stay at the
+// nesting level of the first if.
+func (w *cognitiveWalker) ifChain(s *ast.IfStmt, nesting int) {
+	if s.Init != nil {
+		w.visit(s.Init, nesting+1)
+	}
+	w.visit(s.Cond, nesting+1)
+	w.visit(s.Body, nesting+1)
+	switch e := s.Else.(type) {
+	case *ast.IfStmt:
+		w.stats.Cognitive++
+		w.ifChain(e, nesting)
+	case *ast.BlockStmt:
+		w.stats.Cognitive++
+		w.visit(e, nesting+1)
+	}
+}
+
+// children visits the direct children of n.
+func (w *cognitiveWalker) children(n ast.Node, nesting int) {
+	ast.Inspect(n, func(c ast.Node) bool {
+		if c == n {
+			return true
+		}
+		if c != nil {
+			w.visit(c, nesting)
+		}
+		return false
+	})
+}
+
+// flattenLogical lists the logical operators of an expression in source
+// order, looking through parentheses, and the operands between them.
+func flattenLogical(e ast.Expr, ops *[]token.Token, operands *[]ast.Expr) {
+	switch t := e.(type) {
+	case *ast.ParenExpr:
+		flattenLogical(t.X, ops, operands)
+		return
+	case *ast.BinaryExpr:
+		if t.Op == token.LAND || t.Op == token.LOR {
+			flattenLogical(t.X, ops, operands)
+			*ops = append(*ops, t.Op)
+			flattenLogical(t.Y, ops, operands)
+			return
+		}
+	}
+	*operands = append(*operands, e)
+}
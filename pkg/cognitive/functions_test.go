@@ -0,0 +1,65 @@
+package cognitive
+
+import (
+	"reflect"
+	"testing"
+)
+
+// The first two functions are the examples of the SonarSource cognitive
+// complexity white paper.
+const functionsSrc = `package p
+
+func sumOfPrimes(max int) int {
+	total := 0
+OUT:
+	for i := 1; i <= max; i++ { // +1
+		for j := 2; j < i; j++ { // +2 (nesting 1)
+			if i%j == 0 { // +3 (nesting 2)
+				continue OUT // +1
+			}
+		}
+		total += i
+	}
+	return total
+}
+
+func getWords(number int) string {
+	switch number { // +1
+	case 1:
+		return "one"
+	case 2:
+		return "a couple"
+	default:
+		return "lots"
+	}
+}
+
+func (s *T[K]) check(a, b, c bool) {
+	if a && b || c { // +1, +2 for two operator sequences
+		go func() {
+			if a { // +3 (nesting 2: if body, function literal)
+			}
+		}()
+	} else if b { // +1
+	} else { // +1
+	}
+}
+`
+
+func TestFunctionStats(t *testing.T) {
+	stats, err := AnalyzeSource("p.go", []byte(functionsSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FunctionStats{
+		{Name: "sumOfPrimes", Line: 3, Lines: 13, Params: 1, Cyclomatic: 4, Cognitive: 7, MaxNesting: 3},
+		{Name: "getWords", Line: 17, Lines: 10, Params: 1, Cyclomatic: 4, Cognitive: 1, MaxNesting: 1},
+		{Name: "(*T).check", Line: 28, Lines: 10, Params: 4, Cyclomatic: 6, Cognitive: 8, MaxNesting: 3},
+	}
+	if !reflect.DeepEqual(stats.PerFunction, want) {
+		t.Errorf("PerFunction =\n%+v\nwant\n%+v", stats.PerFunction, want)
+	}
+	if stats.Cognitive != 16 {
+		t.Errorf("file cognitive complexity = %d, want 16", stats.Cognitive)
+	}
+}
@@ -0,0 +1,160 @@
+package cognitive
+
+import (
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"math"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Halstead holds the Halstead software science measures of a file.
+type Halstead struct {
+	DistinctOperators int     `json:"distinct_operators"` // n1
+	DistinctOperands  int     `json:"distinct_operands"`  // n2
+	Operators         int     `json:"operators"`          // N1
+	Operands          int     `json:"operands"`           // N2
+	Vocabulary        int     `json:"vocabulary"`         // n = n1 + n2
+	Length            int     `json:"length"`             // N = N1 + N2
+	Volume            float64 `json:"volume"`             // V = N * log2(n)
+	Difficulty        float64 `json:"difficulty"`         // D = n1/2 * N2/n2
+	Effort            float64 `json:"effort"`             // E = D * V
+	Bugs              float64 `json:"bugs"`               // B = V / 3000
+}
+
+// halsteadCounter accumulates operator and operand occurrences.
+type halsteadCounter struct {
+	operators map[string]int
+	operands  map[string]int
+}
+
+func newHalsteadCounter() *halsteadCounter {
+	return &halsteadCounter{operators: make(map[string]int), operands: make(map[string]int)}
+}
+
+func (c *halsteadCounter) operator(op string) { c.operators[op]++ }
+func (c *halsteadCounter) operand(v string)   { c.operands[v]++ }
+
+// measures derives the Halstead measures from the counts.
+func (c *halsteadCounter) measures() Halstead {
+	h := Halstead{DistinctOperators: len(c.operators), DistinctOperands: len(c.operands)}
+	for _, n := range c.operators {
+		h.Operators += n
+	}
+	for _, n := range c.operands {
+		h.Operands += n
+	}
+	h.Vocabulary = h.DistinctOperators + h.DistinctOperands
+	h.Length = h.Operators + h.Operands
+	if h.Vocabulary > 0 {
+		h.Volume = float64(h.Length) * math.Log2(float64(h.Vocabulary))
+	}
+	if h.DistinctOperands > 0 {
+		h.Difficulty = float64(h.DistinctOperators) / 2 * float64(h.Operands) / float64(h.DistinctOperands)
+	}
+	h.Effort = h.Difficulty * h.Volume
+	h.Bugs = h.Volume / 3000
+	return h
+}
+
+// goHalstead counts the tokens of a parsed Go file. Identifiers and basic
+// literals are operands; keywords, operators and delimiters are operators,
+// with bracket pairs counted once. The package clause and imports are
+// declarations rather than logic and are skipped, as are comments and the
+// semicolons inserted at line ends.
+func goHalstead(fset *token.FileSet, file *ast.File, content []byte) Halstead {
+	type span struct{ from, to token.Pos }
+	skip := []span{{file.Package, file.Name.End()}}
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			skip = append(skip, span{gen.Pos(), gen.End()})
+		}
+	}
+	skipped := func(pos token.Pos) bool {
+		for _, s := range skip {
+			if pos >= s.from && pos < s.to {
+				return true
+			}
+		}
+		return false
+	}
+
+	c := newHalsteadCounter()
+	tf := fset.File(file.Pos())
+	var s scanner.Scanner
+	s.Init(tf, content, nil, 0)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if skipped(pos) {
+			continue
+		}
+		switch {
+		case tok == token.IDENT || tok.IsLiteral():
+			c.operand(lit)
+		case tok == token.SEMICOLON:
+			if lit == ";" {
+				c.operator(";")
+			}
+		case tok == token.LPAREN:
+			c.operator("()")
+		case tok == token.LBRACK:
+			c.operator("[]")
+		case tok == token.LBRACE:
+			c.operator("{}")
+		case tok == token.RPAREN, tok == token.RBRACK, tok == token.RBRACE:
+			// Counted with the opening bracket
+		default:
+			c.operator(tok.String())
+		}
+	}
+	return c.measures()
+}
+
+// genericHalstead approximates the counts for files without a parser:
+// runs of letters, digits and underscores are operands, other runs of
+// punctuation are operators, and brackets count once per pair.
+func genericHalstead(content []byte) Halstead {
+	c := newHalsteadCounter()
+	word := func(r rune) bool { return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) }
+	for i := 0; i < len(content); {
+		r, size := utf8.DecodeRune(content[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case word(r):
+			j := i
+			for j < len(content) {
+				r, size := utf8.DecodeRune(content[j:])
+				if !word(r) {
+					break
+				}
+				j += size
+			}
+			c.operand(string(content[i:j]))
+			i = j
+		case r == '(' || r == '[' || r == '{':
+			c.operator(string(r) + string(closing[r]))
+			i += size
+		case r == ')' || r == ']' || r == '}':
+			i += size
+		default:
+			j := i
+			for j < len(content) {
+				r, size := utf8.DecodeRune(content[j:])
+				if word(r) || unicode.IsSpace(r) || closing[r] != 0 || r == ')' || r == ']' || r == '}' {
+					break
+				}
+				j += size
+			}
+			c.operator(string(content[i:j]))
+			i = j
+		}
+	}
+	return c.measures()
+}
+
+var closing = map[rune]rune{'(': ')', '[': ']', '{': '}'}
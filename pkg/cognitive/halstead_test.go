@@ -0,0 +1,77 @@
+package cognitive
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHalsteadGo(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want Halstead
+	}{
+		{
+			// Operators: func () , {} return +; operands: add a b int (x2) a b
+			name: "add",
+			src:  "package p\n\nfunc add(a, b int) int {\n\treturn a + b\n}\n",
+			want: Halstead{DistinctOperators: 6, DistinctOperands: 4, Operators: 6, Operands: 7},
+		},
+		{
+			// The import is skipped. Operators: func ()x2 {}x2 if > . *;
+			// operands: f x int x 0 fmt Println x 2
+			name: "call",
+			src:  "package p\n\nimport \"fmt\"\n\nfunc f(x int) {\n\tif x > 0 {\n\t\tfmt.Println(x * 2)\n\t}\n}\n",
+			want: Halstead{DistinctOperators: 7, DistinctOperands: 7, Operators: 9, Operands: 9},
+		},
+	}
+	for _, tt := range tests {
+		stats, err := AnalyzeSource("x.go", []byte(tt.src))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		checkCounts(t, tt.name, stats.Halstead, tt.want)
+	}
+
+	stats, _ := AnalyzeSource("x.go", []byte(tests[0].src))
+	h := stats.Halstead
+	// n = 10, N = 13, V = 13 log2 10, D = 6/2 * 7/4, E = D * V
+	approx(t, "volume", h.Volume, 43.185)
+	approx(t, "difficulty", h.Difficulty, 5.25)
+	approx(t, "effort", h.Effort, 226.722)
+	approx(t, "bugs", h.Bugs, 0.014)
+}
+
+func TestHalsteadGeneric(t *testing.T) {
+	// Operands: x y 1 x; operators: = + () ; ==
+	stats, err := AnalyzeSource("x.txt", []byte("x = y + (1);\nx == y;\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCounts(t, "generic", stats.Halstead, Halstead{DistinctOperators: 5, DistinctOperands: 3, Operators: 6, Operands: 5})
+
+	empty := genericHalstead(nil)
+	if empty.Volume != 0 || empty.Difficulty != 0 || empty.Effort != 0 {
+		t.Errorf("empty file: %+v", empty)
+	}
+}
+
+func checkCounts(t *testing.T, name string, got, want Halstead) {
+	t.Helper()
+	if got.DistinctOperators != want.DistinctOperators || got.DistinctOperands != want.DistinctOperands ||
+		got.Operators != want.Operators || got.Operands != want.Operands {
+		t.Errorf("%s: n1=%d n2=%d N1=%d N2=%d, want n1=%d n2=%d N1=%d N2=%d", name,
+			got.DistinctOperators, got.DistinctOperands, got.Operators, got.Operands,
+			want.DistinctOperators, want.DistinctOperands, want.Operators, want.Operands)
+	}
+	if got.Vocabulary != want.DistinctOperators+want.DistinctOperands || got.Length != want.Operators+want.Operands {
+		t.Errorf("%s: vocabulary %d, length %d", name, got.Vocabulary, got.Length)
+	}
+}
+
+func approx(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 0.001 {
+		t.Errorf("%s = %.4f, want %.3f", name, got, want)
+	}
+}
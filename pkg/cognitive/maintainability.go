@@ -0,0 +1,79 @@
+package cognitive
+
+import (
+	"math"
+	"path"
+)
+
+// MaintainabilityIndex returns the maintainability index normalized to
+// 0-100 (higher is easier to maintain):
+//
+//	max(0, (171 - 5.2 ln(V) - 0.23 G - 16.2 ln(LOC)) * 100 / 171)
+//
+// where V is the Halstead volume, G the cyclomatic complexity and LOC the
+// non-blank lines. Empty files score 100.
+func MaintainabilityIndex(volume float64, cyclomatic, loc int) float64 {
+	if loc == 0 {
+		return 100
+	}
+	mi := 171 - 5.2*math.Log(max(volume, 1)) - 0.23*float64(cyclomatic) - 16.2*math.Log(float64(loc))
+	return math.Round(min(max(mi*100/171, 0), 100)*100) / 100
+}
+
+// Summary aggregates the complexity of a set of files.
+type Summary struct {
+	Files           int     `json:"files"`
+	Lines           int     `json:"lines"` // Non-blank lines
+	Functions       int     `json:"functions"`
+	Cyclomatic      int     `json:"cyclomatic_complexity"`
+	Cognitive       int     `json:"cognitive_complexity"`
+	HalsteadVolume  float64 `json:"halstead_volume"`
+	HalsteadEffort  float64 `json:"halstead_effort"`
+	Maintainability float64 `json:"maintainability_index"` // Mean of the files, weighted by lines
+
+	// Unrounded sums behind the fields above
+	volume, effort, weightedMI float64
+}
+
+// Add counts one file.
+func (s *Summary) Add(stats *ComplexityStats) {
+	s.Files++
+	s.Lines += stats.Lines
+	s.Functions += stats.Functions
+	s.Cyclomatic += stats.Cyclomatic
+	s.Cognitive += stats.Cognitive
+	s.volume += stats.Halstead.Volume
+	s.effort += stats.Halstead.Effort
+	s.HalsteadVolume = math.Round(s.volume*100) / 100
+	s.HalsteadEffort = math.Round(s.effort*100) / 100
+	s.weightedMI += stats.Maintainability * float64(stats.Lines)
+	s.Maintainability = 100
+	if s.Lines > 0 {
+		s.Maintainability = math.Round(s.weightedMI/float64(s.Lines)*100) / 100
+	}
+}
+
+// Report summarizes the source files of a tree, in total and per package
+// (directory). Files without a language analyzer are left out.
+type Report struct {
+	Total    Summary            `json:"total"`
+	Packages map[string]Summary `json:"packages,omitempty"` // By slash-separated directory, "." for the root
+}
+
+// NewReport returns an empty report.
+func NewReport() *Report {
+	return &Report{Packages: make(map[string]Summary)}
+}
+
+// Add counts the file at the slash-separated path relative to the root of
+// the tree.
+func (r *Report) Add(relPath string, stats *ComplexityStats) {
+	if stats.Analyzer == AnalyzerID(genericAnalyzer{}) {
+		return
+	}
+	r.Total.Add(stats)
+	dir := path.Dir(relPath)
+	pkg := r.Packages[dir]
+	pkg.Add(stats)
+	r.Packages[dir] = pkg
+}
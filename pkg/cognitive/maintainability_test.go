@@ -0,0 +1,72 @@
+package cognitive
+
+import "testing"
+
+func TestMaintainabilityIndex(t *testing.T) {
+	tests := []struct {
+		volume     float64
+		cyclomatic int
+		loc        int
+		want       float64
+	}{
+		// (171 - 5.2 ln 1000 - 0.23*10 - 16.2 ln 100) * 100/171
+		{1000, 10, 100, 34.02},
+		{0, 0, 0, 100},
+		// Clamped at zero
+		{1e9, 5000, 1e6, 0},
+	}
+	for _, tt := range tests {
+		if got := MaintainabilityIndex(tt.volume, tt.cyclomatic, tt.loc); got != tt.want {
+			t.Errorf("MaintainabilityIndex(%v, %d, %d) = %v, want %v", tt.volume, tt.cyclomatic, tt.loc, got, tt.want)
+		}
+	}
+
+	// V = 43.185, G = 1, 4 non-blank lines
+	stats, err := AnalyzeSource("x.go", []byte("package p\n\nfunc add(a, b int) int {\n\treturn a + b\n}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Lines != 4 || stats.Cyclomatic != 1 {
+		t.Fatalf("lines = %d, cyclomatic = %d, want 4 and 1", stats.Lines, stats.Cyclomatic)
+	}
+	approx(t, "maintainability", stats.Maintainability, 75.28)
+}
+
+func TestReport(t *testing.T) {
+	small := &ComplexityStats{Analyzer: "go-ast@1", Lines: 10, Cyclomatic: 2, Cognitive: 1, Functions: 1, Maintainability: 80}
+	large := &ComplexityStats{Analyzer: "go-ast@1", Lines: 30, Cyclomatic: 6, Cognitive: 9, Functions: 2, Maintainability: 40}
+	doc := &ComplexityStats{Analyzer: "generic@1", Lines: 50, Cyclomatic: 6}
+
+	r := NewReport()
+	r.Add("main.go", small)
+	r.Add("pkg/a/a.go", large)
+	r.Add("pkg/a/b.go", small)
+	r.Add("README.md", doc)
+
+	if r.Total.Files != 3 || r.Total.Lines != 50 || r.Total.Cognitive != 11 || r.Total.Cyclomatic != 10 {
+		t.Errorf("total = %+v", r.Total)
+	}
+	// (80*10 + 40*30 + 80*10) / 50
+	if r.Total.Maintainability != 56 {
+		t.Errorf("total maintainability = %v, want 56", r.Total.Maintainability)
+	}
+	if len(r.Packages) != 2 {
+		t.Fatalf("packages = %v, want . and pkg/a", r.Packages)
+	}
+	// (40*30 + 80*10) / 40
+	if pkg := r.Packages["pkg/a"]; pkg.Files != 2 || pkg.Maintainability != 50 {
+		t.Errorf("pkg/a = %+v", pkg)
+	}
+	if pkg := r.Packages["."]; pkg.Files != 1 || pkg.Maintainability != 80 {
+		t.Errorf(". = %+v", pkg)
+	}
+
+	// Sums are rounded once: 3 * 0.004 is 0.01, not 3 * 0
+	var s Summary
+	for i := 0; i < 3; i++ {
+		s.Add(&ComplexityStats{Halstead: Halstead{Volume: 0.004, Effort: 0.004}})
+	}
+	if s.HalsteadVolume != 0.01 || s.HalsteadEffort != 0.01 {
+		t.Errorf("Halstead sums = %v, %v; want 0.01", s.HalsteadVolume, s.HalsteadEffort)
+	}
+}
@@ -0,0 +1,136 @@
+package cognitive
+
+import "github.com/windgeek/HCP/pkg/lexer"
+
+// pyKeywords are the words counted as Halstead operators.
+var pyKeywords = keywordSet(
+	"and", "as", "assert", "async", "await", "break", "class", "continue", "def", "del", "elif", "else", "except",
+	"finally", "for", "from", "global", "if", "import", "in", "is", "lambda", "nonlocal", "not", "or", "pass",
+	"raise", "return", "try", "while", "with", "yield", "match", "case",
+)
+
+// pythonAnalyzer measures Python from its tokens, nesting by indentation:
+// if, for, while and except are control structures, elif and else add
+// one, conditional expressions count like if, match cases are decisions
+// and each sequence of and/or adds one. Nested functions belong to the
+// outermost one.
+type pythonAnalyzer struct{}
+
+func (pythonAnalyzer) Name() string { return "python" }
+func (pythonAnalyzer) Version() int { return 1 }
+
+func (pythonAnalyzer) Analyze(path string, content []byte) (*ComplexityStats, error) {
+	lx := lexer.Python.Lex(string(content))
+	t := newTally()
+
+	type block struct {
+		indent    int
+		kind      braceKind
+		outermost bool
+	}
+	var blocks []block
+	nesting := func() int {
+		n, funcs := 0, 0
+		for _, b := range blocks {
+			switch b.kind {
+			case braceControl, braceSwitch:
+				n++
+			case braceFunction:
+				funcs++
+			}
+		}
+		return n + max(funcs-1, 0)
+	}
+
+	lastLine := 0 // Last line of the previous logical line
+	for start := 0; start < len(lx); {
+		end := start
+		for end < len(lx) && lx[end].Kind != lexer.Newline {
+			end++
+		}
+		line := lx[start:end]
+		next := end + 1
+		if len(line) == 0 {
+			start = next
+			continue
+		}
+
+		// 1. Close the blocks this line is not indented into
+		indent := line[0].Col
+		for len(blocks) > 0 && blocks[len(blocks)-1].indent >= indent {
+			if blocks[len(blocks)-1].outermost {
+				t.close(lastLine)
+			}
+			blocks = blocks[:len(blocks)-1]
+		}
+		lastLine = line[len(line)-1].Line
+
+		// 2. Statement keyword
+		first := 0
+		if line[0].Text == "async" && len(line) > 1 {
+			first = 1
+		}
+		opens := line[len(line)-1].Text == ":"
+		kind := braceOther
+		switch line[first].Text {
+		case "if", "for", "while", "except":
+			t.structure(nesting())
+			t.decision()
+			kind, opens = braceControl, true
+		case "elif":
+			t.increment(1)
+			t.decision()
+			kind, opens = braceControl, true
+		case "else":
+			t.increment(1)
+			kind, opens = braceControl, true
+		case "match":
+			if opens && len(line) > 2 {
+				t.structure(nesting())
+				kind = braceSwitch
+			}
+		case "case":
+			if len(blocks) > 0 && blocks[len(blocks)-1].kind == braceSwitch {
+				t.decision()
+			}
+		case "def":
+			kind, opens = braceFunction, true
+		}
+
+		// 3. Expressions: conditional expressions and logical operators
+		for i := first + 1; i < len(line); i++ {
+			switch line[i].Text {
+			case "if":
+				t.structure(nesting())
+				t.decision()
+			case "and", "or":
+				t.logical(line[i].Text)
+			}
+		}
+		t.endExpression()
+
+		if opens {
+			b := block{indent: indent, kind: kind}
+			if kind == braceFunction && t.current < 0 {
+				name, params := "<anonymous>", 0
+				if first+1 < len(line) {
+					name = line[first+1].Text
+				}
+				for i := first + 1; i < len(line); i++ {
+					if line[i].Text == "(" {
+						params = countArgs(line, i)
+						break
+					}
+				}
+				t.open(name, line[first].Line, params)
+				b.outermost = true
+			}
+			blocks = append(blocks, b)
+		}
+		start = next
+	}
+	if t.current >= 0 {
+		t.close(lastLine)
+	}
+	return t.finish(lx, pyKeywords), nil
+}
@@ -0,0 +1,144 @@
+package cognitive
+
+import "github.com/windgeek/HCP/pkg/lexer"
+
+// tokenHalstead counts a token stream: keywords and punctuation are
+// operators, other words, numbers and strings operands. Bracket pairs
+// count once.
+func tokenHalstead(tokens []lexer.Token, keywords map[string]bool) Halstead {
+	c := newHalsteadCounter()
+	for _, tok := range tokens {
+		switch {
+		case tok.Kind == lexer.Newline:
+		case tok.Kind == lexer.Punct:
+			switch tok.Text {
+			case ")", "]", "}":
+				// Counted with the opening bracket
+			case "(", "[", "{":
+				c.operator(tok.Text + string(closing[rune(tok.Text[0])]))
+			default:
+				c.operator(tok.Text)
+			}
+		case tok.Kind == lexer.Word && keywords[tok.Text]:
+			c.operator(tok.Text)
+		default:
+			c.operand(tok.Text)
+		}
+	}
+	return c.measures()
+}
+
+// tally accumulates the complexity of a token stream into stats and the
+// function currently open.
+type tally struct {
+	stats    *ComplexityStats
+	current  int    // Index in stats.PerFunction of the outermost open function, -1 at top level
+	lastBool string // Last logical operator of the current expression
+}
+
+func newTally() *tally {
+	return &tally{stats: &ComplexityStats{}, current: -1}
+}
+
+func (t *tally) function() *FunctionStats {
+	if t.current < 0 {
+		return nil
+	}
+	return &t.stats.PerFunction[t.current]
+}
+
+// decision counts a branch, loop or logical operator for cyclomatic
+// complexity.
+func (t *tally) decision() {
+	t.stats.Cyclomatic++
+	if f := t.function(); f != nil {
+		f.Cyclomatic++
+	}
+}
+
+// structure counts a control structure at the given nesting level.
+func (t *tally) structure(nesting int) {
+	t.increment(1 + nesting)
+	if f := t.function(); f != nil {
+		f.MaxNesting = max(f.MaxNesting, nesting+1)
+	}
+}
+
+func (t *tally) increment(n int) {
+	t.stats.Cognitive += n
+	if f := t.function(); f != nil {
+		f.Cognitive += n
+	}
+}
+
+// open starts an outermost function.
+func (t *tally) open(name string, line, params int) {
+	t.stats.PerFunction = append(t.stats.PerFunction, FunctionStats{Name: name, Line: line, Params: params, Cyclomatic: 1})
+	t.stats.Functions++
+	t.current = len(t.stats.PerFunction) - 1
+}
+
+// close ends the outermost function on its last line.
+func (t *tally) close(line int) {
+	if f := t.function(); f != nil {
+		f.Lines = line - f.Line + 1
+	}
+	t.current = -1
+}
+
+// finish derives the file totals: one path per function, or one for a
+// file without functions.
+func (t *tally) finish(tokens []lexer.Token, keywords map[string]bool) *ComplexityStats {
+	t.stats.Cyclomatic += max(t.stats.Functions, 1)
+	t.stats.NodeCount = len(tokens)
+	t.stats.Halstead = tokenHalstead(tokens, keywords)
+	return t.stats
+}
+
+// logical counts a logical operator; a sequence of like operators adds
+// one to cognitive complexity.
+func (t *tally) logical(op string) {
+	t.decision()
+	if op != t.lastBool {
+		t.increment(1)
+	}
+	t.lastBool = op
+}
+
+// endExpression ends a sequence of logical operators.
+func (t *tally) endExpression() {
+	t.lastBool = ""
+}
+
+// countArgs counts the comma-separated entries between the brackets at
+// tokens[open] and its match.
+func countArgs(tokens []lexer.Token, open int) int {
+	n, depth, empty := 0, 0, true
+	for i := open; i < len(tokens); i++ {
+		switch tokens[i].Text {
+		case "(", "[", "{":
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case ")", "]", "}":
+			depth--
+			if depth == 0 {
+				if !empty {
+					n++
+				}
+				return n
+			}
+		case ",":
+			if depth == 1 {
+				n++
+				empty = true
+				continue
+			}
+		}
+		if depth >= 1 {
+			empty = false
+		}
+	}
+	return n
+}
@@ -11,9 +11,33 @@ import (
 // Config holds the HCP configuration.
 type Config struct {
 	IdentityKeyPath string `yaml:"identity_key_path"`
+
+	// Fuzzy Verification tolerances (unset selects the built-in default)
+	SimilarityThreshold *int `yaml:"similarity_threshold,omitempty"` // Min text similarity score (0-100)
+	PerceptualThreshold *int `yaml:"phash_threshold,omitempty"`      // Max image Hamming distance (0-64)
+
+	// AHA author scoping (emails are matched after .mailmap resolution)
+	AuthorEmails      []string          `yaml:"author_emails,omitempty"`       // Commits counted towards AHA; empty counts every author
+	EmailAliases      map[string]string `yaml:"email_aliases,omitempty"`       // Alternate email -> canonical email
+	SignedCommitsOnly bool              `yaml:"signed_commits_only,omitempty"` // Only count commits with an HCP-Signature trailer from the identity key
+	MicroHistory      bool              `yaml:"micro_history,omitempty"`       // Also count reflog, stash, dangling commits and hcp snapshots
+	VimUndo           bool              `yaml:"vim_undo,omitempty"`            // Attach Vim undo history summaries to the AHA metrics
+	VimUndoDirs       []string          `yaml:"vim_undo_dirs,omitempty"`       // Vim 'undodir' directories; empty uses the Vim and Neovim defaults
+
+	// AHA scoring (recorded in the manifest so verifiers can recompute scores)
+	ScoringModel  string                      `yaml:"scoring_model,omitempty"`  // Built-in model or a key of ScoringModels; empty uses aha-v4
+	ScoringModels map[string][]ScoringFeature `yaml:"scoring_models,omitempty"` // Custom models: weighted features with caps
 	// Add more config fields here as needed
 }
 
+// ScoringFeature is one term of a custom scoring model: the metric
+// contributes Weight points once it reaches Cap. Weights add up to 100.
+type ScoringFeature struct {
+	Metric string  `yaml:"metric"`
+	Weight float64 `yaml:"weight"`
+	Cap    float64 `yaml:"cap"`
+}
+
 // DefaultConfig returns the default configuration.
 func DefaultConfig() (*Config, error) {
 	home, err := os.UserHomeDir()
@@ -0,0 +1,43 @@
+package hash
+
+import (
+	"os"
+	"strings"
+
+	"github.com/windgeek/HCP/pkg/lexer"
+)
+
+// cFamilyHasher tokenizes C-like source (C, C++, Java, JavaScript/TypeScript,
+// C#, Rust, ...) and hashes the token stream, so comments, whitespace and
+// line breaks are ignored. String and character literals are kept intact.
+// Preprocessor directives end at a newline, which is therefore preserved
+// after them.
+type cFamilyHasher struct {
+	lexer lexer.Lexer
+}
+
+// newCFamilyHasher hashes the tokens of l, which is made to end directives.
+func newCFamilyHasher(l lexer.Lexer) cFamilyHasher {
+	l.Directives = true
+	return cFamilyHasher{lexer: l}
+}
+
+func (cFamilyHasher) Name() string { return "c-family" }
+
+// Version 2 reads Rust lifetimes as words. Version 3 tokenizes with the
+// lexer of the complexity analyzers: multi-character operators and numbers
+// are single tokens, and only JavaScript quotes with backticks.
+func (cFamilyHasher) Version() int { return 3 }
+
+func (h cFamilyHasher) Hash(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	tokens := h.lexer.Lex(string(data))
+	texts := make([]string, len(tokens))
+	for i, tok := range tokens {
+		texts[i] = tok.Text
+	}
+	return sumString(strings.Join(texts, " ")), nil
+}
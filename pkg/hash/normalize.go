@@ -0,0 +1,222 @@
+package hash
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Mode selects how identifiers are treated by ComputeNormalizedHash.
+type Mode int
+
+const (
+	// ModeAST hashes the full syntax tree with every identifier verbatim.
+	// It survives reformatting and comment edits, nothing else.
+	ModeAST Mode = iota
+	// ModeAlpha additionally replaces local variables, parameters, results,
+	// receivers, local constants/types and labels with positional placeholders
+	// ("alpha-normalization"). Package-level names, fields, methods and
+	// imported references are kept, so only behaviour-neutral renames survive.
+	ModeAlpha
+)
+
+// String returns the manifest name of the mode.
+func (m Mode) String() string {
+	switch m {
+	case ModeAST:
+		return "ast"
+	case ModeAlpha:
+		return "alpha"
+	}
+	return fmt.Sprintf("mode(%d)", int(m))
+}
+
+// ComputeNormalizedHash hashes the complete AST of a Go file (function bodies
+// included), ignoring comments, whitespace and import order.
+func ComputeNormalizedHash(path string, mode Mode) (string, error) {
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return "", err
+	}
+
+	n := &normalizer{mode: mode}
+	if mode == ModeAlpha {
+		n.info = resolveIdents(fset, node)
+	}
+
+	fmt.Fprintf(&n.sb, "pkg:%s;", node.Name.Name)
+
+	// Imports (Sorted to ignore order)
+	var imports []string
+	for _, imp := range node.Imports {
+		name := ""
+		if imp.Name != nil {
+			name = imp.Name.Name + " "
+		}
+		imports = append(imports, name+imp.Path.Value)
+	}
+	sort.Strings(imports)
+	for _, imp := range imports {
+		fmt.Fprintf(&n.sb, "imp:%s;", imp)
+	}
+
+	for _, decl := range node.Decls {
+		if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+			continue
+		}
+		// Placeholders are positional within each top-level declaration.
+		n.locals = make(map[types.Object]int)
+		ast.Inspect(decl, n.visit)
+	}
+
+	h := sha256.New()
+	h.Write([]byte(n.sb.String()))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ComputeAlphaHash is shorthand for ComputeNormalizedHash(path, ModeAlpha).
+func ComputeAlphaHash(path string) (string, error) {
+	return ComputeNormalizedHash(path, ModeAlpha)
+}
+
+type normalizer struct {
+	mode   Mode
+	info   *types.Info
+	locals map[types.Object]int
+	sb     strings.Builder
+
+	// qualifyImports replaces import names with import paths, making the
+	// hash independent of per-file import aliases.
+	qualifyImports bool
+}
+
+func (n *normalizer) visit(node ast.Node) bool {
+	if node == nil {
+		n.sb.WriteString(")")
+		return true
+	}
+
+	switch t := node.(type) {
+	case *ast.CommentGroup, *ast.Comment:
+		return false
+	case *ast.Ident:
+		fmt.Fprintf(&n.sb, "(id:%s", n.identName(t))
+		return true
+	case *ast.BasicLit:
+		fmt.Fprintf(&n.sb, "(lit:%s:%s", t.Kind, t.Value)
+		return true
+	case *ast.BinaryExpr:
+		fmt.Fprintf(&n.sb, "(bin:%s", t.Op)
+		return true
+	case *ast.UnaryExpr:
+		fmt.Fprintf(&n.sb, "(un:%s", t.Op)
+		return true
+	case *ast.AssignStmt:
+		fmt.Fprintf(&n.sb, "(assign:%s", t.Tok)
+		return true
+	case *ast.IncDecStmt:
+		fmt.Fprintf(&n.sb, "(incdec:%s", t.Tok)
+		return true
+	case *ast.BranchStmt:
+		fmt.Fprintf(&n.sb, "(branch:%s", t.Tok)
+		return true
+	case *ast.GenDecl:
+		fmt.Fprintf(&n.sb, "(decl:%s", t.Tok)
+		return true
+	case *ast.ChanType:
+		fmt.Fprintf(&n.sb, "(chan:%d", t.Dir)
+		return true
+	}
+
+	fmt.Fprintf(&n.sb, "(%T", node)
+	return true
+}
+
+// identName returns the name to hash for an identifier: a positional
+// placeholder for function-local objects in ModeAlpha, the source name otherwise.
+func (n *normalizer) identName(id *ast.Ident) string {
+	if n.info == nil || id.Name == "_" {
+		return id.Name
+	}
+
+	obj := n.info.Defs[id]
+	if obj == nil {
+		obj = n.info.Uses[id]
+	}
+	if pn, ok := obj.(*types.PkgName); ok && n.qualifyImports {
+		return "import:" + pn.Imported().Path()
+	}
+	if n.mode != ModeAlpha || obj == nil || !isLocal(obj) {
+		return id.Name
+	}
+
+	idx, ok := n.locals[obj]
+	if !ok {
+		idx = len(n.locals)
+		n.locals[obj] = idx
+	}
+	return fmt.Sprintf("$%d", idx)
+}
+
+// isLocal reports whether obj is declared inside a function (including its
+// signature) rather than at package level or in the universe.
+func isLocal(obj types.Object) bool {
+	switch o := obj.(type) {
+	case *types.Label:
+		return true
+	case *types.Var:
+		if o.IsField() {
+			return false
+		}
+	case *types.Const, *types.TypeName:
+	default:
+		return false
+	}
+	scope := obj.Parent()
+	if scope == nil || scope == types.Universe {
+		return false
+	}
+	if pkg := obj.Pkg(); pkg != nil && scope == pkg.Scope() {
+		return false
+	}
+	return true
+}
+
+// resolveIdents type-checks a single file to bind identifiers to scopes.
+// Imports and other files of the package are not loaded; unresolved
+// references are simply kept verbatim, which is exactly what we want for
+// package-level and imported names.
+func resolveIdents(fset *token.FileSet, file *ast.File) *types.Info {
+	info := &types.Info{
+		Defs: make(map[*ast.Ident]types.Object),
+		Uses: make(map[*ast.Ident]types.Object),
+	}
+	conf := types.Config{
+		Importer: stubImporter{},
+		Error:    func(error) {}, // best effort; errors are expected without dependencies
+	}
+	conf.Check(file.Name.Name, fset, []*ast.File{file}, info)
+	return info
+}
+
+// stubImporter satisfies imports with empty packages so that type checking
+// never touches the network or the build cache.
+type stubImporter struct{}
+
+func (stubImporter) Import(importPath string) (*types.Package, error) {
+	name := path.Base(importPath)
+	if i := strings.IndexAny(name, ".-"); i > 0 {
+		name = name[:i]
+	}
+	pkg := types.NewPackage(importPath, name)
+	pkg.MarkComplete()
+	return pkg, nil
+}
@@ -0,0 +1,111 @@
+package hash
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const alphaBase = `package demo
+
+import "fmt"
+
+var Total int
+
+func Sum(values []int) (result int) {
+	for i, v := range values {
+		if i > 0 {
+			result += v
+		}
+	}
+	fmt.Println(result)
+	Total = result
+	return result
+}
+`
+
+func writeSrc(t *testing.T, dir, name, src string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestAlphaHashRenames(t *testing.T) {
+	dir := t.TempDir()
+	base := writeSrc(t, dir, "base.go", alphaBase)
+
+	cases := []struct {
+		name      string
+		src       string
+		sameAST   bool
+		sameAlpha bool
+	}{
+		{
+			name: "reformatted",
+			src: `package demo
+import "fmt"
+// Total is the running total.
+var Total int
+func Sum(values []int) (result int) {
+	for i, v := range values { if i > 0 { result += v } }
+	fmt.Println(result); Total = result
+	return result
+}
+`,
+			sameAST: true, sameAlpha: true,
+		},
+		{
+			name:    "local rename",
+			src:     replaceAll(alphaBase, map[string]string{"values": "xs", "result": "acc", "i, v": "idx, x", "i > 0": "idx > 0", "+= v": "+= x"}),
+			sameAST: false, sameAlpha: true,
+		},
+		{
+			name:    "exported rename",
+			src:     replaceAll(alphaBase, map[string]string{"func Sum": "func Add"}),
+			sameAST: false, sameAlpha: false,
+		},
+		{
+			name:    "package-level reference",
+			src:     replaceAll(alphaBase, map[string]string{"var Total int": "var Total, Other int", "Total = result": "Other = result"}),
+			sameAST: false, sameAlpha: false,
+		},
+		{
+			name:    "behaviour change",
+			src:     replaceAll(alphaBase, map[string]string{"i > 0": "i > 1"}),
+			sameAST: false, sameAlpha: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := writeSrc(t, dir, "variant.go", tc.src)
+			for _, m := range []struct {
+				mode Mode
+				want bool
+			}{{ModeAST, tc.sameAST}, {ModeAlpha, tc.sameAlpha}} {
+				h1, err := ComputeNormalizedHash(base, m.mode)
+				if err != nil {
+					t.Fatal(err)
+				}
+				h2, err := ComputeNormalizedHash(p, m.mode)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if (h1 == h2) != m.want {
+					t.Errorf("%s: hashes equal = %v, want %v", m.mode, h1 == h2, m.want)
+				}
+			}
+		})
+	}
+}
+
+func replaceAll(s string, repl map[string]string) string {
+	for old, new := range repl {
+		s = strings.ReplaceAll(s, old, new)
+	}
+	return s
+}
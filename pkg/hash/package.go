@@ -0,0 +1,184 @@
+package hash
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// PackageHash is the file-layout independent logic hash of one Go package.
+type PackageHash struct {
+	ImportPath string `json:"import_path"`
+	Dir        string `json:"dir"`                 // Relative to the release root (slash separated)
+	APIHash    string `json:"api_hash"`            // Exported API surface
+	ImplHash   string `json:"impl_hash"`           // Every declaration of the non-test build
+	TestHash   string `json:"test_hash,omitempty"` // _test.go files (in-package and external)
+	// Files lists the file names in Dir that the hashes cover: the Go files
+	// of the build, tests included, sorted. Files excluded by build
+	// constraints are not covered.
+	Files []string `json:"files,omitempty"`
+}
+
+// ComputePackageHashes loads every package under root with type information
+// and hashes each one independently of how its code is split across files.
+// Build constraints are honoured for the host platform plus the given tags.
+func ComputePackageHashes(root string, tags []string) ([]PackageHash, error) {
+	// NeedDeps type-checks dependencies from source rather than relying on
+	// compiler export data, whose format varies between Go toolchains.
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedSyntax |
+			packages.NeedImports | packages.NeedDeps | packages.NeedTypes | packages.NeedTypesInfo,
+		Dir:   root,
+		Tests: true,
+	}
+	if len(tags) > 0 {
+		cfg.BuildFlags = []string{"-tags=" + strings.Join(tags, ",")}
+	}
+
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load packages: %w", err)
+	}
+
+	// go/packages reports up to four variants per directory: the package,
+	// the package recompiled with its _test.go files, the external _test
+	// package and the generated test main. Fold them back together.
+	byPath := make(map[string]*PackageHash)
+	tests := make(map[string][]string)
+	files := make(map[string]map[string]bool)
+	for _, p := range pkgs {
+		if strings.HasSuffix(p.ID, ".test") || p.Types == nil {
+			continue // generated test main
+		}
+		basePath := strings.TrimSuffix(p.PkgPath, "_test")
+		isTestVariant := strings.Contains(p.ID, " [")
+		if files[basePath] == nil {
+			files[basePath] = make(map[string]bool)
+		}
+		// GoFiles adds the sources of cgo files, which are compiled from
+		// generated files in the build cache.
+		for _, f := range slices.Concat(p.GoFiles, p.CompiledGoFiles) {
+			if strings.HasSuffix(f, ".go") {
+				files[basePath][f] = true
+			}
+		}
+
+		if !isTestVariant {
+			dir := ""
+			if len(p.GoFiles) > 0 {
+				if rel, err := filepath.Rel(root, filepath.Dir(p.GoFiles[0])); err == nil {
+					dir = filepath.ToSlash(rel)
+				}
+			}
+			byPath[basePath] = &PackageHash{
+				ImportPath: basePath,
+				Dir:        dir,
+				APIHash:    hashAPI(p.Types),
+				ImplHash:   hashDecls(p.Fset, p.Syntax, p.TypesInfo, nil),
+			}
+			continue
+		}
+
+		onlyTests := func(name string) bool { return strings.HasSuffix(name, "_test.go") }
+		tests[basePath] = append(tests[basePath], hashDecls(p.Fset, p.Syntax, p.TypesInfo, onlyTests))
+	}
+
+	var result []PackageHash
+	for path, ph := range byPath {
+		if digests := tests[path]; len(digests) > 0 {
+			sort.Strings(digests)
+			ph.TestHash = sumStrings(digests)
+		}
+		for f := range files[path] {
+			if rel, err := filepath.Rel(root, filepath.Dir(f)); err == nil && filepath.ToSlash(rel) == ph.Dir {
+				ph.Files = append(ph.Files, filepath.Base(f))
+			}
+		}
+		sort.Strings(ph.Files)
+		result = append(result, *ph)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ImportPath < result[j].ImportPath })
+	return result, nil
+}
+
+// hashDecls hashes the top-level declarations of a package as a sorted set,
+// so moving a declaration between files (or reordering it) is invisible.
+// Import aliases are resolved to import paths for the same reason.
+func hashDecls(fset *token.FileSet, files []*ast.File, info *types.Info, keep func(string) bool) string {
+	var digests []string
+	for _, f := range files {
+		if keep != nil && !keep(fset.Position(f.Pos()).Filename) {
+			continue
+		}
+		for _, decl := range f.Decls {
+			if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+				continue
+			}
+			n := &normalizer{mode: ModeAST, info: info, qualifyImports: true}
+			ast.Inspect(decl, n.visit)
+			digests = append(digests, sumStrings([]string{n.sb.String()}))
+		}
+	}
+	sort.Strings(digests)
+	return sumStrings(digests)
+}
+
+// hashAPI hashes the exported objects of a package: their names, types and,
+// for named types, exported fields and methods.
+func hashAPI(pkg *types.Package) string {
+	qualifier := func(p *types.Package) string { return p.Path() }
+	scope := pkg.Scope()
+
+	var entries []string
+	for _, name := range scope.Names() { // Names() is sorted
+		obj := scope.Lookup(name)
+		if !obj.Exported() {
+			continue
+		}
+		tn, ok := obj.(*types.TypeName)
+		if !ok {
+			entries = append(entries, types.ObjectString(obj, qualifier))
+			continue
+		}
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "type %s ", name)
+		if st, ok := tn.Type().Underlying().(*types.Struct); ok {
+			sb.WriteString("struct{")
+			for i := 0; i < st.NumFields(); i++ {
+				if f := st.Field(i); f.Exported() {
+					fmt.Fprintf(&sb, "%s %s;", f.Name(), types.TypeString(f.Type(), qualifier))
+				}
+			}
+			sb.WriteString("}")
+		} else {
+			sb.WriteString(types.TypeString(tn.Type().Underlying(), qualifier))
+		}
+		mset := types.NewMethodSet(types.NewPointer(tn.Type()))
+		for i := 0; i < mset.Len(); i++ {
+			if m := mset.At(i).Obj(); m.Exported() {
+				fmt.Fprintf(&sb, ";method %s%s", m.Name(), types.TypeString(m.Type(), qualifier))
+			}
+		}
+		entries = append(entries, sb.String())
+	}
+	return sumStrings(entries)
+}
+
+func sumStrings(parts []string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
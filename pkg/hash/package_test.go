@@ -0,0 +1,85 @@
+package hash
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files["go.mod"] = "module example.com/demo\n\ngo 1.21\n"
+	for name, src := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func loadOne(t *testing.T, dir string) PackageHash {
+	t.Helper()
+	pkgs, err := ComputePackageHashes(dir, nil)
+	if err != nil {
+		t.Fatalf("ComputePackageHashes failed: %v", err)
+	}
+	if len(pkgs) != 1 {
+		t.Fatalf("expected 1 package, got %d", len(pkgs))
+	}
+	return pkgs[0]
+}
+
+func TestPackageHashLayoutIndependent(t *testing.T) {
+	base := loadOne(t, writeModule(t, map[string]string{
+		"a.go":      "package demo\n\nimport \"strings\"\n\nfunc Up(s string) string { return strings.ToUpper(s) }\n\nfunc Down(s string) string { return strings.ToLower(s) }\n",
+		"a_test.go": "package demo\n\nimport \"testing\"\n\nfunc TestUp(t *testing.T) { _ = Up(\"x\") }\n",
+	}))
+
+	moved := loadOne(t, writeModule(t, map[string]string{
+		"a.go":      "package demo\n\nimport \"strings\"\n\nfunc Up(s string) string { return strings.ToUpper(s) }\n",
+		"b.go":      "package demo\n\nimport str \"strings\"\n\n// Down lowers s.\nfunc Down(s string) string { return str.ToLower(s) }\n",
+		"a_test.go": "package demo\n\nimport \"testing\"\n\nfunc TestUp(t *testing.T) { _ = Up(\"x\") }\n",
+	}))
+	if base.ImportPath != "example.com/demo" || base.Dir != "." {
+		t.Errorf("unexpected package identity: %+v", base)
+	}
+	if base.ImplHash != moved.ImplHash || base.APIHash != moved.APIHash || base.TestHash != moved.TestHash {
+		t.Errorf("moving a function between files changed the package hash")
+	}
+
+	changed := loadOne(t, writeModule(t, map[string]string{
+		"a.go":      "package demo\n\nimport \"strings\"\n\nfunc Up(s string) string { return strings.ToUpper(s) }\n\nfunc Down(s string) string { return strings.ToTitle(s) }\n",
+		"a_test.go": "package demo\n\nimport \"testing\"\n\nfunc TestUp(t *testing.T) { _ = Up(\"y\") }\n",
+	}))
+	if base.ImplHash == changed.ImplHash {
+		t.Errorf("implementation change not detected")
+	}
+	if base.APIHash != changed.APIHash {
+		t.Errorf("API hash changed although the exported surface did not")
+	}
+	if base.TestHash == changed.TestHash {
+		t.Errorf("test change not detected")
+	}
+}
+
+func TestPackageHashFiles(t *testing.T) {
+	p := loadOne(t, writeModule(t, map[string]string{
+		"a.go":            "package demo\n\nfunc Up() {}\n",
+		"a_test.go":       "package demo\n\nimport \"testing\"\n\nfunc TestUp(t *testing.T) { Up() }\n",
+		"z_plan9.go":      "package demo\n\nfunc Evil() {}\n",
+		"ignored.go":      "//go:build ignore\n\npackage demo\n\nfunc Evil() {}\n",
+		"x_test.go":       "package demo_test\n\nimport \"testing\"\n\nfunc TestX(t *testing.T) {}\n",
+		"doc.go":          "// Package demo is a test.\npackage demo\n",
+		"z_plan9_test.go": "package demo\n",
+	}))
+	want := []string{"a.go", "a_test.go", "doc.go", "x_test.go"}
+	if !reflect.DeepEqual(p.Files, want) {
+		t.Errorf("files = %v, want %v", p.Files, want)
+	}
+}
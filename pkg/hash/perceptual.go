@@ -0,0 +1,191 @@
+package hash
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif" // register decoders for image.Decode
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultPerceptualThreshold is the maximum Hamming distance (out of 64 bits)
+// at which two perceptual hashes are considered visually identical. It
+// tolerates re-encoding, recompression and resizing, but not edits.
+const DefaultPerceptualThreshold = 10
+
+// PerceptualHash holds 64-bit perceptual fingerprints of an image, hex encoded.
+type PerceptualHash struct {
+	PHash string // DCT based: robust to scaling, compression and gamma changes
+	DHash string // Gradient based: cheap and robust to resizing
+}
+
+// IsImage reports whether the file has an image format we can fingerprint.
+func IsImage(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png", ".jpg", ".jpeg", ".gif":
+		return true
+	}
+	return false
+}
+
+// ComputePerceptualHash decodes an image and computes its pHash and dHash.
+// Unlike RawHash, both survive re-encoding or resizing by a platform.
+func ComputePerceptualHash(path string) (*PerceptualHash, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	return &PerceptualHash{
+		PHash: formatBits(pHash(img)),
+		DHash: formatBits(dHash(img)),
+	}, nil
+}
+
+// HammingDistance returns the number of differing bits between two hex
+// encoded 64-bit perceptual hashes.
+func HammingDistance(a, b string) (int, error) {
+	x, err := strconv.ParseUint(a, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid perceptual hash %q: %w", a, err)
+	}
+	y, err := strconv.ParseUint(b, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid perceptual hash %q: %w", b, err)
+	}
+	return bits.OnesCount64(x ^ y), nil
+}
+
+func formatBits(v uint64) string {
+	return fmt.Sprintf("%016x", v)
+}
+
+// pHash: shrink to 32x32 grayscale, take the 2D DCT, keep the 8x8 lowest
+// frequencies and set one bit per coefficient above their median.
+func pHash(img image.Image) uint64 {
+	const size, keep = 32, 8
+	gray := downscale(img, size, size)
+
+	dct := dct2D(gray, size)
+	coeffs := make([]float64, 0, keep*keep)
+	for y := 0; y < keep; y++ {
+		for x := 0; x < keep; x++ {
+			coeffs = append(coeffs, dct[y*size+x])
+		}
+	}
+
+	// The DC term only encodes average brightness; leave it out of the median.
+	sorted := append([]float64(nil), coeffs[1:]...)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var h uint64
+	for i, c := range coeffs {
+		if c > median {
+			h |= 1 << uint(i)
+		}
+	}
+	return h
+}
+
+// dHash: shrink to 9x8 grayscale and set one bit per horizontally adjacent
+// pair whose brightness increases.
+func dHash(img image.Image) uint64 {
+	gray := downscale(img, 9, 8)
+
+	var h uint64
+	bit := 0
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if gray[y*9+x] < gray[y*9+x+1] {
+				h |= 1 << uint(bit)
+			}
+			bit++
+		}
+	}
+	return h
+}
+
+// downscale converts img to grayscale luminance and box-filters it to w×h.
+// Images smaller than the target are sampled nearest-neighbour instead.
+func downscale(img image.Image, w, h int) []float64 {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	out := make([]float64, w*h)
+	if sw == 0 || sh == 0 {
+		return out
+	}
+
+	counts := make([]int, w*h)
+	for y := 0; y < sh; y++ {
+		cy := y * h / sh
+		for x := 0; x < sw; x++ {
+			cx := x * w / sw
+			out[cy*w+cx] += luminance(img, b.Min.X+x, b.Min.Y+y)
+			counts[cy*w+cx]++
+		}
+	}
+
+	for i := range out {
+		if counts[i] > 0 {
+			out[i] /= float64(counts[i])
+			continue
+		}
+		// Upscaling: no source pixel fell into this cell.
+		x := (i%w)*sw/w + b.Min.X
+		y := (i/w)*sh/h + b.Min.Y
+		out[i] = luminance(img, x, y)
+	}
+	return out
+}
+
+func luminance(img image.Image, x, y int) float64 {
+	r, g, b, _ := img.At(x, y).RGBA()
+	return (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 257
+}
+
+// dct2D computes the (unnormalized) 2D DCT-II of an n×n matrix.
+func dct2D(in []float64, n int) []float64 {
+	cos := make([]float64, n*n)
+	for k := 0; k < n; k++ {
+		for i := 0; i < n; i++ {
+			cos[k*n+i] = math.Cos(math.Pi / float64(n) * (float64(i) + 0.5) * float64(k))
+		}
+	}
+
+	// Rows, then columns.
+	tmp := make([]float64, n*n)
+	for y := 0; y < n; y++ {
+		for k := 0; k < n; k++ {
+			var sum float64
+			for i := 0; i < n; i++ {
+				sum += in[y*n+i] * cos[k*n+i]
+			}
+			tmp[y*n+k] = sum
+		}
+	}
+	out := make([]float64, n*n)
+	for x := 0; x < n; x++ {
+		for k := 0; k < n; k++ {
+			var sum float64
+			for i := 0; i < n; i++ {
+				sum += tmp[i*n+x] * cos[k*n+i]
+			}
+			out[k*n+x] = sum
+		}
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+package hash
+
+import (
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testImage draws a few shapes whose layout is controlled by variant.
+func testImage(w, h int, variant int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			fx, fy := float64(x)/float64(w), float64(y)/float64(h)
+			c := color.RGBA{uint8(255 * fx), uint8(255 * fy), 128, 255}
+			if variant == 0 && fx > 0.2 && fx < 0.5 && fy > 0.3 && fy < 0.8 {
+				c = color.RGBA{250, 250, 250, 255}
+			}
+			if variant == 1 && (fx-0.7)*(fx-0.7)+(fy-0.3)*(fy-0.3) < 0.04 {
+				c = color.RGBA{10, 10, 10, 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func saveImage(t *testing.T, path string, img image.Image) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if filepath.Ext(path) == ".png" {
+		err = png.Encode(f, img)
+	} else {
+		err = jpeg.Encode(f, img, &jpeg.Options{Quality: 60})
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func distance(t *testing.T, a, b string) (int, int) {
+	t.Helper()
+	ha, err := ComputePerceptualHash(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hb, err := ComputePerceptualHash(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dp, err := HammingDistance(ha.PHash, hb.PHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dd, err := HammingDistance(ha.DHash, hb.DHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dp, dd
+}
+
+func TestPerceptualHash(t *testing.T) {
+	dir := t.TempDir()
+	orig := filepath.Join(dir, "art.png")
+	reencoded := filepath.Join(dir, "art.jpg")
+	resized := filepath.Join(dir, "art_small.png")
+	other := filepath.Join(dir, "other.png")
+
+	saveImage(t, orig, testImage(400, 300, 0))
+	saveImage(t, reencoded, testImage(400, 300, 0))
+	saveImage(t, resized, testImage(200, 150, 0))
+	saveImage(t, other, testImage(400, 300, 1))
+
+	for _, p := range []string{reencoded, resized} {
+		dp, dd := distance(t, orig, p)
+		if dp > DefaultPerceptualThreshold || dd > DefaultPerceptualThreshold {
+			t.Errorf("%s: distance pHash=%d dHash=%d, want <= %d", filepath.Base(p), dp, dd, DefaultPerceptualThreshold)
+		}
+	}
+
+	dp, dd := distance(t, orig, other)
+	if dp <= DefaultPerceptualThreshold && dd <= DefaultPerceptualThreshold {
+		t.Errorf("different images considered identical: pHash=%d dHash=%d", dp, dd)
+	}
+}
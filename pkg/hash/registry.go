@@ -0,0 +1,84 @@
+package hash
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/windgeek/HCP/pkg/lexer"
+)
+
+// LogicHasher computes a formatting-invariant "logic" hash for one kind of
+// file (RFC-004 Fuzzy Verification). Two files with the same logic hash are
+// considered equivalent even if their raw bytes differ.
+type LogicHasher interface {
+	// Name identifies the hasher in manifests (e.g. "go-ast", "json").
+	Name() string
+	// Version must be bumped whenever the normalization changes, so that
+	// hashes produced by different versions are never compared.
+	Version() int
+	// Hash returns the logic hash of the file at path.
+	Hash(path string) (string, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]LogicHasher)
+)
+
+// RegisterHasher makes h the LogicHasher for files with the given extension
+// (e.g. ".json"), replacing any previous registration. Extensions are
+// matched case-insensitively.
+func RegisterHasher(ext string, h LogicHasher) {
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[strings.ToLower(ext)] = h
+}
+
+// LookupHasher returns the LogicHasher registered for the extension of path.
+func LookupHasher(path string) (LogicHasher, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	h, ok := registry[strings.ToLower(filepath.Ext(path))]
+	return h, ok
+}
+
+// HasherID formats a hasher name and version as recorded in manifests.
+func HasherID(h LogicHasher) string {
+	return fmt.Sprintf("%s@%d", h.Name(), h.Version())
+}
+
+// goHasher wraps ComputeLogicHash.
+type goHasher struct{}
+
+func (goHasher) Name() string                     { return "go-ast" }
+func (goHasher) Version() int                     { return 1 }
+func (goHasher) Hash(path string) (string, error) { return ComputeLogicHash(path) }
+
+func init() {
+	RegisterHasher(".go", goHasher{})
+
+	RegisterHasher(".json", jsonHasher{})
+	for _, ext := range []string{".yaml", ".yml"} {
+		RegisterHasher(ext, yamlHasher{})
+	}
+	RegisterHasher(".toml", tomlHasher{})
+	for _, ext := range []string{".md", ".markdown", ".txt", ".rst"} {
+		RegisterHasher(ext, proseHasher{})
+	}
+	for _, ext := range []string{
+		".c", ".h", ".cc", ".cpp", ".cxx", ".hpp", ".hh",
+		".java", ".cs", ".swift", ".kt", ".scala", ".dart",
+	} {
+		RegisterHasher(ext, newCFamilyHasher(lexer.C))
+	}
+	for _, ext := range []string{".js", ".mjs", ".jsx", ".ts", ".tsx"} {
+		RegisterHasher(ext, newCFamilyHasher(lexer.JavaScript))
+	}
+	RegisterHasher(".php", newCFamilyHasher(lexer.PHP))
+	RegisterHasher(".rs", newCFamilyHasher(lexer.Rust))
+}
@@ -0,0 +1,67 @@
+package hash
+
+import (
+	"testing"
+)
+
+func TestBuiltinHashers(t *testing.T) {
+	cases := []struct {
+		ext    string
+		hasher string
+		a, b   string
+		same   bool
+	}{
+		{".json", "json", `{"b": [1, 2], "a": {"x": "y"}}`, "{\n  \"a\": {\"x\": \"y\"},\n  \"b\": [1,2]\n}\n", true},
+		{".json", "json", `{"a": 1}`, `{"a": "1"}`, false},
+		{".yaml", "yaml", "# config\nb: 2\na: [1, 2]\n", "a:\n  - 1\n  - 2\nb: 2\n", true},
+		{".yml", "yaml", "a: 1\n", "a: 2\n", false},
+		{".toml", "toml", "title = \"x\" # name\n[server]\nport=80\nhosts = [\"a\",\n  \"b\"]\n", "title=\"x\"\n\n[server]\nhosts = [ \"a\", \"b\" ]\nport = 80\n", true},
+		{".toml", "toml", "[a]\nk = 1\n[b]\nk = 2\n", "[a]\nk = 2\n[b]\nk = 1\n", false},
+		{".md", "prose", "# Title\n\nA long line that was\nwrapped by an editor.\n", "# Title\n\nA long line  that was wrapped\nby an editor.   \n", true},
+		{".md", "prose", "One paragraph.\n\nTwo.\n", "One paragraph. Two.\n", false},
+		{".c", "c-family", "/* header */\n#include <stdio.h>\nint main(){ // entry\n  return 0;\n}\n", "#include <stdio.h>\n\nint main()\n{\n\treturn 0; /* done */\n}\n", true},
+		{".js", "c-family", "const s = \"a // b\";", "const s = \"a\";", false},
+		{".rs", "c-family", "fn f<'a>(x: &'a str) -> &'a str {\n    x // same\n}\n", "fn f<'a>(x:&'a str)->&'a str { x }\n", true},
+		{".rs", "c-family", "let c = 'a';", "let c = 'b';", false},
+	}
+
+	dir := t.TempDir()
+	for _, tc := range cases {
+		h, ok := LookupHasher("file" + tc.ext)
+		if !ok {
+			t.Fatalf("no hasher registered for %s", tc.ext)
+		}
+		if h.Name() != tc.hasher {
+			t.Errorf("%s: got hasher %s, want %s", tc.ext, h.Name(), tc.hasher)
+		}
+
+		ha, err := h.Hash(writeSrc(t, dir, "a"+tc.ext, tc.a))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.ext, err)
+		}
+		hb, err := h.Hash(writeSrc(t, dir, "b"+tc.ext, tc.b))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.ext, err)
+		}
+		if (ha == hb) != tc.same {
+			t.Errorf("%s: %q vs %q: equal = %v, want %v", tc.ext, tc.a, tc.b, ha == hb, tc.same)
+		}
+	}
+}
+
+type fixedHasher struct{}
+
+func (fixedHasher) Name() string                     { return "custom" }
+func (fixedHasher) Version() int                     { return 3 }
+func (fixedHasher) Hash(path string) (string, error) { return "fixed", nil }
+
+func TestRegisterHasher(t *testing.T) {
+	RegisterHasher("custom-ext", fixedHasher{})
+	h, ok := LookupHasher("dir/file.CUSTOM-EXT")
+	if !ok {
+		t.Fatal("registered hasher not found")
+	}
+	if id := HasherID(h); id != "custom@3" {
+		t.Errorf("unexpected hasher id %q", id)
+	}
+}
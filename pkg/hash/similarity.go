@@ -0,0 +1,227 @@
+package hash
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultSimilarityThreshold is the minimum similarity score (0-100) at
+// which a text counts as "content substantially preserved".
+const DefaultSimilarityThreshold = 90
+
+// The similarity digest is a context-triggered piecewise hash in the style
+// of ssdeep: a rolling hash over a small window splits the text into chunks
+// at content-defined boundaries and every chunk contributes one character.
+// An edit therefore only changes the characters of the chunks it touches,
+// and the edit distance between two digests measures how much changed.
+const (
+	rollingWindow  = 7
+	digestLength   = 64
+	minBlockSize   = 3
+	chunkHashInit  = 0x28021967
+	chunkHashPrime = 0x01000193
+	digestAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+)
+
+// ComputeSimilarityDigest returns the similarity digest of a text file.
+// Binary files are rejected.
+func ComputeSimilarityDigest(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if !IsText(data) {
+		return "", fmt.Errorf("not a text file: %s", path)
+	}
+	return SimilarityDigest(string(data)), nil
+}
+
+// IsText reports whether data looks like text: valid UTF-8 without NUL bytes
+// in its first 8 KiB.
+func IsText(data []byte) bool {
+	head := data
+	if len(head) > 8192 {
+		head = head[:8192]
+		// Do not reject a multi-byte rune split by the cut.
+		for i := 0; i < utf8.UTFMax && !utf8.Valid(head); i++ {
+			head = head[:len(head)-1]
+		}
+	}
+	return bytes.IndexByte(head, 0) < 0 && utf8.Valid(head)
+}
+
+// SimilarityDigest computes the digest of a text, formatted as
+// "blocksize:digest:digest2x". Whitespace is collapsed first, so re-wrapping
+// or re-indenting a text does not change its digest.
+func SimilarityDigest(text string) string {
+	data := []byte(strings.Join(strings.Fields(text), " "))
+
+	blockSize := minBlockSize
+	for blockSize*digestLength < len(data) {
+		blockSize *= 2
+	}
+	for {
+		d1, d2 := piecewiseHash(data, blockSize)
+		// Too few boundaries at this block size: retry with a smaller one.
+		if len(d1) < digestLength/2 && blockSize > minBlockSize {
+			blockSize /= 2
+			continue
+		}
+		return fmt.Sprintf("%d:%s:%s", blockSize, d1, d2)
+	}
+}
+
+// piecewiseHash produces the digests for blockSize and 2*blockSize.
+func piecewiseHash(data []byte, blockSize int) (string, string) {
+	var roll rollingHash
+	var d1, d2 []byte
+	h1, h2 := uint32(chunkHashInit), uint32(chunkHashInit)
+	dirty := false
+
+	for _, c := range data {
+		h1 = h1*chunkHashPrime ^ uint32(c)
+		h2 = h2*chunkHashPrime ^ uint32(c)
+		dirty = true
+		r := roll.update(c)
+
+		if r%uint32(blockSize) == uint32(blockSize-1) && len(d1) < digestLength-1 {
+			d1 = append(d1, digestAlphabet[h1%64])
+			h1 = chunkHashInit
+			dirty = false
+		}
+		if r%uint32(2*blockSize) == uint32(2*blockSize-1) && len(d2) < digestLength/2-1 {
+			d2 = append(d2, digestAlphabet[h2%64])
+			h2 = chunkHashInit
+		}
+	}
+	if dirty || len(d1) == 0 {
+		d1 = append(d1, digestAlphabet[h1%64])
+	}
+	d2 = append(d2, digestAlphabet[h2%64])
+	return string(d1), string(d2)
+}
+
+// rollingHash is the Adler-32 style rolling hash used by spamsum/ssdeep.
+type rollingHash struct {
+	window     [rollingWindow]byte
+	h1, h2, h3 uint32
+	n          int
+}
+
+func (r *rollingHash) update(c byte) uint32 {
+	r.h2 -= r.h1
+	r.h2 += rollingWindow * uint32(c)
+	r.h1 += uint32(c)
+	r.h1 -= uint32(r.window[r.n%rollingWindow])
+	r.window[r.n%rollingWindow] = c
+	r.n++
+	r.h3 = r.h3<<5 ^ uint32(c)
+	return r.h1 + r.h2 + r.h3
+}
+
+// Similarity scores two similarity digests from 0 (unrelated) to 100
+// (identical). Digests with incompatible block sizes, i.e. texts of very
+// different length, score 0.
+func Similarity(a, b string) (int, error) {
+	ba, a1, a2, err := parseDigest(a)
+	if err != nil {
+		return 0, err
+	}
+	bb, b1, b2, err := parseDigest(b)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case ba == bb:
+		return max(compareDigests(a1, b1), compareDigests(a2, b2)), nil
+	case ba == 2*bb:
+		return compareDigests(a1, b2), nil
+	case bb == 2*ba:
+		return compareDigests(a2, b1), nil
+	}
+	return 0, nil
+}
+
+func parseDigest(d string) (int, string, string, error) {
+	parts := strings.SplitN(d, ":", 3)
+	if len(parts) != 3 {
+		return 0, "", "", fmt.Errorf("invalid similarity digest %q", d)
+	}
+	bs, err := strconv.Atoi(parts[0])
+	if err != nil || bs < minBlockSize {
+		return 0, "", "", fmt.Errorf("invalid similarity digest block size %q", parts[0])
+	}
+	return bs, parts[1], parts[2], nil
+}
+
+func compareDigests(a, b string) int {
+	if a == b {
+		return 100
+	}
+	a, b = collapseRuns(a), collapseRuns(b)
+
+	// Unrelated digests virtually never share a run of rollingWindow
+	// characters; requiring one keeps random edit distances from scoring.
+	if len(a) >= rollingWindow && len(b) >= rollingWindow && !shareSubstring(a, b, rollingWindow) {
+		return 0
+	}
+
+	longest := max(len(a), len(b))
+	if longest == 0 {
+		return 100
+	}
+	score := 100 - 100*editDistance(a, b)/longest
+	return max(score, 0)
+}
+
+// collapseRuns shortens runs of more than three identical characters, which
+// carry little information (long stretches of repeated content).
+func collapseRuns(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if i >= 3 && s[i] == s[i-1] && s[i] == s[i-2] && s[i] == s[i-3] {
+			continue
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+func shareSubstring(a, b string, n int) bool {
+	seen := make(map[string]bool)
+	for i := 0; i+n <= len(a); i++ {
+		seen[a[i:i+n]] = true
+	}
+	for i := 0; i+n <= len(b); i++ {
+		if seen[b[i:i+n]] {
+			return true
+		}
+	}
+	return false
+}
+
+// editDistance is the Levenshtein distance between two short strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,41 @@
+package hash
+
+import (
+	"strings"
+	"testing"
+)
+
+const chapter = `It was late in the evening when the letter finally arrived. Mara turned it over
+twice before opening it, as if the weight of the envelope could tell her what was inside.
+The handwriting was her brother's, cramped and hurried, the way he wrote when he was afraid.
+She read it standing by the window while the rain traced slow lines down the glass, and when
+she had finished she folded it carefully and placed it in the drawer with the others.
+Nobody in the village knew that he had been writing to her all these years, and she meant to
+keep it that way for as long as the river kept its course and the old bridge still stood.`
+
+func TestSimilarity(t *testing.T) {
+	base := SimilarityDigest(chapter)
+
+	typo := SimilarityDigest(strings.Replace(chapter, "carefully", "carefuly", 1))
+	rewrapped := SimilarityDigest(strings.ReplaceAll(chapter, "\n", " "))
+	other := SimilarityDigest(alphaBase + "\nThe quarterly report shows revenue grew in every region except the north.")
+
+	if score, _ := Similarity(base, rewrapped); score != 100 {
+		t.Errorf("re-wrapped text scored %d, want 100", score)
+	}
+	if score, _ := Similarity(base, typo); score < DefaultSimilarityThreshold {
+		t.Errorf("one typo scored %d, want >= %d", score, DefaultSimilarityThreshold)
+	}
+	if score, _ := Similarity(base, other); score >= DefaultSimilarityThreshold {
+		t.Errorf("unrelated text scored %d, want < %d", score, DefaultSimilarityThreshold)
+	}
+}
+
+func TestIsText(t *testing.T) {
+	if !IsText([]byte("héllo\n")) {
+		t.Error("utf-8 text rejected")
+	}
+	if IsText([]byte{0x89, 'P', 'N', 'G', 0, 0}) {
+		t.Error("binary accepted")
+	}
+}
@@ -0,0 +1,309 @@
+package hash
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// jsonHasher hashes the decoded JSON value with canonical key ordering,
+// so whitespace, indentation and object key order are ignored.
+type jsonHasher struct{}
+
+func (jsonHasher) Name() string { return "json" }
+func (jsonHasher) Version() int { return 1 }
+
+func (jsonHasher) Hash(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber() // keep numbers exactly as written, no float rounding
+	var sb strings.Builder
+	for {
+		var v interface{}
+		if err := dec.Decode(&v); err == io.EOF {
+			break
+		} else if err != nil {
+			return "", fmt.Errorf("invalid json: %w", err)
+		}
+		writeCanonical(&sb, v)
+		sb.WriteString(";")
+	}
+	return sumString(sb.String()), nil
+}
+
+// yamlHasher hashes every YAML document as a canonical value tree, ignoring
+// comments, indentation style, quoting style and mapping key order.
+type yamlHasher struct{}
+
+func (yamlHasher) Name() string { return "yaml" }
+func (yamlHasher) Version() int { return 1 }
+
+func (yamlHasher) Hash(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	var sb strings.Builder
+	for {
+		var v interface{}
+		if err := dec.Decode(&v); err == io.EOF {
+			break
+		} else if err != nil {
+			return "", fmt.Errorf("invalid yaml: %w", err)
+		}
+		writeCanonical(&sb, v)
+		sb.WriteString(";")
+	}
+	return sumString(sb.String()), nil
+}
+
+// writeCanonical serializes a decoded JSON/YAML value deterministically.
+func writeCanonical(sb *strings.Builder, v interface{}) {
+	switch t := v.(type) {
+	case nil:
+		sb.WriteString("null")
+	case string:
+		sb.WriteString(strconv.Quote(t))
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		sb.WriteString("{")
+		for _, k := range keys {
+			fmt.Fprintf(sb, "%s:", strconv.Quote(k))
+			writeCanonical(sb, t[k])
+			sb.WriteString(",")
+		}
+		sb.WriteString("}")
+	case map[interface{}]interface{}:
+		// YAML allows non-string keys; order them by their rendered form.
+		keys := make([]string, 0, len(t))
+		rendered := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			var kb strings.Builder
+			writeCanonical(&kb, k)
+			keys = append(keys, kb.String())
+			rendered[kb.String()] = val
+		}
+		sort.Strings(keys)
+		sb.WriteString("{")
+		for _, k := range keys {
+			fmt.Fprintf(sb, "%s:", k)
+			writeCanonical(sb, rendered[k])
+			sb.WriteString(",")
+		}
+		sb.WriteString("}")
+	case []interface{}:
+		sb.WriteString("[")
+		for _, e := range t {
+			writeCanonical(sb, e)
+			sb.WriteString(",")
+		}
+		sb.WriteString("]")
+	default:
+		fmt.Fprintf(sb, "%v", t)
+	}
+}
+
+// tomlHasher canonicalizes TOML without a full parser: every key/value pair
+// is qualified with its table, whitespace outside strings and comments are
+// dropped, and the pairs are sorted. Multi-line values are joined first, so
+// line breaks inside multi-line strings are not significant.
+type tomlHasher struct{}
+
+func (tomlHasher) Name() string { return "toml" }
+func (tomlHasher) Version() int { return 1 }
+
+func (tomlHasher) Hash(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	var entries []string
+	table := ""
+	arrayTables := make(map[string]int)
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(stripTOMLComment(lines[i]))
+		if line == "" {
+			continue
+		}
+
+		// Table headers: [table] or [[array.of.tables]]
+		if strings.HasPrefix(line, "[") && !strings.Contains(line, "=") {
+			name := squeezeOutsideStrings(line)
+			if strings.HasPrefix(name, "[[") {
+				// Each array element is its own table; number them so that
+				// sorting the entries cannot mix elements up.
+				arrayTables[name]++
+				name = fmt.Sprintf("%s#%d", name, arrayTables[name])
+			}
+			table = name
+			entries = append(entries, "table:"+table)
+			continue
+		}
+
+		for !tomlBalanced(line) && i+1 < len(lines) {
+			i++
+			line += " " + strings.TrimSpace(stripTOMLComment(lines[i]))
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return "", fmt.Errorf("invalid toml line %d: %q", i+1, line)
+		}
+		entries = append(entries, fmt.Sprintf("%s:%s=%s", table, squeezeOutsideStrings(key), squeezeOutsideStrings(value)))
+	}
+
+	sort.Strings(entries)
+	return sumString(strings.Join(entries, "\n")), nil
+}
+
+// stripTOMLComment removes a trailing # comment that is not inside a string.
+func stripTOMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// tomlBalanced reports whether a value is complete: no open brackets or
+// braces, and no unterminated multi-line string.
+func tomlBalanced(s string) bool {
+	if strings.Count(s, `"""`)%2 != 0 || strings.Count(s, `'''`)%2 != 0 {
+		return false
+	}
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return depth <= 0
+}
+
+// squeezeOutsideStrings drops all whitespace that is not inside a quoted string.
+func squeezeOutsideStrings(s string) string {
+	var sb strings.Builder
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			sb.WriteByte(c)
+			if c == '\\' && quote == '"' && i+1 < len(s) {
+				i++
+				sb.WriteByte(s[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			sb.WriteByte(c)
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// proseHasher makes text and Markdown invariant to whitespace and line
+// wrapping: paragraphs (separated by blank lines) are kept, but every run of
+// whitespace inside a paragraph collapses to a single space. Fenced code
+// blocks keep their line structure, minus trailing whitespace.
+type proseHasher struct{}
+
+func (proseHasher) Name() string { return "prose" }
+func (proseHasher) Version() int { return 1 }
+
+func (proseHasher) Hash(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return sumString(strings.Join(proseParagraphs(string(data)), "\n\n")), nil
+}
+
+// proseParagraphs splits text into whitespace-normalized paragraphs.
+func proseParagraphs(text string) []string {
+	var paragraphs []string
+	var current []string
+	inFence := false
+
+	flush := func() {
+		if len(current) > 0 {
+			paragraphs = append(paragraphs, strings.Join(current, " "))
+			current = nil
+		}
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			flush()
+			inFence = !inFence
+			paragraphs = append(paragraphs, trimmed)
+			continue
+		}
+		if inFence {
+			paragraphs = append(paragraphs, strings.TrimRight(line, " \t"))
+			continue
+		}
+		if trimmed == "" {
+			flush()
+			continue
+		}
+		current = append(current, strings.Join(strings.Fields(trimmed), " "))
+	}
+	flush()
+	return paragraphs
+}
+
+func sumString(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,59 @@
+package identity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+)
+
+// CommitTrailer is the git trailer that carries an HCP signature of a
+// commit, e.g. "HCP-Signature: 3045...".
+//
+// The trailer is part of the commit message and thus of the commit hash
+// itself, so it signs the commit's CommitPayload rather than its hash.
+const CommitTrailer = "HCP-Signature"
+
+// CommitPayload is what an HCP-Signature trailer signs: every field of the
+// commit that is known before it is made, except the dates. Binding the
+// parents and author keeps a signature from being replayed on another
+// commit with the same tree.
+type CommitPayload struct {
+	Tree    string
+	Parents []string // In commit order, none for a root commit
+	Author  string   // "Name <email>" as recorded, before .mailmap
+}
+
+// SignCommit signs a commit payload and returns the hex encoded DER
+// signature.
+func SignCommit(key *btcec.PrivateKey, p CommitPayload) string {
+	digest := p.digest()
+	return hex.EncodeToString(ecdsa.Sign(key, digest[:]).Serialize())
+}
+
+// VerifyCommit reports whether sigHex is a valid SignCommit signature of p
+// made with the private key belonging to pubKey.
+func VerifyCommit(pubKey *btcec.PublicKey, p CommitPayload, sigHex string) bool {
+	sigBytes, err := hex.DecodeString(sigHex)
+	if err != nil {
+		return false
+	}
+	signature, err := ecdsa.ParseSignature(sigBytes)
+	if err != nil {
+		return false
+	}
+	digest := p.digest()
+	return signature.Verify(digest[:], pubKey)
+}
+
+func (p CommitPayload) digest() [32]byte {
+	var b strings.Builder
+	b.WriteString("hcp-commit:v1\ntree " + p.Tree + "\n")
+	for _, parent := range p.Parents {
+		b.WriteString("parent " + parent + "\n")
+	}
+	b.WriteString("author " + p.Author + "\n")
+	return sha256.Sum256([]byte(b.String()))
+}
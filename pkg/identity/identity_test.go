@@ -51,3 +51,41 @@ func TestIdentityFlow(t *testing.T) {
 		t.Fatal("LoadKey with wrong passphrase should fail")
 	}
 }
+
+func TestSignCommit(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	other, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	p := CommitPayload{
+		Tree:    "4b825dc642cb6eb9a060e54bf8d69288fbee4904",
+		Parents: []string{"1f7a2b3c4d5e6f708192a3b4c5d6e7f809102132"},
+		Author:  "Alice <alice@example.com>",
+	}
+	sig := SignCommit(key, p)
+	if !VerifyCommit(key.PubKey(), p, sig) {
+		t.Fatal("signature did not verify")
+	}
+	if VerifyCommit(other.PubKey(), p, sig) {
+		t.Fatal("signature verified with the wrong key")
+	}
+	if VerifyCommit(key.PubKey(), p, "zz") {
+		t.Fatal("malformed signature verified")
+	}
+
+	// The signature does not carry over to another commit with the same tree.
+	for name, q := range map[string]CommitPayload{
+		"tree":    {Tree: "0000000000000000000000000000000000000000", Parents: p.Parents, Author: p.Author},
+		"parents": {Tree: p.Tree, Author: p.Author},
+		"author":  {Tree: p.Tree, Parents: p.Parents, Author: "Mallory <mallory@example.com>"},
+	} {
+		if VerifyCommit(key.PubKey(), q, sig) {
+			t.Errorf("signature verified for a different %s", name)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Package lexer splits source code into tokens for the analyses that do not
+// parse it: the c-family logic hash (pkg/hash) and the complexity analyzers
+// of languages other than Go (pkg/cognitive).
+package lexer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind classifies a token.
+type Kind int
+
+const (
+	Word Kind = iota
+	Number
+	String
+	Punct
+	Newline // End of a logical line or preprocessor directive
+)
+
+// Token is a token of source text. Col is the indentation of the line it
+// starts, with tabs advancing to the next multiple of 8.
+type Token struct {
+	Text string
+	Kind Kind
+	Line int
+	Col  int
+}
+
+// Lexer splits source into words, numbers, string literals and operators,
+// dropping comments and whitespace.
+type Lexer struct {
+	LineComments   []string    // e.g. "//", "#"
+	BlockComments  [][2]string // e.g. {"/*", "*/"}
+	Quotes         string      // Characters that delimit string literals
+	TripleQuotes   bool        // Python """ and ''' strings
+	StringPrefixes bool        // Python r"", b"", f"" ...
+	Lifetimes      bool        // Rust 'a is a word, not a character literal
+	Newlines       bool        // Emit Newline outside brackets
+	Directives     bool        // "#" at the start of a line opens a directive ended by a Newline
+}
+
+// Lexers of the supported languages.
+var (
+	C          = Lexer{LineComments: []string{"//"}, BlockComments: [][2]string{{"/*", "*/"}}, Quotes: `"'`}
+	JavaScript = Lexer{LineComments: []string{"//"}, BlockComments: [][2]string{{"/*", "*/"}}, Quotes: "\"'`"}
+	PHP        = Lexer{LineComments: []string{"//", "#"}, BlockComments: [][2]string{{"/*", "*/"}}, Quotes: `"'`}
+	Rust       = Lexer{LineComments: []string{"//"}, BlockComments: [][2]string{{"/*", "*/"}}, Quotes: `"'`, Lifetimes: true}
+	Python     = Lexer{LineComments: []string{"#"}, Quotes: `"'`, TripleQuotes: true, StringPrefixes: true, Newlines: true}
+)
+
+// operators lists the multi-character operators, longest first.
+var operators = []string{
+	"...", ">>=", "<<=", "**=", "//=",
+	"&&", "||", "??", "?.", "?:", "=>", "->", "::", ":=", "==", "!=", "<=", ">=",
+	"++", "--", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "<<", ">>", "**", "//",
+}
+
+// Lex splits src into tokens.
+func (l *Lexer) Lex(src string) []Token {
+	var out []Token
+	line, col, indent := 1, 0, 0
+	lineStart := true  // Only whitespace seen since the last newline
+	depth := 0         // Bracket depth
+	directive := false // Inside a preprocessor directive
+
+	// advance moves past n bytes, keeping track of lines.
+	advance := func(i, n int) int {
+		for _, c := range src[i : i+n] {
+			if c == '\n' {
+				line++
+				col, lineStart = 0, true
+			} else {
+				col++
+			}
+		}
+		return i + n
+	}
+	emit := func(text string, kind Kind) {
+		if lineStart {
+			indent, lineStart = col, false
+		}
+		out = append(out, Token{Text: text, Kind: kind, Line: line, Col: indent})
+	}
+
+scan:
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			if directive || l.Newlines && depth == 0 && len(out) > 0 && out[len(out)-1].Kind != Newline {
+				out = append(out, Token{Text: "\n", Kind: Newline, Line: line})
+			}
+			directive = false
+			i = advance(i, 1)
+			continue
+		case c == '\t':
+			col = (col/8 + 1) * 8
+			i++
+			continue
+		case c == ' ' || c == '\r' || c == '\f' || c == '\v':
+			col++
+			i++
+			continue
+		case c == '\\' && i+1 < len(src) && src[i+1] == '\n':
+			// Line continuation: a directive goes on.
+			i++
+			line++
+			col = 0
+			i++
+			continue
+		}
+		for _, prefix := range l.LineComments {
+			if strings.HasPrefix(src[i:], prefix) {
+				end := strings.IndexByte(src[i:], '\n')
+				if end < 0 {
+					end = len(src) - i
+				}
+				i += end
+				continue scan
+			}
+		}
+		for _, bc := range l.BlockComments {
+			if strings.HasPrefix(src[i:], bc[0]) {
+				end := strings.Index(src[i+len(bc[0]):], bc[1])
+				if end < 0 {
+					i = advance(i, len(src)-i)
+				} else {
+					i = advance(i, len(bc[0])+end+len(bc[1]))
+				}
+				continue scan
+			}
+		}
+
+		switch {
+		case l.Directives && c == '#' && lineStart:
+			directive = true
+			emit("#", Punct)
+			i = advance(i, 1)
+		case l.Lifetimes && c == '\'' && i+2 < len(src) && isWordChar(rune(src[i+1])) && src[i+2] != '\'':
+			j := i + 1
+			for j < len(src) && isWordChar(rune(src[j])) {
+				j++
+			}
+			emit(src[i:j], Word)
+			i = advance(i, j-i)
+		case strings.IndexByte(l.Quotes, c) >= 0:
+			n := l.stringLen(src[i:])
+			emit(src[i:i+n], String)
+			i = advance(i, n)
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(src) && (isWordChar(rune(src[j])) || src[j] == '.') {
+				j++
+			}
+			emit(src[i:j], Number)
+			i = advance(i, j-i)
+		default:
+			r, size := utf8.DecodeRuneInString(src[i:])
+			if isWordChar(r) {
+				j := i
+				for j < len(src) {
+					r, size := utf8.DecodeRuneInString(src[j:])
+					if !isWordChar(r) {
+						break
+					}
+					j += size
+				}
+				if l.StringPrefixes && j < len(src) && strings.IndexByte(l.Quotes, src[j]) >= 0 && j-i <= 2 &&
+					strings.Trim(strings.ToLower(src[i:j]), "rbfu") == "" {
+					j += l.stringLen(src[j:])
+					emit(src[i:j], String)
+				} else {
+					emit(src[i:j], Word)
+				}
+				i = advance(i, j-i)
+				continue
+			}
+			op := src[i : i+size]
+			for _, candidate := range operators {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			switch op {
+			case "(", "[", "{":
+				depth++
+			case ")", "]", "}":
+				depth = max(depth-1, 0)
+			}
+			emit(op, Punct)
+			i = advance(i, len(op))
+		}
+	}
+	return out
+}
+
+// stringLen returns the length of the string literal at the start of s.
+// Unterminated literals run to the end of s.
+func (l *Lexer) stringLen(s string) int {
+	if l.TripleQuotes && len(s) >= 3 && (s[:3] == `"""` || s[:3] == `'''`) {
+		if end := strings.Index(s[3:], s[:3]); end >= 0 {
+			return 3 + end + 3
+		}
+		return len(s)
+	}
+	quote := s[0]
+	for j := 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case quote:
+			return j + 1
+		case '\n':
+			if quote != '`' {
+				return j // Unterminated on this line
+			}
+		}
+	}
+	return len(s)
+}
+
+func isWordChar(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,39 @@
+package lexer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		name  string
+		lexer Lexer
+		src   string
+		want  []string
+	}{
+		{"operators", C, "a >>= 1.5; // done", []string{"a", ">>=", "1.5", ";"}},
+		{"strings", JavaScript, "f(`a\n// b`, 'c')", []string{"f", "(", "`a\n// b`", ",", "'c'", ")"}},
+		{"lifetimes", Rust, "fn f<'a>(x: &'a str) { 'b' }", []string{"fn", "f", "<", "'a", ">", "(", "x", ":", "&", "'a", "str", ")", "{", "'b'", "}"}},
+		{"php comments", PHP, "# note\n$x = 1;", []string{"$x", "=", "1", ";"}},
+		{"python", Python, "s = rb'x'\nif (a and\n    b):\n", []string{"s", "=", "rb'x'", "\n", "if", "(", "a", "and", "b", ")", ":", "\n"}},
+		{"directives", Lexer{LineComments: []string{"//"}, Directives: true}, "#define A \\\n  1\nint a = A # 2;", []string{"#", "define", "A", "1", "\n", "int", "a", "=", "A", "#", "2", ";"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, tok := range tt.lexer.Lex(tt.src) {
+			got = append(got, tok.Text)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLexPositions(t *testing.T) {
+	tokens := Python.Lex("def f():\n\treturn 1\n")
+	last := tokens[len(tokens)-2]
+	if last.Text != "1" || last.Kind != Number || last.Line != 2 || last.Col != 8 {
+		t.Errorf("got %+v, want 1 at line 2, indentation 8", last)
+	}
+}
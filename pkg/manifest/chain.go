@@ -0,0 +1,112 @@
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ArchiveDir is where hcp-release keeps a copy of every manifest it writes,
+// named after the SHA-256 of the file, so that ParentHash links still
+// resolve once manifest.hcp has been overwritten.
+const ArchiveDir = ".hcp/manifests"
+
+// ErrParentNotFound means no manifest matching a ParentHash was found.
+var ErrParentNotFound = errors.New("parent manifest not found")
+
+// Load reads a manifest file.
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// FileHash returns the SHA-256 of a manifest file, the value recorded as
+// ParentHash by the next release.
+func FileHash(path string) (string, error) {
+	return calculateFileHash(path)
+}
+
+// Archive copies the manifest file at path into ArchiveDir under root and
+// returns its SHA-256, the ParentHash of the next release.
+func Archive(path, root string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum, err := calculateFileHash(path)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(root, filepath.FromSlash(ArchiveDir))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create archive: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, sum+".hcp"), data, 0644); err != nil {
+		return "", fmt.Errorf("failed to archive manifest: %w", err)
+	}
+	return sum, nil
+}
+
+// ChainLink is one release of a provenance chain.
+type ChainLink struct {
+	Hash     string // SHA-256 of the manifest file
+	Path     string
+	Manifest *Manifest
+}
+
+// Chain follows the ParentHash links from the manifest at path, newest
+// first. Parents are looked up among the .hcp files next to path and in
+// its ArchiveDir. If a parent cannot be found, the links read so far are
+// returned with an error wrapping ErrParentNotFound.
+func Chain(path string) ([]ChainLink, error) {
+	// 1. Index Candidate Manifests by Hash
+	dir := filepath.Dir(path)
+	candidates, _ := filepath.Glob(filepath.Join(dir, "*.hcp"))
+	archived, _ := filepath.Glob(filepath.Join(dir, filepath.FromSlash(ArchiveDir), "*.hcp"))
+	byHash := make(map[string]string)
+	for _, c := range append(candidates, archived...) {
+		if sum, err := calculateFileHash(c); err == nil {
+			if _, ok := byHash[sum]; !ok {
+				byHash[sum] = c
+			}
+		}
+	}
+
+	// 2. Walk the Chain
+	sum, err := calculateFileHash(path)
+	if err != nil {
+		return nil, err
+	}
+	var links []ChainLink
+	seen := make(map[string]bool)
+	for {
+		m, err := Load(path)
+		if err != nil {
+			return links, err
+		}
+		links = append(links, ChainLink{Hash: sum, Path: path, Manifest: m})
+		seen[sum] = true
+
+		sum = m.ParentHash
+		if sum == "" {
+			return links, nil
+		}
+		if seen[sum] {
+			return links, fmt.Errorf("provenance chain loops back to %s", sum)
+		}
+		next, ok := byHash[sum]
+		if !ok {
+			return links, fmt.Errorf("%w: %s", ErrParentNotFound, sum)
+		}
+		path = next
+	}
+}
@@ -0,0 +1,58 @@
+package manifest
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	dir := t.TempDir()
+	current := filepath.Join(dir, "manifest.hcp")
+
+	// 1. Two releases overwrite manifest.hcp, each archived first
+	var parent string
+	for i := int64(1); i <= 2; i++ {
+		m := &Manifest{Version: "v1-release", Timestamp: i, ParentHash: parent}
+		if err := m.Save(current); err != nil {
+			t.Fatal(err)
+		}
+		sum, err := Archive(current, dir)
+		if err != nil {
+			t.Fatalf("Archive failed: %v", err)
+		}
+		parent = sum
+	}
+
+	// 2. A third release is versioned
+	tagged := filepath.Join(dir, "manifest-v3.hcp")
+	if err := (&Manifest{Version: "v1-release", Timestamp: 3, ParentHash: parent}).Save(tagged); err != nil {
+		t.Fatal(err)
+	}
+
+	links, err := Chain(tagged)
+	if err != nil {
+		t.Fatalf("Chain failed: %v", err)
+	}
+	if len(links) != 3 {
+		t.Fatalf("got %d links, want 3", len(links))
+	}
+	for i, link := range links {
+		if want := int64(3 - i); link.Manifest.Timestamp != want {
+			t.Errorf("link %d has timestamp %d, want %d", i, link.Manifest.Timestamp, want)
+		}
+	}
+	if links[1].Hash != parent || links[0].Manifest.ParentHash != links[1].Hash || links[1].Manifest.ParentHash != links[2].Hash {
+		t.Error("links do not follow ParentHash")
+	}
+
+	// 3. A missing parent keeps the links read so far
+	orphan := filepath.Join(dir, "orphan.hcp")
+	if err := (&Manifest{Timestamp: 4, ParentHash: "00ff"}).Save(orphan); err != nil {
+		t.Fatal(err)
+	}
+	links, err = Chain(orphan)
+	if !errors.Is(err, ErrParentNotFound) || len(links) != 1 {
+		t.Errorf("got %d links and %v, want 1 and ErrParentNotFound", len(links), err)
+	}
+}
@@ -0,0 +1,186 @@
+package manifest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/windgeek/HCP/pkg/hash"
+)
+
+// Match describes how a freshly calculated asset relates to its manifest entry.
+type Match int
+
+const (
+	MatchNone    Match = iota // Content and logic changed
+	MatchExact                // Raw bytes identical
+	MatchLogic                // Reformatted, logic identical
+	MatchRenames              // Logic identical up to renamed locals/parameters
+	MatchVisual               // Image re-encoded or resized, visually identical
+	MatchSimilar              // Text edited, content substantially preserved
+)
+
+// CompareOptions tunes the tolerance of Fuzzy Verification.
+// Nil thresholds select the defaults.
+type CompareOptions struct {
+	// PerceptualThreshold is the maximum Hamming distance (0-64) between
+	// perceptual hashes for an image to count as visually identical.
+	PerceptualThreshold *int
+	// SimilarityThreshold is the minimum similarity score (0-100) for a
+	// text to count as substantially preserved.
+	SimilarityThreshold *int
+}
+
+// Validate checks that the thresholds are in range.
+func (o CompareOptions) Validate() error {
+	if t := o.PerceptualThreshold; t != nil && (*t < 0 || *t > 64) {
+		return fmt.Errorf("perceptual threshold %d out of range 0-64", *t)
+	}
+	if t := o.SimilarityThreshold; t != nil && (*t < 0 || *t > 100) {
+		return fmt.Errorf("similarity threshold %d out of range 0-100", *t)
+	}
+	return nil
+}
+
+// CompareAsset classifies the difference between a recorded asset and the
+// current state of the same file (RFC-004 Fuzzy Verification).
+func CompareAsset(recorded, current Asset, opts CompareOptions) Match {
+	if recorded.RawHash == current.RawHash {
+		return MatchExact
+	}
+
+	if recorded.PHash != "" && current.PHash != "" {
+		if VisualDistance(recorded, current) <= opts.perceptualThreshold() {
+			return MatchVisual
+		}
+		return MatchNone
+	}
+
+	// Prefer the full-AST hashes when both sides carry them; they see inside
+	// function bodies and can tell reformatting apart from renames.
+	if recorded.ASTHash != "" && current.ASTHash != "" {
+		if recorded.ASTHash == current.ASTHash {
+			return MatchLogic
+		}
+		if recorded.AlphaHash != "" && recorded.AlphaHash == current.AlphaHash {
+			return MatchRenames
+		}
+		return MatchNone
+	}
+
+	// Otherwise fall back to the extension's LogicHash, but only compare
+	// hashes produced by the same hasher and version.
+	if recorded.LogicHash != "" && recorded.LogicHash == current.LogicHash &&
+		hasherOf(recorded) == hasherOf(current) {
+		return MatchLogic
+	}
+
+	// Prose and unstructured text may drift a little (a fixed typo) and still
+	// be the same work. Code and structured data must match logically.
+	if recorded.SimDigest != "" && current.SimDigest != "" && similarityApplies(current) {
+		if score, err := hash.Similarity(recorded.SimDigest, current.SimDigest); err == nil &&
+			score >= opts.similarityThreshold() {
+			return MatchSimilar
+		}
+	}
+	return MatchNone
+}
+
+// similarityApplies reports whether an asset is prose or generic text, as
+// opposed to code or structured data with its own logic hasher.
+func similarityApplies(a Asset) bool {
+	h, ok := hash.LookupHasher(a.Path)
+	return !ok || h.Name() == "prose"
+}
+
+// hasherOf returns the hasher ID of an asset. Manifests predating the hasher
+// registry only carried Go logic hashes.
+func hasherOf(a Asset) string {
+	if a.LogicHasher == "" && strings.HasSuffix(a.Path, ".go") {
+		return "go-ast@1"
+	}
+	return a.LogicHasher
+}
+
+// PackageStatus is the package-level outcome of Fuzzy Verification.
+type PackageStatus struct {
+	ImportPath     string
+	Dir            string
+	LogicPreserved bool // Non-test implementation unchanged
+	TestsPreserved bool // _test.go files unchanged
+
+	recorded, current map[string]bool // Files of the build at release and now
+}
+
+// Covers reports whether the package verdict verifies a changed file of
+// its directory, given by name. The file must be part of the build now and,
+// unless it is new, at the release: files excluded by build constraints
+// are not in the package hashes.
+func (s PackageStatus) Covers(name string, isNew bool) bool {
+	if !s.LogicPreserved || !s.current[name] || !isNew && !s.recorded[name] {
+		return false
+	}
+	return !strings.HasSuffix(name, "_test.go") || s.TestsPreserved
+}
+
+func fileSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// ComparePackages checks every recorded package against the current tree.
+// Packages that no longer exist are reported as not preserved.
+func ComparePackages(recorded, current []hash.PackageHash) []PackageStatus {
+	byPath := make(map[string]hash.PackageHash)
+	for _, p := range current {
+		byPath[p.ImportPath] = p
+	}
+
+	var statuses []PackageStatus
+	for _, r := range recorded {
+		st := PackageStatus{ImportPath: r.ImportPath, Dir: r.Dir, recorded: fileSet(r.Files)}
+		if c, ok := byPath[r.ImportPath]; ok {
+			st.LogicPreserved = r.ImplHash == c.ImplHash
+			st.TestsPreserved = r.TestHash == c.TestHash
+			st.current = fileSet(c.Files)
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses
+}
+
+// VisualDistance returns the larger of the pHash and dHash Hamming distances
+// between two image assets, or 64 (maximally different) if they cannot be
+// compared.
+func VisualDistance(recorded, current Asset) int {
+	dist, err := hash.HammingDistance(recorded.PHash, current.PHash)
+	if err != nil {
+		return 64
+	}
+	if recorded.DHash != "" && current.DHash != "" {
+		d, err := hash.HammingDistance(recorded.DHash, current.DHash)
+		if err != nil {
+			return 64
+		}
+		if d > dist {
+			dist = d
+		}
+	}
+	return dist
+}
+
+func (o CompareOptions) similarityThreshold() int {
+	if o.SimilarityThreshold == nil {
+		return hash.DefaultSimilarityThreshold
+	}
+	return *o.SimilarityThreshold
+}
+
+func (o CompareOptions) perceptualThreshold() int {
+	if o.PerceptualThreshold == nil {
+		return hash.DefaultPerceptualThreshold
+	}
+	return *o.PerceptualThreshold
+}
@@ -0,0 +1,69 @@
+package manifest
+
+import (
+	"testing"
+
+	"github.com/windgeek/HCP/pkg/hash"
+)
+
+func TestCompareAssetThresholds(t *testing.T) {
+	// pHashes one bit apart
+	recorded := Asset{RawHash: "a", PHash: "0000000000000000"}
+	current := Asset{RawHash: "b", PHash: "0000000000000001"}
+
+	zero, one := 0, 1
+	tests := []struct {
+		name string
+		opts CompareOptions
+		want Match
+	}{
+		{"default", CompareOptions{}, MatchVisual},
+		{"zero", CompareOptions{PerceptualThreshold: &zero}, MatchNone},
+		{"one", CompareOptions{PerceptualThreshold: &one}, MatchVisual},
+	}
+	for _, tt := range tests {
+		if got := CompareAsset(recorded, current, tt.opts); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCompareOptionsValidate(t *testing.T) {
+	for _, n := range []int{0, 64} {
+		if err := (CompareOptions{PerceptualThreshold: &n}).Validate(); err != nil {
+			t.Errorf("phash %d: %v", n, err)
+		}
+	}
+	for _, n := range []int{-1, 65} {
+		if err := (CompareOptions{PerceptualThreshold: &n}).Validate(); err == nil {
+			t.Errorf("phash %d accepted", n)
+		}
+	}
+	for _, n := range []int{-1, 101} {
+		if err := (CompareOptions{SimilarityThreshold: &n}).Validate(); err == nil {
+			t.Errorf("similarity %d accepted", n)
+		}
+	}
+}
+
+func TestComparePackagesCovers(t *testing.T) {
+	recorded := []hash.PackageHash{{ImportPath: "example.com/p", Dir: "p", ImplHash: "i", TestHash: "t", Files: []string{"a.go", "a_test.go"}}}
+	current := []hash.PackageHash{{ImportPath: "example.com/p", Dir: "p", ImplHash: "i", TestHash: "t2", Files: []string{"a.go", "a_test.go", "b.go"}}}
+	st := ComparePackages(recorded, current)[0]
+	tests := []struct {
+		name  string
+		isNew bool
+		want  bool
+	}{
+		{"a.go", false, true},
+		{"b.go", true, true},          // Split off a.go
+		{"b.go", false, false},        // Not in the build at the release
+		{"z_windows.go", true, false}, // Excluded by build constraints
+		{"a_test.go", false, false},   // Tests changed
+	}
+	for _, tt := range tests {
+		if got := st.Covers(tt.name, tt.isNew); got != tt.want {
+			t.Errorf("Covers(%s, new=%v) = %v, want %v", tt.name, tt.isNew, got, tt.want)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package manifest
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -12,25 +14,43 @@ import (
 	"github.com/windgeek/HCP/pkg/aha"
 	"github.com/windgeek/HCP/pkg/cognitive"
 	"github.com/windgeek/HCP/pkg/hash"
-	"github.com/windgeek/HCP/pkg/zkp"
 )
 
 // CalculateDirHash scans a directory, ignores files, calculates global hash,
-// and generates AHA/Cognitive metrics.
+// and collects AHA metrics. The git history of root is analyzed in a single
+// pass. Cognitive proofs are generated separately by GenerateProofs.
 func CalculateDirHash(root string, ignorePatterns []string) (
-	string, 
+	string,
 	[]Asset, // Changed return type
-	map[string]aha.AHAMetrics, 
-	map[string]zkp.Proof, 
+	map[string]aha.AHAMetrics,
+	error,
+) {
+	history, err := aha.AnalyzeRepo(root, aha.Options{})
+	if errors.Is(err, aha.ErrNotRepository) {
+		// Trees outside git have no history.
+		history = nil
+	} else if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to analyze history: %w", err)
+	}
+	return CalculateDirHashWithHistory(root, ignorePatterns, history)
+}
+
+// CalculateDirHashWithHistory is CalculateDirHash with precomputed AHA
+// metrics, keyed by slash-separated path relative to root (as returned by
+// aha.AnalyzeRepo). Files missing from history get zero metrics.
+func CalculateDirHashWithHistory(root string, ignorePatterns []string, history map[string]*aha.AHAMetrics) (
+	string,
+	[]Asset,
+	map[string]aha.AHAMetrics,
 	error,
 ) {
 	var files []string
-	
+
 	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		
+
 		relPath, _ := filepath.Rel(root, path)
 		if relPath == "." {
 			return nil
@@ -42,7 +62,7 @@ func CalculateDirHash(root string, ignorePatterns []string) (
 			}
 			return nil
 		}
-		
+
 		// Additional hidden file check
 		if strings.HasPrefix(filepath.Base(path), ".") && relPath != "." {
 			if info.IsDir() {
@@ -57,7 +77,7 @@ func CalculateDirHash(root string, ignorePatterns []string) (
 		return nil
 	})
 	if err != nil {
-		return "", nil, nil, nil, err
+		return "", nil, nil, err
 	}
 
 	sort.Strings(files)
@@ -65,64 +85,79 @@ func CalculateDirHash(root string, ignorePatterns []string) (
 	globalHasher := sha256.New()
 	var assets []Asset // Changed type
 	contribMap := make(map[string]aha.AHAMetrics)
-	zkpMap := make(map[string]zkp.Proof)
-	
+
 	for _, file := range files {
 		f, err := os.Open(file)
 		if err != nil {
-			return "", nil, nil, nil, err
+			return "", nil, nil, err
 		}
-		
+
 		h := sha256.New()
 		if _, err := io.Copy(h, f); err != nil {
 			f.Close()
-			return "", nil, nil, nil, err
+			return "", nil, nil, err
 		}
 		f.Close()
-		
+
 		fileHash := hex.EncodeToString(h.Sum(nil))
 		relPath, _ := filepath.Rel(root, file)
 		cleanPath := filepath.ToSlash(relPath)
 
-		// Calculate Logic Hash for .go files
-		var logicHash string
-		if strings.HasSuffix(file, ".go") {
-			if lh, err := hash.ComputeLogicHash(file); err == nil {
+		// Calculate Logic Hash with the hasher registered for the extension
+		var logicHash, logicHasher, astHash, alphaHash string
+		if h, ok := hash.LookupHasher(file); ok {
+			if lh, err := h.Hash(file); err == nil {
 				logicHash = lh
+				logicHasher = hash.HasherID(h)
+			}
+		}
+		if strings.HasSuffix(file, ".go") {
+			if ah, err := hash.ComputeNormalizedHash(file, hash.ModeAST); err == nil {
+				astHash = ah
+			}
+			if ah, err := hash.ComputeNormalizedHash(file, hash.ModeAlpha); err == nil {
+				alphaHash = ah
+			}
+		}
+
+		// Perceptual fingerprints for images, similarity digests for text
+		var pHash, dHash, simDigest string
+		if hash.IsImage(file) {
+			if ph, err := hash.ComputePerceptualHash(file); err == nil {
+				pHash, dHash = ph.PHash, ph.DHash
 			}
+		} else if sh, err := hash.ComputeSimilarityDigest(file); err == nil {
+			simDigest = sh
 		}
 
 		asset := Asset{
-			Path:      cleanPath,
-			RawHash:   fileHash,
-			LogicHash: logicHash,
+			Path:        cleanPath,
+			RawHash:     fileHash,
+			LogicHash:   logicHash,
+			LogicHasher: logicHasher,
+			ASTHash:     astHash,
+			AlphaHash:   alphaHash,
+			PHash:       pHash,
+			DHash:       dHash,
+			SimDigest:   simDigest,
 		}
 		assets = append(assets, asset)
-		
+
 		globalHasher.Write([]byte(cleanPath))
 		globalHasher.Write([]byte(fileHash))
 		// Note: We intentionally hash only RawHash into GlobalHash to maintain strict integrity chain.
 		// LogicHash is for "Fuzzy Verification".
 
 		// Analyze AHA
-		metrics, err := aha.AnalyzeFile(file, root)
-		if err != nil {
-			// Warn mostly expected for non-git or new files
-			metrics = &aha.AHAMetrics{Commits: 0, AHAScore: 0}
+		metrics, ok := history[cleanPath]
+		if !ok {
+			// Expected for non-git or new files
+			metrics = &aha.AHAMetrics{}
 		}
 		contribMap[cleanPath] = *metrics
-
-		// Phase 4: Cognitive & ZKP
-		complexity, err := cognitive.AnalyzeComplexity(file)
-		if err == nil {
-			proof, err := zkp.GenerateProof(*metrics, complexity)
-			if err == nil {
-				zkpMap[cleanPath] = *proof
-			}
-		}
 	}
 
-	return hex.EncodeToString(globalHasher.Sum(nil)), assets, contribMap, zkpMap, nil
+	return hex.EncodeToString(globalHasher.Sum(nil)), assets, contribMap, nil
 }
 
 // ShouldIgnore checks if a file path matches any ignore pattern.
@@ -142,3 +177,35 @@ func ShouldIgnore(path string, patterns []string) bool {
 	}
 	return false
 }
+
+// CalculatePackageHashes computes layout-independent logic hashes for every
+// Go package under root, skipping packages in ignored directories.
+func CalculatePackageHashes(root string, ignorePatterns []string, tags []string) ([]hash.PackageHash, error) {
+	pkgs, err := hash.ComputePackageHashes(root, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	var kept []hash.PackageHash
+	for _, p := range pkgs {
+		if p.Dir != "." && ShouldIgnore(p.Dir, ignorePatterns) {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept, nil
+}
+
+// SummarizeComplexity measures the source files among assets, in total and
+// per package (directory). Files that cannot be read are skipped.
+func SummarizeComplexity(root string, assets []Asset) *cognitive.Report {
+	report := cognitive.NewReport()
+	for _, a := range assets {
+		stats, err := cognitive.AnalyzeComplexity(filepath.Join(root, filepath.FromSlash(a.Path)))
+		if err != nil {
+			continue
+		}
+		report.Add(a.Path, stats)
+	}
+	return report
+}
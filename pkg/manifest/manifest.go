@@ -8,40 +8,55 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
 	"github.com/windgeek/HCP/pkg/aha"
+	"github.com/windgeek/HCP/pkg/cognitive"
+	"github.com/windgeek/HCP/pkg/hash"
 	"github.com/windgeek/HCP/pkg/zkp"
 )
 
 // Asset represents a single file in the release.
 type Asset struct {
-	Path      string `json:"path"`
-	RawHash   string `json:"raw_hash"`
-	LogicHash string `json:"logic_hash,omitempty"`
+	Path        string `json:"path"`
+	RawHash     string `json:"raw_hash"`
+	LogicHash   string `json:"logic_hash,omitempty"`
+	LogicHasher string `json:"logic_hasher,omitempty"`      // Hasher that produced LogicHash ("name@version")
+	ASTHash     string `json:"ast_hash,omitempty"`          // Full AST, identifiers verbatim
+	AlphaHash   string `json:"alpha_hash,omitempty"`        // Full AST, locals alpha-normalized
+	PHash       string `json:"phash,omitempty"`             // Perceptual hash (images)
+	DHash       string `json:"dhash,omitempty"`             // Difference hash (images)
+	SimDigest   string `json:"similarity_digest,omitempty"` // ssdeep-style digest (text)
 }
 
 // Manifest represents the HCP Proof of Humanity.
 type Manifest struct {
 	Version         string                    `json:"version"`
-	Author          string                    `json:"author"`       // Author's Address
-	PublicKey       string                    `json:"public_key"`   // Hex encoded public key (added Phase 5)
-	ContentHash     string                    `json:"content_hash"` // SHA256 of the content
+	Author          string                    `json:"author"`                // Author's Address
+	PublicKey       string                    `json:"public_key"`            // Hex encoded public key (added Phase 5)
+	ContentHash     string                    `json:"content_hash"`          // SHA256 of the content
 	ParentHash      string                    `json:"parent_hash,omitempty"` // Provenance Chain (added Phase 6)
 	Timestamp       int64                     `json:"timestamp"`
 	EntropyDNA      string                    `json:"entropy_dna"`      // Random entropy for now
 	Assets          []Asset                   `json:"assets,omitempty"` // Changed to []Asset in Phase 6
 	ContributionMap map[string]aha.AHAMetrics `json:"contribution_map,omitempty"`
-	CognitiveProofs map[string]zkp.Proof      `json:"cognitive_proofs,omitempty"` // Added Phase 4
-	Signature       string                    `json:"signature"`                  // Hex encoded signature
+	AHAScoreVersion string                    `json:"aha_score_version,omitempty"` // Formula behind ContributionMap scores
+	ScoringModel    *aha.ModelSpec            `json:"scoring_model,omitempty"`     // Model and parameters to recompute the scores
+	CognitiveProofs map[string]zkp.Proof      `json:"cognitive_proofs,omitempty"`  // Added Phase 4
+	AggregateProof  *zkp.AggregateProof       `json:"aggregate_proof,omitempty"`   // Release-level proof replacing CognitiveProofs
+	Packages        []hash.PackageHash        `json:"packages,omitempty"`          // Per Go package logic hashes
+	BuildTags       []string                  `json:"build_tags,omitempty"`        // Tags used to load Packages
+	Complexity      *cognitive.Report         `json:"complexity,omitempty"`        // Complexity summary of the source files
+	Signature       string                    `json:"signature"`                   // Hex encoded signature
 }
 
 // NewManifest creates a new Manifest for a given file.
 func NewManifest(filePath string, authorAddr string, pubKey string) (*Manifest, error) {
 	// 1. Calculate Content Hash
-	hash, err := calculateFileHash(filePath)
+	contentHash, err := calculateFileHash(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to calculate hash: %w", err)
 	}
@@ -52,21 +67,61 @@ func NewManifest(filePath string, authorAddr string, pubKey string) (*Manifest,
 		return nil, fmt.Errorf("failed to generate entropy: %w", err)
 	}
 
+	timestamp, err := ReleaseTime()
+	if err != nil {
+		return nil, err
+	}
+
 	return &Manifest{
 		Version:     "v1",
 		Author:      authorAddr,
 		PublicKey:   pubKey,
-		ContentHash: hash,
-		Timestamp:   time.Now().Unix(),
+		ContentHash: contentHash,
+		Timestamp:   timestamp,
 		EntropyDNA:  hex.EncodeToString(entropy),
 	}, nil
 }
 
+// SourceDateEpoch is the environment variable that fixes the timestamps of
+// reproducible builds (https://reproducible-builds.org/specs/source-date-epoch/).
+const SourceDateEpoch = "SOURCE_DATE_EPOCH"
+
+// ReleaseTime returns the Unix time to record in a new manifest and its
+// proofs: SOURCE_DATE_EPOCH when set, so that two builds of the same commit
+// agree, and the current time otherwise.
+func ReleaseTime() (int64, error) {
+	v := os.Getenv(SourceDateEpoch)
+	if v == "" {
+		return time.Now().Unix(), nil
+	}
+	epoch, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || epoch < 0 {
+		return 0, fmt.Errorf("invalid %s %q: want a non-negative Unix time", SourceDateEpoch, v)
+	}
+	return epoch, nil
+}
+
 // Sign signs the manifest using the provided private key.
 // It signs the hash of the JSON representation (excluding the signature itself).
 func (m *Manifest) Sign(key *btcec.PrivateKey) error {
-	// 1. Serialize for signing (canonical JSON)
-	// We need a stable representation. For simplicity, we create a struct without signature.
+	// 1. Serialize and hash the signing payload
+	digest, err := m.payloadHash()
+	if err != nil {
+		return err
+	}
+
+	// 2. Sign
+	signature := ecdsa.Sign(key, digest[:])
+
+	// 3. Store signature
+	m.Signature = hex.EncodeToString(signature.Serialize())
+	return nil
+}
+
+// payloadHash returns the SHA256 of the canonical JSON representation of the
+// manifest, excluding the signature itself. Sign and Verify must agree on it.
+func (m *Manifest) payloadHash() ([32]byte, error) {
+	// We need a stable representation, so we use a struct without signature.
 	type payload struct {
 		Version         string                    `json:"version"`
 		Author          string                    `json:"author"`
@@ -77,7 +132,13 @@ func (m *Manifest) Sign(key *btcec.PrivateKey) error {
 		EntropyDNA      string                    `json:"entropy_dna"`
 		Assets          []Asset                   `json:"assets,omitempty"`
 		ContributionMap map[string]aha.AHAMetrics `json:"contribution_map,omitempty"`
+		AHAScoreVersion string                    `json:"aha_score_version,omitempty"`
+		ScoringModel    *aha.ModelSpec            `json:"scoring_model,omitempty"`
 		CognitiveProofs map[string]zkp.Proof      `json:"cognitive_proofs,omitempty"`
+		AggregateProof  *zkp.AggregateProof       `json:"aggregate_proof,omitempty"`
+		Packages        []hash.PackageHash        `json:"packages,omitempty"`
+		BuildTags       []string                  `json:"build_tags,omitempty"`
+		Complexity      *cognitive.Report         `json:"complexity,omitempty"`
 	}
 	p := payload{
 		Version:         m.Version,
@@ -89,23 +150,20 @@ func (m *Manifest) Sign(key *btcec.PrivateKey) error {
 		EntropyDNA:      m.EntropyDNA,
 		Assets:          m.Assets,
 		ContributionMap: m.ContributionMap,
+		AHAScoreVersion: m.AHAScoreVersion,
+		ScoringModel:    m.ScoringModel,
 		CognitiveProofs: m.CognitiveProofs,
+		AggregateProof:  m.AggregateProof,
+		Packages:        m.Packages,
+		BuildTags:       m.BuildTags,
+		Complexity:      m.Complexity,
 	}
 
 	data, err := json.Marshal(p)
 	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %w", err)
+		return [32]byte{}, fmt.Errorf("failed to marshal payload: %w", err)
 	}
-
-	// 2. Hash the data
-	hash := sha256.Sum256(data)
-
-	// 3. Sign
-	signature := ecdsa.Sign(key, hash[:])
-
-	// 4. Store signature
-	m.Signature = hex.EncodeToString(signature.Serialize())
-	return nil
+	return sha256.Sum256(data), nil
 }
 
 // Save saves the signed manifest to a file.
@@ -1,11 +1,19 @@
 package manifest
 
 import (
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/windgeek/HCP/pkg/aha"
 	"github.com/windgeek/HCP/pkg/identity"
+	"github.com/windgeek/HCP/pkg/zkp"
 )
 
 func TestManifestSigning(t *testing.T) {
@@ -25,7 +33,7 @@ func TestManifestSigning(t *testing.T) {
 
 	// 3. Create Manifest
 	authorAddr := "bc1qtest..." // Mock address
-	m, err := NewManifest(contentPath, authorAddr)
+	m, err := NewManifest(contentPath, authorAddr, hex.EncodeToString(key.PubKey().SerializeCompressed()))
 	if err != nil {
 		t.Fatalf("NewManifest failed: %v", err)
 	}
@@ -66,3 +74,95 @@ func TestManifestSigning(t *testing.T) {
 		t.Fatal("Loaded signature does not match")
 	}
 }
+
+func TestRecomputeScores(t *testing.T) {
+	model, _ := aha.BuiltinModel("persistence-v1")
+	spec := model.Spec()
+	m := Manifest{
+		AHAScoreVersion: model.Name(),
+		ScoringModel:    &spec,
+		ContributionMap: map[string]aha.AHAMetrics{
+			"a.md": {Commits: 2, EditingDays: 10, AHAScore: 56},
+			"b.md": {Commits: 2, EditingDays: 10, AHAScore: 80},
+		},
+	}
+	// 50 + 30 * 2/10
+	if got, _, err := m.RecomputeScores(t.TempDir()); err != nil || !reflect.DeepEqual(got, []string{"b.md"}) {
+		t.Errorf("RecomputeScores = %v, %v; want [b.md]", got, err)
+	}
+
+	// Older manifests only name the model.
+	m.ScoringModel = nil
+	if _, _, err := m.RecomputeScores(t.TempDir()); err != nil {
+		t.Errorf("by name: %v", err)
+	}
+	m.AHAScoreVersion = "aha-v1"
+	if _, _, err := m.RecomputeScores(t.TempDir()); err == nil {
+		t.Error("retired model recomputed")
+	}
+
+	// Halstead measures come from the released files, not the manifest.
+	root := t.TempDir()
+	src := "package p\n\nfunc add(a, b int) int {\n\treturn a + b\n}\n"
+	if err := os.WriteFile(filepath.Join(root, "a.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	effort, _ := aha.NewWeightedModel("effort", []aha.Feature{{Metric: "halstead_volume", Weight: 100, Cap: 100}})
+	spec = effort.Spec()
+	measured := aha.AHAMetrics{Commits: 1}
+	measured.AttachHalstead(filepath.Join(root, "a.go"))
+	m = Manifest{
+		ScoringModel:    &spec,
+		Assets:          []Asset{{Path: "a.go", RawHash: rawHash([]byte(src))}},
+		ContributionMap: map[string]aha.AHAMetrics{"a.go": {Commits: 1, AHAScore: effort.Score(&measured)}},
+	}
+	if measured.Halstead == nil {
+		t.Fatal("a.go has no Halstead measures")
+	}
+	if got, skipped, err := m.RecomputeScores(root); err != nil || len(got) != 0 || len(skipped) != 0 {
+		t.Errorf("RecomputeScores = %v, %v, %v; want none", got, skipped, err)
+	}
+
+	// Files missing or changed since the release are skipped, not failed.
+	if got, skipped, _ := m.RecomputeScores(t.TempDir()); len(got) != 0 || !reflect.DeepEqual(skipped, []string{"a.go"}) {
+		t.Errorf("without the file: %v, skipped %v; want a.go skipped", got, skipped)
+	}
+	renamed := strings.NewReplacer("a, b", "x, y", "a + b", "x + y").Replace(src)
+	if err := os.WriteFile(filepath.Join(root, "a.go"), []byte(renamed), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, skipped, _ := m.RecomputeScores(root); len(got) != 0 || !reflect.DeepEqual(skipped, []string{"a.go"}) {
+		t.Errorf("renamed: %v, skipped %v; want a.go skipped", got, skipped)
+	}
+}
+
+// TestVerifyBaselineManifest checks that manifests signed before fields were
+// added to the payload still verify: new fields must be omitted when empty.
+func TestVerifyBaselineManifest(t *testing.T) {
+	for _, path := range []string{"testdata/baseline.hcp", "testdata/baseline-1.0.hcp"} {
+		m, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyBytes, err := hex.DecodeString(m.PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub, err := btcec.ParsePubKey(keyBytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := m.Verify(pub); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+		// Its proofs come from the hash-commitment prover of the time
+		if len(m.CognitiveProofs) == 0 {
+			t.Fatalf("%s: no cognitive proofs", path)
+		}
+		for file, err := range m.VerifyProofs() {
+			if !errors.Is(err, zkp.ErrUnproven) {
+				t.Errorf("%s: proof of %s: got %v, want ErrUnproven", path, file, err)
+			}
+		}
+	}
+}
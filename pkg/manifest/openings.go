@@ -0,0 +1,111 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/windgeek/HCP/pkg/zkp"
+)
+
+// OpeningsDir is where hcp-release keeps the openings of each release's
+// cognitive proofs, relative to the user's home directory and named after
+// the SHA-256 of the manifest like ArchiveDir. Openings reveal the committed
+// values, so they never live in the released tree.
+const OpeningsDir = ".hcp/openings"
+
+// OpeningsPath returns the sidecar holding the openings of the manifest
+// with the given hash.
+func OpeningsPath(manifestHash string) (string, error) {
+	return openingsFile(manifestHash + ".json")
+}
+
+func openingsFile(name string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate home directory: %w", err)
+	}
+	return filepath.Join(home, filepath.FromSlash(OpeningsDir), name), nil
+}
+
+// writePrivate writes data readable by the user only.
+func writePrivate(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create openings directory: %w", err)
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// SaveOpenings writes the openings of proofs, by file path, to the private
+// sidecar of the manifest with the given hash.
+func SaveOpenings(manifestHash string, proofs map[string]zkp.Proof) (string, error) {
+	openings := make(map[string]*zkp.Opening)
+	for path, p := range proofs {
+		if p.Opening != nil {
+			openings[path] = p.Opening
+		}
+	}
+	data, err := json.MarshalIndent(openings, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal openings: %w", err)
+	}
+	path, err := OpeningsPath(manifestHash)
+	if err != nil {
+		return "", err
+	}
+	if err := writePrivate(path, data); err != nil {
+		return "", fmt.Errorf("failed to save openings: %w", err)
+	}
+	return path, nil
+}
+
+// AggregatePath returns the sidecar holding the opening of the aggregate
+// proof of the manifest with the given hash.
+func AggregatePath(manifestHash string) (string, error) {
+	return openingsFile(manifestHash + ".aggregate.json")
+}
+
+// SaveAggregateOpening writes the opening of an aggregate proof next to the
+// openings of the manifest with the given hash. Like them, it must stay
+// private: it holds the file proofs the aggregate hides.
+func SaveAggregateOpening(manifestHash string, opening *zkp.AggregateOpening) (string, error) {
+	data, err := json.MarshalIndent(opening, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal aggregate opening: %w", err)
+	}
+	path, err := AggregatePath(manifestHash)
+	if err != nil {
+		return "", err
+	}
+	if err := writePrivate(path, data); err != nil {
+		return "", fmt.Errorf("failed to save aggregate opening: %w", err)
+	}
+	return path, nil
+}
+
+// LoadAggregateOpening reads an aggregate opening sidecar.
+func LoadAggregateOpening(path string) (*zkp.AggregateOpening, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var opening zkp.AggregateOpening
+	if err := json.Unmarshal(data, &opening); err != nil {
+		return nil, fmt.Errorf("invalid aggregate opening %s: %w", path, err)
+	}
+	return &opening, nil
+}
+
+// LoadOpenings reads an openings sidecar.
+func LoadOpenings(path string) (map[string]*zkp.Opening, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var openings map[string]*zkp.Opening
+	if err := json.Unmarshal(data, &openings); err != nil {
+		return nil, fmt.Errorf("invalid openings %s: %w", path, err)
+	}
+	return openings, nil
+}
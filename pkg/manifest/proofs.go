@@ -0,0 +1,79 @@
+package manifest
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/windgeek/HCP/pkg/aha"
+	"github.com/windgeek/HCP/pkg/cognitive"
+	"github.com/windgeek/HCP/pkg/zkp"
+)
+
+// GenerateProofs proves the cognitive work behind every asset under root
+// with system, binding each proof to the asset's hashes and the public key
+// of the author's key. Proofs are deterministic: the same tree, key and
+// timestamp (see ReleaseTime) give the same proofs. Files that cannot be
+// analyzed or proven get no proof; their errors are returned by path.
+func GenerateProofs(root string, assets []Asset, contribMap map[string]aha.AHAMetrics, system zkp.ProofSystem, key *btcec.PrivateKey, timestamp int64) (map[string]zkp.Proof, map[string]error) {
+	publicKey := hex.EncodeToString(key.PubKey().SerializeCompressed())
+	proofs := make(map[string]zkp.Proof)
+	failed := make(map[string]error)
+	for _, a := range assets {
+		complexity, err := cognitive.AnalyzeComplexity(filepath.Join(root, filepath.FromSlash(a.Path)))
+		if err != nil {
+			failed[a.Path] = fmt.Errorf("analyzing complexity: %w", err)
+			continue
+		}
+		proof, err := zkp.GenerateProof(system, zkp.Witness{Metrics: contribMap[a.Path], Complexity: complexity}, proofBinding(a, publicKey), key, timestamp)
+		if err != nil {
+			failed[a.Path] = fmt.Errorf("proving: %w", err)
+			continue
+		}
+		proofs[a.Path] = *proof
+	}
+	return proofs, failed
+}
+
+// VerifyProofs verifies every cognitive proof, with the backend it names,
+// against the recorded asset it measures and the manifest's public key. It
+// returns the error of each failing proof by path.
+func (m *Manifest) VerifyProofs() map[string]error {
+	assets := make(map[string]Asset)
+	for _, a := range m.Assets {
+		assets[a.Path] = a
+	}
+	failed := make(map[string]error)
+	for path, p := range m.CognitiveProofs {
+		a, ok := assets[path]
+		if !ok {
+			failed[path] = errors.New("no asset with this path")
+			continue
+		}
+		if err := zkp.VerifyProof(&p, proofBinding(a, m.PublicKey)); err != nil {
+			failed[path] = err
+		}
+	}
+	return failed
+}
+
+// CheckDisclosure checks a file disclosed from the manifest's aggregate
+// proof against the recorded asset, the manifest's public key and its
+// content hash.
+func (m *Manifest) CheckDisclosure(d *zkp.FileDisclosure) error {
+	if m.AggregateProof == nil {
+		return errors.New("manifest has no aggregate proof")
+	}
+	for _, a := range m.Assets {
+		if a.Path == d.Path {
+			return d.Check(m.AggregateProof, proofBinding(a, m.PublicKey), m.ContentHash)
+		}
+	}
+	return errors.New("no asset with this path")
+}
+
+func proofBinding(a Asset, publicKey string) zkp.Binding {
+	return zkp.Binding{Path: a.Path, RawHash: a.RawHash, LogicHash: a.LogicHash, PublicKey: publicKey}
+}
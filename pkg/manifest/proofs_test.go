@@ -0,0 +1,141 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/windgeek/HCP/pkg/aha"
+	"github.com/windgeek/HCP/pkg/zkp"
+)
+
+func TestVerifyProofs(t *testing.T) {
+	dir := t.TempDir()
+	src := "package p\n\nfunc f(x int) int {\n\tif x > 0 {\n\t\treturn 1\n\t}\n\treturn 0\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "f.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, assets, _, err := CalculateDirHashWithHistory(dir, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contrib := map[string]aha.AHAMetrics{"f.go": {Commits: 1}}
+	priv, pub := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{7}, 32))
+	key := hex.EncodeToString(pub.SerializeCompressed())
+
+	proofs, failed := GenerateProofs(dir, assets, contrib, zkp.DefaultSystem, priv, 0)
+	if len(failed) != 0 {
+		t.Fatalf("GenerateProofs failed: %v", failed)
+	}
+	m := &Manifest{Assets: assets, PublicKey: key, CognitiveProofs: proofs}
+	if got := m.CognitiveProofs["f.go"].Claim(); got != zkp.ClaimHumanRatio {
+		t.Fatalf("claim = %q, want %q", got, zkp.ClaimHumanRatio)
+	}
+	if failed := m.VerifyProofs(); len(failed) != 0 {
+		t.Fatalf("VerifyProofs failed: %v", failed)
+	}
+
+	// The proofs of another author's manifest
+	m.PublicKey = "03" + key[2:]
+	if err := m.VerifyProofs()["f.go"]; !errors.Is(err, zkp.ErrBinding) {
+		t.Errorf("other author: got %v, want ErrBinding", err)
+	}
+	m.PublicKey = key
+
+	// A proof moved to other content
+	m.Assets[0].RawHash = "00" + m.Assets[0].RawHash[2:]
+	if err := m.VerifyProofs()["f.go"]; !errors.Is(err, zkp.ErrBinding) {
+		t.Errorf("other content: got %v, want ErrBinding", err)
+	}
+}
+
+func TestGenerateProofsReportsFailures(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"f.go":      "package p\n\nfunc f() int { return 1 }\n",
+		"broken.go": "package p\n\nfunc (\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, assets, _, err := CalculateDirHashWithHistory(dir, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{7}, 32))
+	proofs, failed := GenerateProofs(dir, assets, nil, zkp.DefaultSystem, priv, 0)
+	if _, ok := proofs["f.go"]; !ok || len(proofs) != 1 {
+		t.Errorf("proofs of %d files, want only f.go", len(proofs))
+	}
+	if err := failed["broken.go"]; err == nil || len(failed) != 1 {
+		t.Errorf("failed = %v, want only broken.go", failed)
+	}
+}
+
+func TestReleaseTime(t *testing.T) {
+	t.Setenv(SourceDateEpoch, "1700000000")
+	if got, err := ReleaseTime(); err != nil || got != 1700000000 {
+		t.Errorf("ReleaseTime() = %d, %v, want 1700000000", got, err)
+	}
+	for _, v := range []string{"yesterday", "-1"} {
+		t.Setenv(SourceDateEpoch, v)
+		if _, err := ReleaseTime(); err == nil {
+			t.Errorf("ReleaseTime accepted %s=%q", SourceDateEpoch, v)
+		}
+	}
+}
+
+func TestCheckDisclosure(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"f.go": "package p\n\nfunc f(x int) int {\n\tif x > 0 {\n\t\treturn 1\n\t}\n\treturn 0\n}\n",
+		"g.go": "package p\n\nfunc g() int { return 2 }\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	contentHash, assets, _, err := CalculateDirHashWithHistory(dir, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, pub := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{7}, 32))
+	key := hex.EncodeToString(pub.SerializeCompressed())
+	proofs, _ := GenerateProofs(dir, assets, nil, zkp.DefaultSystem, priv, 0)
+	aggregate, err := zkp.GenerateAggregate(proofs, 80, contentHash, priv)
+	if err != nil {
+		t.Fatalf("GenerateAggregate failed: %v", err)
+	}
+	m := &Manifest{Assets: assets, ContentHash: contentHash, PublicKey: key, AggregateProof: aggregate}
+	if err := zkp.VerifyAggregate(m.AggregateProof, m.PublicKey, m.ContentHash); err != nil {
+		t.Fatalf("VerifyAggregate failed: %v", err)
+	}
+
+	d, err := aggregate.Opening.Disclose("g.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.CheckDisclosure(d); err != nil {
+		t.Errorf("CheckDisclosure failed: %v", err)
+	}
+
+	// The file changed since the release
+	raw := m.Assets[1].RawHash
+	m.Assets[1].RawHash = "00" + raw[2:]
+	if err := m.CheckDisclosure(d); !errors.Is(err, zkp.ErrBinding) {
+		t.Errorf("other content: got %v, want ErrBinding", err)
+	}
+	m.Assets[1].RawHash = raw
+
+	// The aggregate of another release
+	m.ContentHash = strings.Repeat("0", 64)
+	if err := m.CheckDisclosure(d); !errors.Is(err, zkp.ErrBinding) {
+		t.Errorf("other release: got %v, want ErrBinding", err)
+	}
+}
@@ -3,52 +3,25 @@ package manifest
 import (
 	"crypto/sha256"
 	"encoding/hex"
-	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
 	"github.com/windgeek/HCP/pkg/aha"
-	"github.com/windgeek/HCP/pkg/zkp"
 )
 
 // Verify verifies the signature of the manifest against the provided public key.
 func (m *Manifest) Verify(pubKey *btcec.PublicKey) error {
-	// 1. Reconstruct payload (must match Sign method's payload)
-	type payload struct {
-		Version         string                    `json:"version"`
-		Author          string                    `json:"author"`
-		PublicKey       string                    `json:"public_key"`
-		ContentHash     string                    `json:"content_hash"`
-		ParentHash      string                    `json:"parent_hash,omitempty"`
-		Timestamp       int64                     `json:"timestamp"`
-		EntropyDNA      string                    `json:"entropy_dna"`
-		Assets          []Asset                   `json:"assets,omitempty"`
-		ContributionMap map[string]aha.AHAMetrics `json:"contribution_map,omitempty"`
-		CognitiveProofs map[string]zkp.Proof      `json:"cognitive_proofs,omitempty"`
-	}
-	p := payload{
-		Version:         m.Version,
-		Author:          m.Author,
-		PublicKey:       m.PublicKey,
-		ContentHash:     m.ContentHash,
-		ParentHash:      m.ParentHash,
-		Timestamp:       m.Timestamp,
-		EntropyDNA:      m.EntropyDNA,
-		Assets:          m.Assets,
-		ContributionMap: m.ContributionMap,
-		CognitiveProofs: m.CognitiveProofs,
-	}
-
-	data, err := json.Marshal(p)
+	// 1. Reconstruct payload hash (must match Sign)
+	digest, err := m.payloadHash()
 	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %w", err)
+		return err
 	}
 
-	// 2. Hash
-	hash := sha256.Sum256(data)
-
-	// 3. Decode Signature
+	// 2. Decode Signature
 	sigBytes, err := hex.DecodeString(m.Signature)
 	if err != nil {
 		return fmt.Errorf("invalid signature format: %w", err)
@@ -58,10 +31,54 @@ func (m *Manifest) Verify(pubKey *btcec.PublicKey) error {
 		return fmt.Errorf("failed to parse signature: %w", err)
 	}
 
-	// 4. Verify
-	if !signature.Verify(hash[:], pubKey) {
+	// 3. Verify
+	if !signature.Verify(digest[:], pubKey) {
 		return fmt.Errorf("signature verification failed")
 	}
 
 	return nil
 }
+
+// RecomputeScores rescores the ContributionMap with the recorded scoring
+// model and returns the files whose recorded score differs, sorted.
+// Manifests without a ScoringModel name their model in AHAScoreVersion.
+// Halstead measures are not recorded and are taken from the files in root;
+// if the model weighs them, files missing from root or changed since the
+// release cannot be rescored and are returned as skipped, sorted.
+func (m *Manifest) RecomputeScores(root string) (mismatched, skipped []string, err error) {
+	spec := aha.ModelSpec{Name: m.AHAScoreVersion}
+	if m.ScoringModel != nil {
+		spec = *m.ScoringModel
+	}
+	model, err := aha.ModelFromSpec(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	released := make(map[string]string)
+	for _, a := range m.Assets {
+		released[a.Path] = a.RawHash
+	}
+	for path, metrics := range m.ContributionMap {
+		if spec.UsesHalstead() {
+			file := filepath.Join(root, filepath.FromSlash(path))
+			if data, err := os.ReadFile(file); err != nil || rawHash(data) != released[path] {
+				skipped = append(skipped, path)
+				continue
+			}
+			metrics.AttachHalstead(file)
+		}
+		if model.Score(&metrics) != metrics.AHAScore {
+			mismatched = append(mismatched, path)
+		}
+	}
+	sort.Strings(mismatched)
+	sort.Strings(skipped)
+	return mismatched, skipped, nil
+}
+
+// rawHash is the RawHash of an asset with the given content.
+func rawHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
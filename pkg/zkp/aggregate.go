@@ -0,0 +1,370 @@
+package zkp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// ErrNotInAggregate means a file disclosure does not lead to the root of the
+// aggregate proof.
+var ErrNotInAggregate = errors.New("file is not part of the aggregate proof")
+
+// aggregateBits is the width of the range proof of 100·H - Threshold·W.
+const aggregateBits = 32
+
+// ClaimHumanShare is the claim of an aggregate proof that at least threshold
+// percent of the release's cyclomatic complexity lies in files the author
+// asserts are in the human range. The aggregate does not tie a file's
+// assertion to its ratio; only the file's disclosed proof does.
+func ClaimHumanShare(threshold int) string {
+	return fmt.Sprintf("asserted human complexity share >= %d%%", threshold)
+}
+
+// AggregateProof is a release-level cognitive proof. It commits to every
+// file proof in a Merkle-sum tree whose nodes add up two Pedersen
+// commitments: to the complexity W of the files below and to the
+// complexity H of those asserted to be in the human range. The leaves are
+// published with proofs that each H is the file's W or zero, and a range
+// proof shows
+// 100·H - Threshold·W >= 0 at the root. Single files can still be disclosed
+// from it (see AggregateOpening.Disclose).
+type AggregateProof struct {
+	System     string      `json:"system"`                // ProofSystem of the file proofs
+	Claim      string      `json:"claim"`                 // ClaimHumanShare(Threshold) or ClaimNone
+	Threshold  int         `json:"threshold"`             // Percent
+	Files      int         `json:"files"`                 // Leaves of the tree
+	Root       string      `json:"root"`                  // Hash of the root node
+	Weight     string      `json:"weight"`                // Commitment to W
+	Human      string      `json:"human"`                 // Commitment to H
+	Leaves     []SumNode   `json:"leaves"`                // In path order
+	RangeProof *RangeProof `json:"range_proof,omitempty"` // Proves the claim
+	Binding    string      `json:"binding"`               // Digest of the author, the content and the tree
+
+	// Opening is the private half of the proof. It is only set by
+	// GenerateAggregate and never serialized with the proof.
+	Opening *AggregateOpening `json:"-"`
+}
+
+// AggregateOpening holds what the author needs to disclose single files of
+// an AggregateProof. Authors keep it private: it reveals the per-file
+// structure the aggregate hides.
+type AggregateOpening struct {
+	Proofs   map[string]Proof  `json:"proofs"`   // File proofs by path
+	Blinding map[string]string `json:"blinding"` // Rerandomizes the human weight of each file
+}
+
+// SumNode is a node of the Merkle-sum tree of an AggregateProof.
+type SumNode struct {
+	Hash     string         `json:"hash"`
+	Weight   string         `json:"weight"`             // Commitment to the complexity below
+	Human    string         `json:"human"`              // Commitment to the complexity in the human range below
+	Relation *RelationProof `json:"relation,omitempty"` // Leaves only
+}
+
+// FileDisclosure reveals the proof of one file of an AggregateProof and
+// the blinding that relates it to its leaf.
+type FileDisclosure struct {
+	Path     string        `json:"path"`
+	Index    int           `json:"index"` // Position of the leaf
+	Proof    Proof         `json:"proof"`
+	Blinding string        `json:"blinding"`         // Of the human weight over the file's
+	Values   []*Disclosure `json:"values,omitempty"` // Committed values revealed with the file
+}
+
+// GenerateAggregate aggregates file proofs by path into a release-level
+// proof of ClaimHumanShare(threshold) for the release content contentHash,
+// signed off by key. The proofs must carry their openings (as returned by
+// GenerateProof) and commit to their complexity. If the release does not
+// meet threshold, the aggregate claims nothing.
+func GenerateAggregate(proofs map[string]Proof, threshold int, contentHash string, key *btcec.PrivateKey) (*AggregateProof, error) {
+	if threshold < 1 || threshold > 100 {
+		return nil, fmt.Errorf("threshold %d is not a percentage", threshold)
+	}
+	if len(proofs) == 0 {
+		return nil, errors.New("no proofs to aggregate")
+	}
+	paths := make([]string, 0, len(proofs))
+	for path := range proofs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	// 1. Sum the Complexity of All Files and of Human Files
+	// Every human weight gets its own blinding: a file in the human range
+	// adds its complexity, any other file zero, and leaves look alike.
+	a := &AggregateProof{System: proofs[paths[0]].System, Claim: ClaimNone, Threshold: threshold, Files: len(paths)}
+	opening := &AggregateOpening{Proofs: make(map[string]Proof), Blinding: make(map[string]string)}
+	nonce := newNonces(key.Key.Bytes(), "aggregate")
+	var totalW, totalH uint64
+	var blindingW, blindingH scalar
+	for _, path := range paths {
+		p := proofs[path]
+		if p.System != a.System {
+			return nil, fmt.Errorf("proof of %s was made by %s, not %s", path, p.System, a.System)
+		}
+		if p.Opening == nil || p.Commitments[ValueComplexity] == "" {
+			return nil, fmt.Errorf("proof of %s has no opened commitment to its complexity", path)
+		}
+		r, err := decodeScalar(p.Opening.Blinding[ValueComplexity])
+		if err != nil {
+			return nil, err
+		}
+		w := p.Opening.Values[ValueComplexity]
+		totalW += w
+		blindingW.Add(r)
+		if p.Claim() == ClaimHumanRatio {
+			totalH += w
+			blindingH.Add(r)
+		}
+		human := nonce.scalar("human;proof_id=" + p.ProofID)
+		blindingH.Add(human)
+		opening.Blinding[path] = encodeScalar(human)
+		opening.Proofs[path] = p
+	}
+
+	// 2. Build the Merkle-Sum Tree
+	leaves, err := opening.leaves(paths)
+	if err != nil {
+		return nil, err
+	}
+	root, err := sumTree(leaves)
+	if err != nil {
+		return nil, err
+	}
+	a.Root, a.Weight, a.Human, a.Leaves = root.Hash, root.Weight, root.Human, leaves
+	a.Binding = aggregateBinding(hex.EncodeToString(key.PubKey().SerializeCompressed()), contentHash, a)
+
+	// 3. Prove the Share Without Revealing W or H
+	// 100·Human - Threshold·Weight commits to 100·H - Threshold·W.
+	t := uint64(threshold)
+	if totalW > 0 && 100*totalH >= t*totalW {
+		if 100*totalH-t*totalW >= 1<<aggregateBits {
+			return nil, fmt.Errorf("complexity %d is too large for the %d-bit range proof of the human share", totalW, aggregateBits)
+		}
+		hundred, minusT := scalarInt(100), scalarInt(-int64(threshold))
+		x, y := mul(&hundred, &blindingH), mul(&minusT, &blindingW)
+		blinding := add(&x, &y)
+		rangeProof, err := ProveRange([]uint64{100*totalH - t*totalW}, []*scalar{&blinding}, aggregateBits, a.Binding)
+		if err != nil {
+			return nil, fmt.Errorf("failed to prove the human share: %w", err)
+		}
+		a.Claim, a.RangeProof = ClaimHumanShare(threshold), rangeProof
+	}
+	a.Opening = opening
+	return a, nil
+}
+
+// VerifyAggregate checks that a was made by the holder of publicKey (hex)
+// for the release content contentHash, that its leaves add up to its root
+// and that the range proof behind its claim verifies.
+func VerifyAggregate(a *AggregateProof, publicKey, contentHash string) error {
+	// 1. Binding
+	if a.Binding != aggregateBinding(publicKey, contentHash, a) {
+		return ErrBinding
+	}
+
+	// 2. Leaves
+	if len(a.Leaves) != a.Files || a.Files == 0 {
+		return fmt.Errorf("%w: %d leaves for %d files", ErrNotInAggregate, len(a.Leaves), a.Files)
+	}
+	for i, leaf := range a.Leaves {
+		if err := verifyRelation(leaf); err != nil {
+			return fmt.Errorf("leaf %d: %w", i, err)
+		}
+	}
+	root, err := sumTree(a.Leaves)
+	if err != nil {
+		return err
+	}
+	if root.Hash != a.Root || root.Weight != a.Weight || root.Human != a.Human {
+		return fmt.Errorf("%w: leaves do not add up to the root", ErrNotInAggregate)
+	}
+
+	// 3. Claim
+	switch a.Claim {
+	case ClaimNone:
+		return nil
+	case ClaimHumanShare(a.Threshold):
+	default:
+		return fmt.Errorf("unknown claim %q", a.Claim)
+	}
+	if a.Threshold < 1 || a.Threshold > 100 {
+		return fmt.Errorf("threshold %d is not a percentage", a.Threshold)
+	}
+	if a.RangeProof == nil || a.RangeProof.Bits != aggregateBits {
+		return fmt.Errorf("claim %q has no %d-bit range proof", a.Claim, aggregateBits)
+	}
+	W, err := decodePoint(a.Weight)
+	if err != nil {
+		return err
+	}
+	H, err := decodePoint(a.Human)
+	if err != nil {
+		return err
+	}
+	share := multiExp([]scalar{scalarInt(100), scalarInt(-int64(a.Threshold))}, []point{H, W})
+	return VerifyRange([]point{share}, a.RangeProof, a.Binding)
+}
+
+// Disclose reveals the proof of path and the blinding of its leaf.
+func (o *AggregateOpening) Disclose(path string) (*FileDisclosure, error) {
+	p, ok := o.Proofs[path]
+	if !ok {
+		return nil, fmt.Errorf("no proof of %s in the aggregate", path)
+	}
+	paths := make([]string, 0, len(o.Proofs))
+	for path := range o.Proofs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return &FileDisclosure{Path: path, Index: sort.SearchStrings(paths, path), Proof: p, Blinding: o.Blinding[path]}, nil
+}
+
+// Check verifies the disclosed file proof for b, the aggregate of the
+// release content contentHash, that the file is its leaf and the revealed
+// values.
+func (d *FileDisclosure) Check(a *AggregateProof, b Binding, contentHash string) error {
+	// 1. File Proof
+	if d.Proof.System != a.System {
+		return fmt.Errorf("proof was made by %s, the aggregate by %s", d.Proof.System, a.System)
+	}
+	if err := VerifyProof(&d.Proof, b); err != nil {
+		return err
+	}
+
+	// 2. Leaf
+	// The blinding opens the leaf's human weight to the file's complexity
+	// commitment if the file is in the human range, to zero otherwise.
+	if err := VerifyAggregate(a, b.PublicKey, contentHash); err != nil {
+		return err
+	}
+	if d.Index < 0 || d.Index >= len(a.Leaves) {
+		return fmt.Errorf("%w: leaf %d of %d", ErrNotInAggregate, d.Index, len(a.Leaves))
+	}
+	node, err := leafNode(d.Path, &d.Proof, d.Blinding)
+	if err != nil {
+		return err
+	}
+	if node.Hash != a.Leaves[d.Index].Hash {
+		return ErrNotInAggregate
+	}
+
+	// 3. Values
+	for _, v := range d.Values {
+		if v.Path != d.Path {
+			return fmt.Errorf("value %s of %s disclosed with %s", v.Name, v.Path, d.Path)
+		}
+		if err := v.Check(&d.Proof); err != nil {
+			return fmt.Errorf("%s: %w", v.Name, err)
+		}
+	}
+	return nil
+}
+
+// leaves returns the leaves of the Merkle-sum tree over the proofs of
+// paths, with their relation proofs.
+func (o *AggregateOpening) leaves(paths []string) ([]SumNode, error) {
+	leaves := make([]SumNode, len(paths))
+	for i, path := range paths {
+		p := o.Proofs[path]
+		node, err := leafNode(path, &p, o.Blinding[path])
+		if err != nil {
+			return nil, err
+		}
+		s, _ := decodeScalar(o.Blinding[path])
+		if node.Relation, err = proveRelation(node, p.Claim() == ClaimHumanRatio, s); err != nil {
+			return nil, fmt.Errorf("failed to prove the human weight of %s: %w", path, err)
+		}
+		leaves[i] = node
+	}
+	return leaves, nil
+}
+
+// sumTree returns the root of the Merkle-sum tree over leaves. An odd node
+// out is carried up unchanged.
+func sumTree(leaves []SumNode) (SumNode, error) {
+	level := make([]SumNode, len(leaves))
+	for i, leaf := range leaves {
+		leaf.Relation = nil
+		level[i] = leaf
+	}
+	for len(level) > 1 {
+		next := make([]SumNode, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			node, err := parentNode(level[i], level[i+1])
+			if err != nil {
+				return SumNode{}, err
+			}
+			next = append(next, node)
+		}
+		level = next
+	}
+	return level[0], nil
+}
+
+// leafNode weighs a file proof by its complexity commitment Wc. Its human
+// weight is Wc + s·H for a file in the human range and s·H otherwise, where
+// the blinding s hides which.
+func leafNode(path string, p *Proof, blinding string) (SumNode, error) {
+	weight, ok := p.Commitments[ValueComplexity]
+	if !ok {
+		return SumNode{}, fmt.Errorf("proof of %s has no commitment to its complexity", path)
+	}
+	s, err := decodeScalar(blinding)
+	if err != nil {
+		return SumNode{}, fmt.Errorf("human weight of %s: %w", path, err)
+	}
+	H := mulPoint(s, &pedersenH)
+	if p.Claim() == ClaimHumanRatio {
+		W, err := decodePoint(weight)
+		if err != nil {
+			return SumNode{}, err
+		}
+		H = addPoints(W, H)
+	}
+	human, err := encodePoint(&H)
+	if err != nil {
+		return SumNode{}, err
+	}
+	return SumNode{Hash: sumHash("leaf;path=%s;proof_id=%s;weight=%s;human=%s", path, p.ProofID, weight, human), Weight: weight, Human: human}, nil
+}
+
+func parentNode(l, r SumNode) (SumNode, error) {
+	var sums [2]string
+	for i, pair := range [2][2]string{{l.Weight, r.Weight}, {l.Human, r.Human}} {
+		a, err := decodePoint(pair[0])
+		if err != nil {
+			return SumNode{}, err
+		}
+		b, err := decodePoint(pair[1])
+		if err != nil {
+			return SumNode{}, err
+		}
+		sum := addPoints(a, b)
+		if sums[i], err = encodePoint(&sum); err != nil {
+			return SumNode{}, err
+		}
+	}
+	return SumNode{Hash: sumHash("node;left=%s;right=%s;weight=%s;human=%s", l.Hash, r.Hash, sums[0], sums[1]), Weight: sums[0], Human: sums[1]}, nil
+}
+
+func sumHash(format string, args ...any) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "HCP/aggregate/v1;"+format, args...))
+	return hex.EncodeToString(sum[:])
+}
+
+// aggregateBinding binds an aggregate to its author, the release content
+// and the tree. It is also the context of the range proof.
+func aggregateBinding(publicKey, contentHash string, a *AggregateProof) string {
+	return sumHash("binding;public_key=%s;content_hash=%s;system=%s;threshold=%d;files=%d;root=%s;weight=%s;human=%s",
+		publicKey, contentHash, a.System, a.Threshold, a.Files, a.Root, a.Weight, a.Human)
+}
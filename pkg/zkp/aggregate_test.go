@@ -0,0 +1,137 @@
+package zkp
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/windgeek/HCP/pkg/aha"
+	"github.com/windgeek/HCP/pkg/cognitive"
+)
+
+// aggregateFixture proves files with the given commits and complexity. A
+// file is in the human range unless complexity > 10·(commits+1).
+func aggregateFixture(t *testing.T, files [][2]int) (map[string]Proof, map[string]Binding) {
+	t.Helper()
+	proofs := make(map[string]Proof)
+	bindings := make(map[string]Binding)
+	for i, f := range files {
+		b := testBinding
+		b.Path = fmt.Sprintf("f%d.go", i)
+		b.PublicKey = hex.EncodeToString(testKey.PubKey().SerializeCompressed())
+		w := Witness{aha.AHAMetrics{Commits: f[0]}, &cognitive.ComplexityStats{Cyclomatic: f[1]}}
+		p, err := GenerateProof(DefaultSystem, w, b, testKey, 0)
+		if err != nil {
+			t.Fatalf("GenerateProof failed: %v", err)
+		}
+		proofs[b.Path], bindings[b.Path] = *p, b
+	}
+	return proofs, bindings
+}
+
+func TestAggregateProof(t *testing.T) {
+	// Complexity 12 + 8 of 31 lies in the human range: a share of 64.5%
+	proofs, bindings := aggregateFixture(t, [][2]int{{3, 12}, {0, 11}, {2, 8}})
+	pub := hex.EncodeToString(testKey.PubKey().SerializeCompressed())
+	content := strings.Repeat("c", 64)
+
+	a, err := GenerateAggregate(proofs, 60, content, testKey)
+	if err != nil {
+		t.Fatalf("GenerateAggregate failed: %v", err)
+	}
+	if a.Claim != ClaimHumanShare(60) || a.Files != 3 {
+		t.Errorf("claim %q over %d files", a.Claim, a.Files)
+	}
+	if err := VerifyAggregate(a, pub, content); err != nil {
+		t.Fatalf("VerifyAggregate failed: %v", err)
+	}
+	if err := VerifyAggregate(a, "03ff", content); !errors.Is(err, ErrBinding) {
+		t.Errorf("other author: got %v, want ErrBinding", err)
+	}
+	if err := VerifyAggregate(a, pub, strings.Repeat("d", 64)); !errors.Is(err, ErrBinding) {
+		t.Errorf("other release: got %v, want ErrBinding", err)
+	}
+
+	// Overstating the share
+	forged := *a
+	forged.Threshold = 70
+	forged.Claim = ClaimHumanShare(70)
+	forged.Binding = aggregateBinding(pub, content, &forged)
+	if err := VerifyAggregate(&forged, pub, content); !errors.Is(err, ErrRangeProof) {
+		t.Errorf("overstated share: got %v, want ErrRangeProof", err)
+	}
+	if none, _ := GenerateAggregate(proofs, 70, content, testKey); none.Claim != ClaimNone || VerifyAggregate(none, pub, content) != nil {
+		t.Errorf("unmet threshold: claim %q", none.Claim)
+	}
+
+	// Leaves hide which files are human, but their human weight must be
+	// the file's or zero
+	seen := make(map[string]bool)
+	for _, leaf := range a.Leaves {
+		if leaf.Human == leaf.Weight || seen[leaf.Human] {
+			t.Errorf("leaf %s reveals its human weight", leaf.Hash)
+		}
+		seen[leaf.Human] = true
+	}
+	forged = *a
+	forged.Leaves = append([]SumNode(nil), a.Leaves...)
+	leaf := &forged.Leaves[1]
+	W, _ := decodePoint(leaf.Weight)
+	s := scalarInt(7)
+	H := addPoints(W, W, mulPoint(&s, &pedersenH))
+	leaf.Human, _ = encodePoint(&H)
+	if leaf.Relation, err = proveRelation(*leaf, true, &s); err != nil {
+		t.Fatalf("proveRelation failed: %v", err)
+	}
+	if err := VerifyAggregate(&forged, pub, content); !errors.Is(err, ErrRelation) {
+		t.Errorf("doubled human weight: got %v, want ErrRelation", err)
+	}
+
+	// Every file can be disclosed on its own
+	for path := range proofs {
+		d, err := a.Opening.Disclose(path)
+		if err != nil {
+			t.Fatalf("Disclose(%s) failed: %v", path, err)
+		}
+		if err := d.Check(a, bindings[path], content); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+	}
+
+	// A file moved to another leaf or outside the human range
+	d, _ := a.Opening.Disclose("f1.go")
+	d.Index = 0
+	if err := d.Check(a, bindings["f1.go"], content); !errors.Is(err, ErrNotInAggregate) {
+		t.Errorf("other leaf: got %v, want ErrNotInAggregate", err)
+	}
+	d, _ = a.Opening.Disclose("f0.go")
+	other, _ := aggregateFixture(t, [][2]int{{0, 50}})
+	d.Proof = other["f0.go"]
+	d.Blinding = a.Opening.Blinding["f1.go"]
+	if err := d.Check(a, bindings["f0.go"], content); !errors.Is(err, ErrNotInAggregate) {
+		t.Errorf("substituted proof: got %v, want ErrNotInAggregate", err)
+	}
+
+	// Values revealed with a file
+	d, _ = a.Opening.Disclose("f2.go")
+	p := proofs["f2.go"]
+	v, _ := p.Opening.Disclose("f2.go", ValueComplexity)
+	d.Values = append(d.Values, v)
+	if err := d.Check(a, bindings["f2.go"], content); err != nil {
+		t.Errorf("disclosed value: %v", err)
+	}
+	v.Value++
+	if err := d.Check(a, bindings["f2.go"], content); !errors.Is(err, ErrOpeningMismatch) {
+		t.Errorf("forged value: got %v, want ErrOpeningMismatch", err)
+	}
+}
+
+func TestAggregateProofTooLarge(t *testing.T) {
+	// 100·H - 1·W = 99·5·10⁷ does not fit the range proof
+	proofs, _ := aggregateFixture(t, [][2]int{{5_000_000, 50_000_000}})
+	if _, err := GenerateAggregate(proofs, 1, strings.Repeat("c", 64), testKey); err == nil {
+		t.Error("GenerateAggregate claimed nothing instead of failing")
+	}
+}
@@ -0,0 +1,90 @@
+package zkp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// ErrBinding means a proof was made for other content or another author.
+var ErrBinding = errors.New("proof is bound to other content or another author")
+
+// Binding is the context a proof is made for: the file it measures and the
+// author releasing it. It enters every challenge of the proof, so a proof
+// cannot be replayed for other content or under another key.
+type Binding struct {
+	Path      string
+	RawHash   string
+	LogicHash string
+	PublicKey string // Hex-encoded public key of the author
+}
+
+// Digest returns the hex SHA-256 of the binding, recorded in Proof.Binding.
+func (b Binding) Digest() string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "HCP/proof-binding/v1;path=%s;raw_hash=%s;logic_hash=%s;public_key=%s",
+		b.Path, b.RawHash, b.LogicHash, b.PublicKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// KnowledgeProof shows knowledge of the opening (v, r) of a commitment
+// C = v·G + r·H without revealing it: R = k1·G + k2·H, s1 = k1 + e·v and
+// s2 = k2 + e·r, where the challenge e hashes the binding, C and R.
+// Verifiers check s1·G + s2·H = R + e·C.
+type KnowledgeProof struct {
+	R  string `json:"r"`
+	S1 string `json:"s1"`
+	S2 string `json:"s2"`
+}
+
+func proveKnowledge(binding, name, commitment string, value uint64, blinding *btcec.ModNScalar) (KnowledgeProof, error) {
+	nonce := newNonces(witnessSecret([]uint64{value}, []*scalar{blinding}),
+		fmt.Sprintf("knowledge;binding=%s;name=%s;commitment=%s", binding, name, commitment))
+	k1, k2 := nonce.scalar("k1"), nonce.scalar("k2")
+	R := addPoints(mulBase(k1), mulPoint(k2, &pedersenH))
+	encR, err := encodePoint(&R)
+	if err != nil {
+		return KnowledgeProof{}, err
+	}
+	e := knowledgeChallenge(binding, name, commitment, encR)
+	v := scalarUint64(value)
+	ev, er := mul(&e, &v), mul(&e, blinding)
+	s1, s2 := add(k1, &ev), add(k2, &er)
+	return KnowledgeProof{R: encR, S1: encodeScalar(&s1), S2: encodeScalar(&s2)}, nil
+}
+
+func verifyKnowledge(binding, name, commitment string, k KnowledgeProof) error {
+	C, err := decodePoint(commitment)
+	if err != nil {
+		return err
+	}
+	R, err := decodePoint(k.R)
+	if err != nil {
+		return err
+	}
+	s1, err := decodeScalar(k.S1)
+	if err != nil {
+		return err
+	}
+	s2, err := decodeScalar(k.S2)
+	if err != nil {
+		return err
+	}
+	e := knowledgeChallenge(binding, name, commitment, k.R)
+	var g point
+	btcec.GeneratorJacobian(&g)
+	minusOne := scalarInt(-1)
+	if !isInfinity(multiExp([]scalar{*s1, *s2, minusOne, neg(&e)}, []point{g, pedersenH, R, C})) {
+		return fmt.Errorf("%w: no knowledge of the opening of %s", ErrBinding, name)
+	}
+	return nil
+}
+
+func knowledgeChallenge(binding, name, commitment, R string) scalar {
+	sum := sha256.Sum256(fmt.Appendf(nil, "HCP/pedersen/knowledge/v1;binding=%s;name=%s;commitment=%s;r=%s", binding, name, commitment, R))
+	var e scalar
+	e.SetByteSlice(sum[:])
+	return e
+}
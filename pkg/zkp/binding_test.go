@@ -0,0 +1,51 @@
+package zkp
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/windgeek/HCP/pkg/aha"
+	"github.com/windgeek/HCP/pkg/cognitive"
+)
+
+var testKey, _ = btcec.PrivKeyFromBytes(bytes.Repeat([]byte{7}, 32))
+
+var testBinding = Binding{
+	Path:      "main.go",
+	RawHash:   "4b2a7c0f",
+	LogicHash: "9e1d33aa",
+	PublicKey: "02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5",
+}
+
+func TestBinding(t *testing.T) {
+	p, err := GenerateProof(DefaultSystem, Witness{aha.AHAMetrics{Commits: 3}, &cognitive.ComplexityStats{Cyclomatic: 12}}, testBinding, testKey, 0)
+	if err != nil {
+		t.Fatalf("GenerateProof failed: %v", err)
+	}
+	if err := VerifyProof(p, testBinding); err != nil {
+		t.Fatalf("VerifyProof failed: %v", err)
+	}
+
+	// Other content or another author
+	for _, b := range []Binding{
+		{Path: "main.go", RawHash: "ffffffff", LogicHash: testBinding.LogicHash, PublicKey: testBinding.PublicKey},
+		{Path: "other.go", RawHash: testBinding.RawHash, LogicHash: testBinding.LogicHash, PublicKey: testBinding.PublicKey},
+		{Path: "main.go", RawHash: testBinding.RawHash, LogicHash: testBinding.LogicHash, PublicKey: "03ff"},
+	} {
+		if err := VerifyProof(p, b); !errors.Is(err, ErrBinding) {
+			t.Errorf("%+v: got %v, want ErrBinding", b, err)
+		}
+	}
+
+	// Rebinding the public parts without the openings
+	stolen := *p
+	other := testBinding
+	other.PublicKey = "03ff"
+	stolen.Binding = other.Digest()
+	stolen.ProofID = proofID(stolen.Binding, stolen.Commitments)
+	if err := VerifyProof(&stolen, other); !errors.Is(err, ErrBinding) {
+		t.Errorf("rebound proof: got %v, want ErrBinding", err)
+	}
+}
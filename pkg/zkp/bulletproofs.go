@@ -0,0 +1,597 @@
+package zkp
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"math/bits"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// Range proofs follow Bulletproofs (Bünz et al., 2018): an aggregated proof
+// that m committed values each lie in [0, 2^n), with a logarithmic inner
+// product argument, made non-interactive with a SHA-256 transcript. Values
+// are committed on G and blinded on pedersenH, as by Commit.
+
+type (
+	scalar = btcec.ModNScalar
+	point  = btcec.JacobianPoint
+)
+
+// maxRangeSize bounds n*m, the number of vector generators.
+const maxRangeSize = 64
+
+// ErrRangeProof means a range proof does not verify.
+var ErrRangeProof = errors.New("range proof does not verify")
+
+var (
+	bpOnce sync.Once
+	bpG    []point // Vector generators for the bits
+	bpH    []point
+	bpU    point // Generator of the inner product
+)
+
+func rangeGenerators() ([]point, []point, point) {
+	bpOnce.Do(func() {
+		for i := 0; i < maxRangeSize; i++ {
+			bpG = append(bpG, deriveGenerator(fmt.Sprintf("HCP/bulletproofs/G/%d", i)))
+			bpH = append(bpH, deriveGenerator(fmt.Sprintf("HCP/bulletproofs/H/%d", i)))
+		}
+		bpU = deriveGenerator("HCP/bulletproofs/U")
+	})
+	return bpG, bpH, bpU
+}
+
+// RangeProof proves that each of a list of commitments opens to a value in
+// [0, 2^Bits). Points are compressed and scalars 32 bytes, both in hex.
+type RangeProof struct {
+	Bits int      `json:"bits"`
+	A    string   `json:"a"`
+	S    string   `json:"s"`
+	T1   string   `json:"t1"`
+	T2   string   `json:"t2"`
+	TauX string   `json:"tau_x"`
+	Mu   string   `json:"mu"`
+	T    string   `json:"t"`
+	L    []string `json:"l"` // Inner product argument, one per round
+	R    []string `json:"r"`
+	IPA  string   `json:"ipa_a"` // Final folded vectors
+	IPB  string   `json:"ipa_b"`
+}
+
+// ProveRange proves that commitPoint(values[j], blindings[j]) opens to a
+// value below 2^nbits for every j. nbits and len(values) must be powers of
+// two with a product of at most maxRangeSize. The proof only verifies with
+// the same context (e.g. a Binding digest).
+func ProveRange(values []uint64, blindings []*scalar, nbits int, context string) (*RangeProof, error) {
+	m := len(values)
+	if err := checkRangeSize(nbits, m); err != nil {
+		return nil, err
+	}
+	if len(blindings) != m {
+		return nil, errors.New("one blinding factor per value is required")
+	}
+	n := nbits * m
+	gs, hs, u := rangeGenerators()
+	gs, hs = gs[:n], hs[:n]
+
+	tr := newRangeTranscript(nbits, m, context)
+	for j, v := range values {
+		if nbits < 64 && v>>nbits != 0 {
+			return nil, fmt.Errorf("value %d does not fit in %d bits", v, nbits)
+		}
+		V := commitPoint(v, blindings[j])
+		tr.point(&V)
+	}
+	nonce := newNonces(witnessSecret(values, blindings), fmt.Sprintf("range;bits=%d;values=%d;context=%s", nbits, m, context))
+
+	// 1. Commit to the Bits (A) and Blinding Vectors (S)
+	aL, aR := make([]scalar, n), make([]scalar, n)
+	minusOne := scalarInt(-1)
+	for j, v := range values {
+		for i := 0; i < nbits; i++ {
+			k := j*nbits + i
+			if v>>i&1 == 1 {
+				aL[k].SetInt(1)
+			} else {
+				aR[k] = minusOne
+			}
+		}
+	}
+	alpha, rho := nonce.scalar("alpha"), nonce.scalar("rho")
+	sL, sR := nonce.scalars("sL", n), nonce.scalars("sR", n)
+	vectorPoints := append(append([]point{pedersenH}, gs...), hs...)
+	A := multiExp(append(append([]scalar{*alpha}, aL...), aR...), vectorPoints)
+	S := multiExp(append(append([]scalar{*rho}, sL...), sR...), vectorPoints)
+	tr.point(&A)
+	tr.point(&S)
+	y, err := tr.challenge()
+	if err != nil {
+		return nil, err
+	}
+	z, err := tr.challenge()
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Commit to the Coefficients of t(X) = <l(X), r(X)>
+	yN := powers(&y, n)
+	d := rangeOffsets(&z, nbits, m)
+	l0, r0, r1 := make([]scalar, n), make([]scalar, n), make([]scalar, n)
+	for k := 0; k < n; k++ {
+		l0[k] = sub(&aL[k], &z)
+		sum := add(&aR[k], &z)
+		r0[k] = mul(&yN[k], &sum)
+		r0[k].Add(&d[k])
+		r1[k] = mul(&yN[k], &sR[k])
+	}
+	t1a, t1b := inner(l0, r1), inner(sL, r0)
+	t1 := add(&t1a, &t1b)
+	t2 := inner(sL, r1)
+	tau1, tau2 := nonce.scalar("tau1"), nonce.scalar("tau2")
+	T1 := addPoints(mulBase(&t1), mulPoint(tau1, &pedersenH))
+	T2 := addPoints(mulBase(&t2), mulPoint(tau2, &pedersenH))
+	tr.point(&T1)
+	tr.point(&T2)
+	x, err := tr.challenge()
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Evaluate at x
+	x2 := mul(&x, &x)
+	tauX := mul(tau2, &x2)
+	tauXa := mul(tau1, &x)
+	tauX.Add(&tauXa)
+	zj := mul(&z, &z)
+	for j := 0; j < m; j++ {
+		term := mul(&zj, blindings[j])
+		tauX.Add(&term)
+		zj.Mul(&z)
+	}
+	mu := mul(rho, &x)
+	mu.Add(alpha)
+	l, r := make([]scalar, n), make([]scalar, n)
+	for k := 0; k < n; k++ {
+		xs := mul(&x, &sL[k])
+		l[k] = add(&l0[k], &xs)
+		xr := mul(&x, &r1[k])
+		r[k] = add(&r0[k], &xr)
+	}
+	t := inner(l, r)
+	tr.scalar(&tauX)
+	tr.scalar(&mu)
+	tr.scalar(&t)
+	w, err := tr.challenge()
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. Inner Product Argument for l and r over G and H' = y^-k H
+	// The folded generators are kept as coefficients of the original ones:
+	// G'_i = sum sG[k] G_k and H'_i = sum sH[k] H_k over k ≡ i mod len(l).
+	q := mulPoint(&w, &u)
+	proof := &RangeProof{
+		Bits: nbits,
+		TauX: encodeScalar(&tauX),
+		Mu:   encodeScalar(&mu),
+		T:    encodeScalar(&t),
+	}
+	for _, f := range []struct {
+		p   *point
+		out *string
+	}{{&A, &proof.A}, {&S, &proof.S}, {&T1, &proof.T1}, {&T2, &proof.T2}} {
+		if *f.out, err = encodePoint(f.p); err != nil {
+			return nil, err
+		}
+	}
+	yInv := new(scalar).InverseValNonConst(&y)
+	sG, sH := powers(new(scalar).SetInt(1), n), powers(yInv, n)
+	ipaPoints := append(append(append([]point(nil), gs...), hs...), q)
+	for len(l) > 1 {
+		size := len(l)
+		h := size / 2
+		cL, cR := inner(l[:h], r[h:]), inner(l[h:], r[:h])
+		kL, kR := make([]scalar, 2*n+1), make([]scalar, 2*n+1)
+		for k := 0; k < n; k++ {
+			if i := k % size; i >= h {
+				kL[k] = mul(&l[i-h], &sG[k])
+				kR[n+k] = mul(&r[i-h], &sH[k])
+			} else {
+				kR[k] = mul(&l[i+h], &sG[k])
+				kL[n+k] = mul(&r[i+h], &sH[k])
+			}
+		}
+		kL[2*n], kR[2*n] = cL, cR
+		L, R := multiExp(kL, ipaPoints), multiExp(kR, ipaPoints)
+		tr.point(&L)
+		tr.point(&R)
+		ch, err := tr.challenge()
+		if err != nil {
+			return nil, err
+		}
+		chInv := new(scalar).InverseValNonConst(&ch)
+		for i := 0; i < h; i++ {
+			l[i] = add2(mul(&l[i], &ch), mul(&l[h+i], chInv))
+			r[i] = add2(mul(&r[i], chInv), mul(&r[h+i], &ch))
+		}
+		for k := 0; k < n; k++ {
+			if k%size < h {
+				sG[k].Mul(chInv)
+				sH[k].Mul(&ch)
+			} else {
+				sG[k].Mul(&ch)
+				sH[k].Mul(chInv)
+			}
+		}
+		l, r = l[:h], r[:h]
+		encL, err := encodePoint(&L)
+		if err != nil {
+			return nil, err
+		}
+		encR, err := encodePoint(&R)
+		if err != nil {
+			return nil, err
+		}
+		proof.L = append(proof.L, encL)
+		proof.R = append(proof.R, encR)
+	}
+	proof.IPA = encodeScalar(&l[0])
+	proof.IPB = encodeScalar(&r[0])
+	return proof, nil
+}
+
+// VerifyRange checks that every commitment opens to a value below
+// 2^p.Bits, for the context the proof was made in.
+func VerifyRange(commitments []point, p *RangeProof, context string) error {
+	m := len(commitments)
+	if err := checkRangeSize(p.Bits, m); err != nil {
+		return err
+	}
+	n := p.Bits * m
+	rounds := bits.TrailingZeros(uint(n))
+	if len(p.L) != rounds || len(p.R) != rounds {
+		return fmt.Errorf("%w: expected %d inner product rounds", ErrRangeProof, rounds)
+	}
+	gs, hs, u := rangeGenerators()
+	gs, hs = gs[:n], hs[:n]
+
+	// 1. Decode and Replay the Transcript
+	var A, S, T1, T2 point
+	for _, f := range []struct {
+		in  string
+		out *point
+	}{{p.A, &A}, {p.S, &S}, {p.T1, &T1}, {p.T2, &T2}} {
+		pt, err := decodePoint(f.in)
+		if err != nil {
+			return err
+		}
+		*f.out = pt
+	}
+	var tauX, mu, t, ipa, ipb *scalar
+	for _, f := range []struct {
+		in  string
+		out **scalar
+	}{{p.TauX, &tauX}, {p.Mu, &mu}, {p.T, &t}, {p.IPA, &ipa}, {p.IPB, &ipb}} {
+		s, err := decodeScalar(f.in)
+		if err != nil {
+			return err
+		}
+		*f.out = s
+	}
+	tr := newRangeTranscript(p.Bits, m, context)
+	for j := range commitments {
+		tr.point(&commitments[j])
+	}
+	tr.point(&A)
+	tr.point(&S)
+	y, err := tr.challenge()
+	if err != nil {
+		return err
+	}
+	z, err := tr.challenge()
+	if err != nil {
+		return err
+	}
+	tr.point(&T1)
+	tr.point(&T2)
+	x, err := tr.challenge()
+	if err != nil {
+		return err
+	}
+	tr.scalar(tauX)
+	tr.scalar(mu)
+	tr.scalar(t)
+	w, err := tr.challenge()
+	if err != nil {
+		return err
+	}
+	Ls, Rs := make([]point, rounds), make([]point, rounds)
+	chs, chInvs := make([]scalar, rounds), make([]scalar, rounds)
+	for j := 0; j < rounds; j++ {
+		if Ls[j], err = decodePoint(p.L[j]); err != nil {
+			return err
+		}
+		if Rs[j], err = decodePoint(p.R[j]); err != nil {
+			return err
+		}
+		tr.point(&Ls[j])
+		tr.point(&Rs[j])
+		if chs[j], err = tr.challenge(); err != nil {
+			return err
+		}
+		chInvs[j].InverseValNonConst(&chs[j])
+	}
+
+	// 2. Check t = t(x): t G + tauX H = sum z^(2+j) V_j + delta G + x T1 + x^2 T2
+	yN := powers(&y, n)
+	z2 := mul(&z, &z)
+	var sumY scalar
+	for k := range yN {
+		sumY.Add(&yN[k])
+	}
+	zMinusZ2 := sub(&z, &z2)
+	delta := mul(&zMinusZ2, &sumY)
+	twoN := powers(new(scalar).SetInt(2), p.Bits+1)[p.Bits] // <1, 2^n> = 2^n - 1
+	twoN.Add(new(scalar).SetInt(1).Negate())
+	zj := mul(&z2, &z)
+	for j := 0; j < m; j++ {
+		term := mul(&zj, &twoN)
+		delta = sub(&delta, &term)
+		zj.Mul(&z)
+	}
+	x2 := mul(&x, &x)
+	var g point
+	btcec.GeneratorJacobian(&g)
+	ks := []scalar{sub(t, &delta), *tauX, neg(&x), neg(&x2)}
+	ps := []point{g, pedersenH, T1, T2}
+	zj = z2
+	for j := range commitments {
+		ks = append(ks, neg(&zj))
+		ps = append(ps, commitments[j])
+		zj.Mul(&z)
+	}
+	if !isInfinity(multiExp(ks, ps)) {
+		return fmt.Errorf("%w: polynomial commitment mismatch", ErrRangeProof)
+	}
+
+	// 3. Check the Inner Product Argument as One Multi-Exponentiation:
+	// A + xS - z<1,G> + <z + d_k y^-k, H> - mu H + w t U + sum(u^2 L + u^-2 R)
+	// = <a s, G> + <b s^-1 y^-k, H> + w a b U
+	d := rangeOffsets(&z, p.Bits, m)
+	yInv := new(scalar).InverseValNonConst(&y)
+	yInvN := powers(yInv, n)
+	ks = ks[:0]
+	ps = ps[:0]
+	for k := 0; k < n; k++ {
+		// s_k multiplies u_j for every round whose split puts k in the upper half
+		var s, sInv scalar
+		s.SetInt(1)
+		sInv.SetInt(1)
+		for j := 0; j < rounds; j++ {
+			if k>>(rounds-1-j)&1 == 1 {
+				s.Mul(&chs[j])
+				sInv.Mul(&chInvs[j])
+			} else {
+				s.Mul(&chInvs[j])
+				sInv.Mul(&chs[j])
+			}
+		}
+		as := mul(ipa, &s)
+		gk := add(&z, &as)
+		ks = append(ks, neg(&gk))
+		ps = append(ps, gs[k])
+
+		dy := mul(&d[k], &yInvN[k])
+		bs := mul(ipb, &sInv)
+		bs.Mul(&yInvN[k])
+		hk := add(&z, &dy)
+		ks = append(ks, sub(&hk, &bs))
+		ps = append(ps, hs[k])
+	}
+	ab := mul(ipa, ipb)
+	tMinusAB := sub(t, &ab)
+	ks = append(ks, *new(scalar).SetInt(1), x, neg(mu), mul(&w, &tMinusAB))
+	ps = append(ps, A, S, pedersenH, u)
+	for j := 0; j < rounds; j++ {
+		ks = append(ks, mul(&chs[j], &chs[j]), mul(&chInvs[j], &chInvs[j]))
+		ps = append(ps, Ls[j], Rs[j])
+	}
+	if !isInfinity(multiExp(ks, ps)) {
+		return fmt.Errorf("%w: inner product mismatch", ErrRangeProof)
+	}
+	return nil
+}
+
+func checkRangeSize(nbits, m int) error {
+	if nbits <= 0 || m <= 0 || nbits&(nbits-1) != 0 || m&(m-1) != 0 || nbits*m > maxRangeSize {
+		return fmt.Errorf("unsupported range proof size: %d values of %d bits", m, nbits)
+	}
+	return nil
+}
+
+// rangeOffsets returns d_k = z^(2+j) 2^i for bit i of value j.
+func rangeOffsets(z *scalar, nbits, m int) []scalar {
+	d := make([]scalar, nbits*m)
+	two := new(scalar).SetInt(2)
+	twoN := powers(two, nbits)
+	zj := mul(z, z)
+	for j := 0; j < m; j++ {
+		for i := 0; i < nbits; i++ {
+			d[j*nbits+i] = mul(&zj, &twoN[i])
+		}
+		zj.Mul(z)
+	}
+	return d
+}
+
+// rangeTranscript derives the challenges from everything sent so far.
+type rangeTranscript struct {
+	h hash.Hash
+}
+
+func newRangeTranscript(nbits, m int, context string) *rangeTranscript {
+	t := &rangeTranscript{h: sha256.New()}
+	fmt.Fprintf(t.h, "HCP/bulletproofs/range/v1;bits=%d;values=%d;context=%s;", nbits, m, context)
+	return t
+}
+
+func (t *rangeTranscript) point(p *point) {
+	c := *p
+	c.ToAffine()
+	if c.X.IsZero() && c.Y.IsZero() {
+		t.h.Write(make([]byte, 33))
+		return
+	}
+	t.h.Write(btcec.NewPublicKey(&c.X, &c.Y).SerializeCompressed())
+}
+
+func (t *rangeTranscript) scalar(s *scalar) {
+	b := s.Bytes()
+	t.h.Write(b[:])
+}
+
+func (t *rangeTranscript) challenge() (scalar, error) {
+	sum := t.h.Sum(nil)
+	t.h.Write(sum)
+	var c scalar
+	c.SetByteSlice(sum)
+	if c.IsZero() {
+		return c, errors.New("zero challenge")
+	}
+	return c, nil
+}
+
+// Scalar and point helpers.
+
+func scalarInt(v int64) scalar {
+	s := scalarUint64(uint64(max(v, -v)))
+	if v < 0 {
+		s.Negate()
+	}
+	return s
+}
+
+func scalarUint64(v uint64) scalar {
+	var s scalar
+	s.SetByteSlice(binary.BigEndian.AppendUint64(nil, v))
+	return s
+}
+
+func add(a, b *scalar) scalar { return *new(scalar).Add2(a, b) }
+func add2(a, b scalar) scalar { return add(&a, &b) }
+func mul(a, b *scalar) scalar { return *new(scalar).Mul2(a, b) }
+func neg(a *scalar) scalar    { return *new(scalar).NegateVal(a) }
+func sub(a, b *scalar) scalar {
+	nb := neg(b)
+	return add(a, &nb)
+}
+
+func powers(x *scalar, n int) []scalar {
+	out := make([]scalar, n)
+	out[0].SetInt(1)
+	for i := 1; i < n; i++ {
+		out[i] = mul(&out[i-1], x)
+	}
+	return out
+}
+
+func inner(a, b []scalar) scalar {
+	var sum scalar
+	for i := range a {
+		term := mul(&a[i], &b[i])
+		sum.Add(&term)
+	}
+	return sum
+}
+
+func mulBase(k *scalar) point {
+	var r point
+	btcec.ScalarBaseMultNonConst(k, &r)
+	return r
+}
+
+func mulPoint(k *scalar, p *point) point {
+	var r point
+	btcec.ScalarMultNonConst(k, p, &r)
+	return r
+}
+
+func addPoints(ps ...point) point {
+	var sum point
+	for i := range ps {
+		var r point
+		btcec.AddNonConst(&sum, &ps[i], &r)
+		sum = r
+	}
+	return sum
+}
+
+// multiExp returns sum ks[i]·ps[i] with Straus' method: the points share
+// the doublings, and each adds one multiple of itself per 4-bit window.
+func multiExp(ks []scalar, ps []point) point {
+	type term struct {
+		digits [32]byte // Big-endian scalar
+		table  [16]point
+	}
+	terms := make([]term, 0, len(ks))
+	for i := range ks {
+		if ks[i].IsZero() {
+			continue
+		}
+		t := term{digits: ks[i].Bytes()}
+		t.table[1] = ps[i]
+		for d := 2; d < 16; d++ {
+			btcec.AddNonConst(&t.table[d-1], &ps[i], &t.table[d])
+		}
+		terms = append(terms, t)
+	}
+
+	var sum point
+	for window := 0; window < 64; window++ {
+		if window > 0 {
+			for j := 0; j < 4; j++ {
+				var r point
+				btcec.DoubleNonConst(&sum, &r)
+				sum = r
+			}
+		}
+		for i := range terms {
+			b := terms[i].digits[window/2]
+			d := b >> 4
+			if window%2 == 1 {
+				d = b & 0x0f
+			}
+			if d != 0 {
+				var r point
+				btcec.AddNonConst(&sum, &terms[i].table[d], &r)
+				sum = r
+			}
+		}
+	}
+	return sum
+}
+
+func isInfinity(p point) bool {
+	p.ToAffine()
+	return p.X.IsZero() && p.Y.IsZero()
+}
+
+func decodePoint(s string) (point, error) {
+	var p point
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return p, fmt.Errorf("invalid point %q", s)
+	}
+	pub, err := btcec.ParsePubKey(b)
+	if err != nil {
+		return p, fmt.Errorf("invalid point %q: %w", s, err)
+	}
+	pub.AsJacobian(&p)
+	return p, nil
+}
@@ -0,0 +1,93 @@
+package zkp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/windgeek/HCP/pkg/aha"
+	"github.com/windgeek/HCP/pkg/cognitive"
+)
+
+func TestRangeProof(t *testing.T) {
+	values := []uint64{0, 9900}
+	blindings := make([]*scalar, len(values))
+	commitments := make([]point, len(values))
+	for j, v := range values {
+		blindings[j], _ = NewBlinding()
+		commitments[j] = commitPoint(v, blindings[j])
+	}
+	p, err := ProveRange(values, blindings, 16, "ctx")
+	if err != nil {
+		t.Fatalf("ProveRange failed: %v", err)
+	}
+	if len(p.L) != 5 {
+		t.Errorf("got %d inner product rounds, want 5 for 32 bits", len(p.L))
+	}
+	if err := VerifyRange(commitments, p, "ctx"); err != nil {
+		t.Fatalf("VerifyRange failed: %v", err)
+	}
+	if err := VerifyRange(commitments, p, "other"); !errors.Is(err, ErrRangeProof) {
+		t.Errorf("other context: got %v, want ErrRangeProof", err)
+	}
+
+	// Another value behind the first commitment
+	forged := append([]point(nil), commitments...)
+	forged[0] = commitPoint(1, blindings[0])
+	if err := VerifyRange(forged, p, "ctx"); !errors.Is(err, ErrRangeProof) {
+		t.Errorf("wrong commitment: got %v, want ErrRangeProof", err)
+	}
+	tampered := *p
+	tampered.T = tampered.TauX
+	if err := VerifyRange(commitments, &tampered, "ctx"); !errors.Is(err, ErrRangeProof) {
+		t.Errorf("tampered proof: got %v, want ErrRangeProof", err)
+	}
+
+	if _, err := ProveRange([]uint64{1 << 16}, blindings[:1], 16, "ctx"); err == nil {
+		t.Error("ProveRange accepted a value out of range")
+	}
+}
+
+func TestRatioClaim(t *testing.T) {
+	tests := []struct {
+		commits, cyclomatic int
+		claim               string
+	}{
+		{3, 12, ClaimHumanRatio}, // 3.0
+		{9, 1, ClaimHumanRatio},  // 0.1
+		{0, 10, ClaimHumanRatio}, // 10.0
+		{0, 11, ClaimNone},       // 11.0
+		{19, 1, ClaimNone},       // 0.05
+	}
+	for _, tt := range tests {
+		stats := &cognitive.ComplexityStats{Cyclomatic: tt.cyclomatic, Analyzer: "go-ast@1"}
+		p, err := GenerateProof(DefaultSystem, Witness{aha.AHAMetrics{Commits: tt.commits}, stats}, testBinding, testKey, 0)
+		if err != nil {
+			t.Fatalf("GenerateProof failed: %v", err)
+		}
+		if p.Claim() != tt.claim {
+			t.Errorf("%d/%d: claim %q, want %q", tt.cyclomatic, tt.commits+1, p.Claim(), tt.claim)
+		}
+		if strings.Contains(p.PublicInput, "commits") || strings.Contains(p.PublicInput, "cyclomatic") {
+			t.Errorf("public input %q reveals the witness", p.PublicInput)
+		}
+		if err := VerifyProof(p, testBinding); err != nil {
+			t.Errorf("%d/%d: %v", tt.cyclomatic, tt.commits+1, err)
+		}
+	}
+
+	// A range proof for one ratio does not carry over to another
+	human, _ := GenerateProof(DefaultSystem, Witness{aha.AHAMetrics{Commits: 3}, &cognitive.ComplexityStats{Cyclomatic: 12}}, testBinding, testKey, 0)
+	other, _ := GenerateProof(DefaultSystem, Witness{aha.AHAMetrics{Commits: 2}, &cognitive.ComplexityStats{Cyclomatic: 12}}, testBinding, testKey, 0)
+	other.RangeProof = human.RangeProof
+	if err := VerifyProof(other, testBinding); !errors.Is(err, ErrRangeProof) {
+		t.Errorf("transplanted range proof: got %v, want ErrRangeProof", err)
+	}
+
+	// Claiming a human ratio without a proof
+	none, _ := GenerateProof(DefaultSystem, Witness{aha.AHAMetrics{}, &cognitive.ComplexityStats{Cyclomatic: 50}}, testBinding, testKey, 0)
+	none.PublicInput = strings.Replace(none.PublicInput, ClaimNone, ClaimHumanRatio, 1)
+	if VerifyProof(none, testBinding) == nil {
+		t.Error("VerifyProof accepted a claim without a range proof")
+	}
+}
@@ -0,0 +1,176 @@
+package zkp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// commitmentSystem is the default backend: Pedersen commitments to the
+// witness values (§3.3.1), knowledge of their openings bound to the file and
+// author (§3.3.3), and a Bulletproofs range proof of the human ratio claim
+// (§3.3.2). The values stay hidden; Proof.Opening reveals them to auditors.
+type commitmentSystem struct{}
+
+func (commitmentSystem) Name() string { return "pedersen-bulletproofs" }
+func (commitmentSystem) Version() int { return 1 }
+
+// Setup derives the generators of the range proofs.
+func (commitmentSystem) Setup() error {
+	rangeGenerators()
+	return nil
+}
+
+// Prove commits to the witness and proves the ratio claim. Its blinding
+// factors and nonces are derived from key and the witness (RFC 6979 style),
+// so the same inputs always give the same proof.
+func (commitmentSystem) Prove(w Witness, b Binding, key *btcec.PrivateKey) (*Proof, error) {
+	// 1. Construct the Secret Witness
+	opening := &Opening{Values: w.values(), Blinding: make(map[string]string)}
+
+	// 2. Commit to Each Value and Prove Knowledge of Its Opening
+	// Blinding factors depend on every value, so commitments to a changed
+	// witness never share one.
+	binding := b.Digest()
+	names := make([]string, 0, len(opening.Values))
+	for name := range opening.Values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	statement := "blinding;binding=" + binding
+	for _, name := range names {
+		statement += fmt.Sprintf(";%s=%d", name, opening.Values[name])
+	}
+	nonce := newNonces(key.Key.Bytes(), statement)
+	commitments := make(map[string]string)
+	knowledge := make(map[string]KnowledgeProof)
+	blindings := make(map[string]*btcec.ModNScalar)
+	for _, name := range names {
+		value := opening.Values[name]
+		r := nonce.scalar(name)
+		c, err := Commit(value, r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to commit to %s: %w", name, err)
+		}
+		commitments[name] = c
+		blindings[name] = r
+		if knowledge[name], err = proveKnowledge(binding, name, c, value, r); err != nil {
+			return nil, fmt.Errorf("failed to prove knowledge of %s: %w", name, err)
+		}
+		opening.Blinding[name] = encodeScalar(r)
+	}
+
+	// 3. Prove the Ratio Is Human Without Revealing It
+	// v - RatioMin and RatioMax - v are committed by C - RatioMin·G (blinding
+	// r) and RatioMax·G - C (blinding -r), which the verifier derives from C.
+	claim := ClaimNone
+	var rangeProof *RangeProof
+	if v := opening.Values[ValueRatio]; v >= RatioMin && v <= RatioMax {
+		r := blindings[ValueRatio]
+		negR := new(btcec.ModNScalar).NegateVal(r)
+		var err error
+		rangeProof, err = ProveRange([]uint64{v - RatioMin, RatioMax - v}, []*btcec.ModNScalar{r, negR}, ratioBits, binding)
+		if err != nil {
+			return nil, fmt.Errorf("failed to prove ratio range: %w", err)
+		}
+		claim = ClaimHumanRatio
+	}
+
+	// 4. Public Input (What the verifier sees)
+	// Only the claim and how complexity was measured; the values stay hidden.
+	return &Proof{
+		ProofID:     proofID(binding, commitments),
+		PublicInput: fmt.Sprintf("claim=%s;analyzer=%s", claim, w.Complexity.Analyzer),
+		Commitments: commitments,
+		RangeProof:  rangeProof,
+		Knowledge:   knowledge,
+		Binding:     binding,
+		Opening:     opening,
+	}, nil
+}
+
+// Verify checks that a proof was made for b: that its commitments are
+// covered by its ID, that the prover knows their openings, and that the
+// range proof behind its claim verifies.
+func (commitmentSystem) Verify(p *Proof, b Binding) error {
+	// 1. Binding
+	binding := b.Digest()
+	if p.Binding != binding {
+		return ErrBinding
+	}
+
+	// 2. Commitments
+	if len(p.Commitments) == 0 {
+		return errors.New("proof has no commitments")
+	}
+	if p.ProofID != proofID(binding, p.Commitments) {
+		return errors.New("proof ID does not match the commitments")
+	}
+	for name, c := range p.Commitments {
+		k, ok := p.Knowledge[name]
+		if !ok {
+			return fmt.Errorf("%w: no knowledge proof for %s", ErrBinding, name)
+		}
+		if err := verifyKnowledge(binding, name, c, k); err != nil {
+			return err
+		}
+	}
+
+	// 3. Claim
+	switch claim := p.Claim(); claim {
+	case ClaimNone:
+		return nil
+	case ClaimHumanRatio:
+		if p.RangeProof == nil || p.RangeProof.Bits != ratioBits {
+			return fmt.Errorf("claim %q has no %d-bit range proof", claim, ratioBits)
+		}
+		c, ok := p.Commitments[ValueRatio]
+		if !ok {
+			return fmt.Errorf("claim %q has no commitment to the ratio", claim)
+		}
+		commitments, err := ratioRangeCommitments(c)
+		if err != nil {
+			return err
+		}
+		return VerifyRange(commitments, p.RangeProof, binding)
+	default:
+		return fmt.Errorf("unknown claim %q", claim)
+	}
+}
+
+// ratioRangeCommitments derives the commitments to v - RatioMin and
+// RatioMax - v from the commitment c to the ratio v.
+func ratioRangeCommitments(c string) ([]point, error) {
+	C, err := decodePoint(c)
+	if err != nil {
+		return nil, err
+	}
+	var g point
+	btcec.GeneratorJacobian(&g)
+	lower := scalarInt(-RatioMin)
+	upper := scalarInt(RatioMax)
+	minusOne := scalarInt(-1)
+	return []point{
+		multiExp([]scalar{*new(scalar).SetInt(1), lower}, []point{C, g}),
+		multiExp([]scalar{upper, minusOne}, []point{g, C}),
+	}, nil
+}
+
+// proofID hashes the binding and the commitments in name order.
+func proofID(binding string, commitments map[string]string) string {
+	names := make([]string, 0, len(commitments))
+	for name := range commitments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	hasher := sha256.New()
+	fmt.Fprintf(hasher, "binding=%s;", binding)
+	for _, name := range names {
+		fmt.Fprintf(hasher, "%s=%s;", name, commitments[name])
+	}
+	return hex.EncodeToString(hasher.Sum(nil))
+}
@@ -3,59 +3,159 @@ package zkp
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"time"
+	"math"
+	"strconv"
+	"strings"
 
+	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/windgeek/HCP/pkg/aha"
 	"github.com/windgeek/HCP/pkg/cognitive"
 )
 
 // Proof represents a Zero-Knowledge Proof of Cognitive Work.
-// In this MVP, it's a hash commitment to the metrics, signed by the timestamp.
+// What it holds depends on the ProofSystem named by System; the default is a
+// set of Pedersen commitments to the metrics, identified by their hash.
 type Proof struct {
-	ProofID   string `json:"proof_id"`   // Unique ID of the proof (Hash)
-	Timestamp int64  `json:"timestamp"`  // When the proof was generated
-	PublicInput string `json:"public_input"` // Summary of what is being proven (e.g. "Complexity > 5")
-}
-
-// GenerateProof creates a mock ZKP for the given file's metrics.
-// It proves:
-// 1. The user spent time (proxied by AHA Commits)
-// 2. The code has complexity (Cognitive Load)
-// 3. The ratio is "Human"
-func GenerateProof(metrics aha.AHAMetrics, complexity *cognitive.ComplexityStats) (*Proof, error) {
-	// 1. Calculate Cognitive Ratio
-	// Ratio = Complexity / (Commits + 1)
-	// (Avoid division by zero)
-	ratio := float64(complexity.Cyclomatic) / float64(metrics.Commits + 1)
-
-	// 2. Construct the "Secret" Witness
-	// In a real ZKP, this would be the private input to the circuit.
-	witness := fmt.Sprintf("commits=%d;complexity=%d;volume=%f;ratio=%f;salt=%d",
-		metrics.Commits, complexity.Cyclomatic, complexity.HalsteadVolume, ratio, time.Now().UnixNano())
-
-	// 3. Generate the Proof (Hash Commitment)
-	hasher := sha256.New()
-	hasher.Write([]byte(witness))
-	proofHash := hex.EncodeToString(hasher.Sum(nil))
-
-	// 4. Public Input (What the verifier sees)
-	// "I prove that complexity is N and commits are M, yielding a human ratio."
-	publicInput := fmt.Sprintf("cyclomatic=%d;commits=%d", complexity.Cyclomatic, metrics.Commits)
+	ProofID     string                    `json:"proof_id"`              // Unique ID of the proof (Hash of the binding and commitments)
+	System      string                    `json:"system,omitempty"`      // ProofSystem that made the proof ("name@version")
+	Timestamp   int64                     `json:"timestamp"`             // When the proof was generated (SOURCE_DATE_EPOCH for reproducible releases)
+	PublicInput string                    `json:"public_input"`          // Summary of what is being proven (e.g. "Complexity > 5")
+	Commitments map[string]string         `json:"commitments,omitempty"` // Pedersen commitment to each witness value
+	RangeProof  *RangeProof               `json:"range_proof,omitempty"` // Proves the claim of PublicInput
+	Knowledge   map[string]KnowledgeProof `json:"knowledge,omitempty"`   // Knowledge of each commitment's opening
+	Binding     string                    `json:"binding,omitempty"`     // Binding.Digest of the file and author
+	Data        json.RawMessage           `json:"data,omitempty"`        // Backend-specific proof (e.g. a Groth16 proof)
 
+	// Opening is the private half of the proof. It is only set by
+	// backends that commit to the witness and never serialized with the proof.
+	Opening *Opening `json:"-"`
+}
+
+// Committed witness values.
+const (
+	ValueCommits    = "commits"
+	ValueComplexity = "complexity"      // Cyclomatic complexity
+	ValueRatio      = "ratio"           // Cognitive ratio in 1/RatioScale units
+	ValueVolume     = "halstead_volume" // Rounded
+	ValueEffort     = "halstead_effort" // Rounded
+)
+
+// RatioScale is the fixed-point scale of the committed ratio.
+const RatioScale = 1000
+
+// The human range of the cognitive ratio (RFC-002 §3.3), in 1/RatioScale
+// units.
+const (
+	RatioMin = 100   // 0.1
+	RatioMax = 10000 // 10.0
+)
+
+// ClaimHumanRatio is the claim of a proof whose committed ratio lies in
+// [RatioMin, RatioMax]; ClaimNone proves nothing beyond the commitments.
+const (
+	ClaimHumanRatio = "ratio in [0.1,10]"
+	ClaimNone       = "none"
+)
+
+// ratioBits is the width of each half of the ratio range proof: v - RatioMin
+// and RatioMax - v both lie in [0, 2^16).
+const ratioBits = 16
+
+// Witness is the private input of a proof: the file's history and
+// complexity.
+type Witness struct {
+	Metrics    aha.AHAMetrics
+	Complexity *cognitive.ComplexityStats
+}
+
+// values returns the witness values every backend proves statements about.
+// The cognitive ratio is complexity / (commits + 1), avoiding division by
+// zero.
+func (w Witness) values() map[string]uint64 {
+	ratio := float64(w.Complexity.Cyclomatic) / float64(w.Metrics.Commits+1)
+	h := w.Complexity.Halstead
+	return map[string]uint64{
+		ValueCommits:    uint64(w.Metrics.Commits),
+		ValueComplexity: uint64(w.Complexity.Cyclomatic),
+		ValueRatio:      uint64(math.Round(ratio * RatioScale)),
+		ValueVolume:     uint64(math.Round(h.Volume)),
+		ValueEffort:     uint64(math.Round(h.Effort)),
+	}
+}
+
+// ratioClaim returns the claim that holds for the ratio v.
+func ratioClaim(v uint64) string {
+	if v >= RatioMin && v <= RatioMax {
+		return ClaimHumanRatio
+	}
+	return ClaimNone
+}
+
+// Claim returns the claim stated in the public input.
+func (p Proof) Claim() string {
+	return p.publicField("claim")
+}
+
+// publicField returns the named field of the public input.
+func (p Proof) publicField(name string) string {
+	for _, field := range strings.Split(p.PublicInput, ";") {
+		if value, ok := strings.CutPrefix(field, name+"="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// MockSystem is the original MVP backend, kept for tests and for tools that
+// cannot do elliptic curve arithmetic. It hides nothing and proves nothing:
+// the witness is stated in the public input, and the proof ID only binds it
+// to the file and author. It is not registered by default, so that releases
+// cannot be downgraded to it unless the author asks for it.
+var MockSystem ProofSystem = mockSystem{}
+
+type mockSystem struct{}
+
+func (mockSystem) Name() string { return "mock" }
+func (mockSystem) Version() int { return 1 }
+func (mockSystem) Setup() error { return nil }
+
+// Prove states the witness and the claim it supports in the clear.
+func (mockSystem) Prove(w Witness, b Binding, key *btcec.PrivateKey) (*Proof, error) {
+	values := w.values()
+	binding := b.Digest()
+	publicInput := fmt.Sprintf("claim=%s;analyzer=%s;commits=%d;cyclomatic=%d;ratio=%d",
+		ratioClaim(values[ValueRatio]), w.Complexity.Analyzer, values[ValueCommits], values[ValueComplexity], values[ValueRatio])
 	return &Proof{
-		ProofID:     proofHash,
-		Timestamp:   time.Now().Unix(),
+		ProofID:     mockProofID(binding, publicInput),
 		PublicInput: publicInput,
+		Binding:     binding,
 	}, nil
 }
 
-// VerifyProof checks if a proof is valid (Mock verification).
-// Real ZKP would check the Groth16 proof here.
-func VerifyProof(p *Proof) bool {
-	// For MVP, just check if it has a valid hash structure
-	if len(p.ProofID) != 64 {
-		return false
+// Verify checks that the proof was made for b and that its claim follows
+// from the stated ratio.
+func (mockSystem) Verify(p *Proof, b Binding) error {
+	binding := b.Digest()
+	if p.Binding != binding {
+		return ErrBinding
+	}
+	if p.ProofID != mockProofID(binding, p.PublicInput) {
+		return errors.New("proof ID does not match the public input")
+	}
+	ratio, err := strconv.ParseUint(p.publicField(ValueRatio), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid ratio in public input %q", p.PublicInput)
 	}
-	return true
+	if claim := p.Claim(); claim != ratioClaim(ratio) {
+		return fmt.Errorf("claim %q does not hold for ratio %d", claim, ratio)
+	}
+	return nil
+}
+
+func mockProofID(binding, publicInput string) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "mock;binding=%s;%s", binding, publicInput))
+	return hex.EncodeToString(sum[:])
 }
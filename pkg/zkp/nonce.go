@@ -0,0 +1,55 @@
+package zkp
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// nonceVersion separates proof nonces from the RFC 6979 nonces of ECDSA
+// signatures made with the same key.
+var nonceVersion = []byte("HCP/zkp/nonce/v1")
+
+// nonces derives the secret scalars of a proof the way RFC 6979 derives
+// ECDSA nonces: HMAC-DRBG keyed by a secret and the hash of the statement,
+// with a label per scalar. Proving the same statement twice yields the same
+// proof, and different statements never share a nonce.
+type nonces struct {
+	secret [32]byte
+	msg    [32]byte
+}
+
+func newNonces(secret [32]byte, statement string) nonces {
+	return nonces{secret: secret, msg: sha256.Sum256([]byte(statement))}
+}
+
+// scalar returns the non-zero scalar for label.
+func (n nonces) scalar(label string) *scalar {
+	extra := sha256.Sum256([]byte(label))
+	return btcec.NonceRFC6979(n.secret[:], n.msg[:], extra[:], nonceVersion, 0)
+}
+
+// scalars returns count scalars for label.
+func (n nonces) scalars(label string, count int) []scalar {
+	out := make([]scalar, count)
+	for i := range out {
+		out[i] = *n.scalar(fmt.Sprintf("%s/%d", label, i))
+	}
+	return out
+}
+
+// witnessSecret hashes secret values and scalars into the key of a nonces.
+func witnessSecret(values []uint64, blindings []*scalar) [32]byte {
+	hasher := sha256.New()
+	for _, v := range values {
+		fmt.Fprintf(hasher, "%d;", v)
+	}
+	for _, r := range blindings {
+		b := r.Bytes()
+		hasher.Write(b[:])
+	}
+	var out [32]byte
+	hasher.Sum(out[:0])
+	return out
+}
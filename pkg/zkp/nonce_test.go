@@ -0,0 +1,54 @@
+package zkp
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/windgeek/HCP/pkg/aha"
+	"github.com/windgeek/HCP/pkg/cognitive"
+)
+
+func TestDeterministicProof(t *testing.T) {
+	stats := &cognitive.ComplexityStats{Cyclomatic: 12, Analyzer: "go-ast@1"}
+	prove := func(commits int, key *btcec.PrivateKey) *Proof {
+		t.Helper()
+		p, err := GenerateProof(DefaultSystem, Witness{aha.AHAMetrics{Commits: commits}, stats}, testBinding, key, 1700000000)
+		if err != nil {
+			t.Fatalf("GenerateProof failed: %v", err)
+		}
+		return p
+	}
+
+	// The same inputs give byte-identical proofs and openings
+	a, b := prove(3, testKey), prove(3, testKey)
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	if !bytes.Equal(ja, jb) {
+		t.Error("proofs of the same inputs differ")
+	}
+	if !reflect.DeepEqual(a.Opening, b.Opening) {
+		t.Error("openings of the same inputs differ")
+	}
+	if a.Timestamp != 1700000000 {
+		t.Errorf("timestamp = %d, want 1700000000", a.Timestamp)
+	}
+
+	// Another key blinds differently
+	otherKey, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{8}, 32))
+	if c := prove(3, otherKey); c.Commitments[ValueCommits] == a.Commitments[ValueCommits] {
+		t.Error("commitments under another key are equal")
+	}
+
+	// A changed witness changes every blinding factor, so the difference of
+	// two commitments to an unchanged value does not vanish
+	c := prove(4, testKey)
+	if c.Commitments[ValueComplexity] == a.Commitments[ValueComplexity] {
+		t.Error("commitments to the unchanged complexity are equal")
+	}
+	if err := VerifyProof(c, testBinding); err != nil {
+		t.Errorf("VerifyProof failed: %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package zkp
+
+import "fmt"
+
+// Opening holds the committed values of a proof and their blinding
+// factors. Authors keep it private and reveal single values with Disclose.
+type Opening struct {
+	Values   map[string]uint64 `json:"values"`
+	Blinding map[string]string `json:"blinding"` // Hex-encoded scalars
+}
+
+// Disclosure reveals one committed value of a file's proof to an auditor.
+type Disclosure struct {
+	Path     string `json:"path"`
+	Name     string `json:"name"`
+	Value    uint64 `json:"value"`
+	Blinding string `json:"blinding"`
+}
+
+// Disclose reveals the named value of the proof of path.
+func (o *Opening) Disclose(path, name string) (*Disclosure, error) {
+	value, ok := o.Values[name]
+	if !ok {
+		return nil, fmt.Errorf("no committed value %q", name)
+	}
+	return &Disclosure{Path: path, Name: name, Value: value, Blinding: o.Blinding[name]}, nil
+}
+
+// Check verifies the disclosed value against the public commitment in p.
+func (d *Disclosure) Check(p *Proof) error {
+	commitment, ok := p.Commitments[d.Name]
+	if !ok {
+		return fmt.Errorf("proof of %s has no commitment to %q", d.Path, d.Name)
+	}
+	return VerifyOpening(commitment, d.Value, d.Blinding)
+}
@@ -0,0 +1,110 @@
+package zkp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// ErrOpeningMismatch means a value and blinding factor do not open a
+// commitment.
+var ErrOpeningMismatch = errors.New("opening does not match commitment")
+
+// pedersenH is the second generator of the commitments. Its discrete log
+// with respect to G is unknown: it is the first point whose x coordinate is
+// SHA-256("HCP/pedersen/H" || counter).
+var pedersenH = deriveGenerator("HCP/pedersen/H")
+
+func deriveGenerator(seed string) btcec.JacobianPoint {
+	for counter := uint32(0); ; counter++ {
+		data := binary.BigEndian.AppendUint32([]byte(seed), counter)
+		sum := sha256.Sum256(data)
+		var x, y btcec.FieldVal
+		if overflow := x.SetByteSlice(sum[:]); overflow {
+			continue
+		}
+		if !btcec.DecompressY(&x, false, &y) {
+			continue
+		}
+		y.Normalize()
+		return btcec.MakeJacobianPoint(&x, &y, new(btcec.FieldVal).SetInt(1))
+	}
+}
+
+// NewBlinding returns a random, non-zero blinding factor.
+func NewBlinding() (*btcec.ModNScalar, error) {
+	var b [32]byte
+	for {
+		if _, err := rand.Read(b[:]); err != nil {
+			return nil, fmt.Errorf("failed to generate blinding factor: %w", err)
+		}
+		var k btcec.ModNScalar
+		if overflow := k.SetBytes(&b); overflow == 0 && !k.IsZero() {
+			return &k, nil
+		}
+	}
+}
+
+// Commit returns the Pedersen commitment value·G + blinding·H as a
+// hex-encoded compressed secp256k1 point. It hides value and binds the
+// committer to it.
+func Commit(value uint64, blinding *btcec.ModNScalar) (string, error) {
+	point := commitPoint(value, blinding)
+	return encodePoint(&point)
+}
+
+func commitPoint(value uint64, blinding *btcec.ModNScalar) btcec.JacobianPoint {
+	v := scalarUint64(value)
+	var vG, rH, c btcec.JacobianPoint
+	btcec.ScalarBaseMultNonConst(&v, &vG)
+	btcec.ScalarMultNonConst(blinding, &pedersenH, &rH)
+	btcec.AddNonConst(&vG, &rH, &c)
+	return c
+}
+
+// VerifyOpening checks that value and the hex-encoded blinding factor open
+// commitment.
+func VerifyOpening(commitment string, value uint64, blinding string) error {
+	r, err := decodeScalar(blinding)
+	if err != nil {
+		return err
+	}
+	got, err := Commit(value, r)
+	if err != nil {
+		return err
+	}
+	if got != commitment {
+		return ErrOpeningMismatch
+	}
+	return nil
+}
+
+func encodePoint(p *btcec.JacobianPoint) (string, error) {
+	p.ToAffine()
+	if (p.X.IsZero() && p.Y.IsZero()) || p.Z.IsZero() {
+		return "", errors.New("commitment is the point at infinity")
+	}
+	return hex.EncodeToString(btcec.NewPublicKey(&p.X, &p.Y).SerializeCompressed()), nil
+}
+
+func decodeScalar(s string) (*btcec.ModNScalar, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 32 {
+		return nil, fmt.Errorf("invalid scalar %q", s)
+	}
+	var k btcec.ModNScalar
+	if overflow := k.SetByteSlice(b); overflow {
+		return nil, fmt.Errorf("scalar %q exceeds the group order", s)
+	}
+	return &k, nil
+}
+
+func encodeScalar(k *btcec.ModNScalar) string {
+	b := k.Bytes()
+	return hex.EncodeToString(b[:])
+}
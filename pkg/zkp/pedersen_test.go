@@ -0,0 +1,82 @@
+package zkp
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/windgeek/HCP/pkg/aha"
+	"github.com/windgeek/HCP/pkg/cognitive"
+)
+
+func TestCommit(t *testing.T) {
+	r, err := NewBlinding()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Commit(42, r)
+	if err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if len(c) != 66 {
+		t.Errorf("commitment %q is not a compressed point", c)
+	}
+	if err := VerifyOpening(c, 42, encodeScalar(r)); err != nil {
+		t.Errorf("VerifyOpening failed: %v", err)
+	}
+	if err := VerifyOpening(c, 43, encodeScalar(r)); !errors.Is(err, ErrOpeningMismatch) {
+		t.Errorf("wrong value: got %v, want ErrOpeningMismatch", err)
+	}
+
+	// Hiding: the same value under another blinding factor commits differently
+	r2, _ := NewBlinding()
+	if c2, _ := Commit(42, r2); c2 == c {
+		t.Error("commitments with different blinding factors are equal")
+	}
+
+	// Homomorphic: C(a, r1) + C(b, r2) = C(a+b, r1+r2)
+	p1, p2 := commitPoint(3, r), commitPoint(4, r2)
+	var sum btcec.JacobianPoint
+	btcec.AddNonConst(&p1, &p2, &sum)
+	got, _ := encodePoint(&sum)
+	want, _ := Commit(7, new(btcec.ModNScalar).Add2(r, r2))
+	if got != want {
+		t.Error("commitments are not additively homomorphic")
+	}
+}
+
+func TestProofOpening(t *testing.T) {
+	stats := &cognitive.ComplexityStats{Cyclomatic: 12, Analyzer: "go-ast@1"}
+	p, err := GenerateProof(DefaultSystem, Witness{aha.AHAMetrics{Commits: 3}, stats}, testBinding, testKey, 0)
+	if err != nil {
+		t.Fatalf("GenerateProof failed: %v", err)
+	}
+	if err := VerifyProof(p, testBinding); err != nil {
+		t.Fatalf("VerifyProof rejected a fresh proof: %v", err)
+	}
+	// 12 / (3+1) = 3.000
+	if got := p.Opening.Values[ValueRatio]; got != 3000 {
+		t.Errorf("ratio = %d, want 3000", got)
+	}
+
+	for name := range p.Commitments {
+		d, err := p.Opening.Disclose("main.go", name)
+		if err != nil {
+			t.Fatalf("Disclose(%s) failed: %v", name, err)
+		}
+		if err := d.Check(p); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	d, _ := p.Opening.Disclose("main.go", ValueCommits)
+	d.Value = 30
+	if err := d.Check(p); !errors.Is(err, ErrOpeningMismatch) {
+		t.Errorf("forged disclosure: got %v, want ErrOpeningMismatch", err)
+	}
+
+	p.Commitments[ValueCommits] = p.Commitments[ValueComplexity]
+	if VerifyProof(p, testBinding) == nil {
+		t.Error("VerifyProof accepted commitments that do not match the proof ID")
+	}
+}
@@ -0,0 +1,121 @@
+package zkp
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+// ErrRelation means a leaf's human commitment does not commit to its weight
+// or to zero.
+var ErrRelation = errors.New("human commitment commits to neither the weight nor zero")
+
+// RelationProof shows that the human commitment Hc of a leaf commits either
+// to the same value as its weight commitment Wc or to zero, without
+// revealing which. Either Hc - Wc or Hc is a multiple s·H of the blinding
+// generator, and the proof is an OR of two Schnorr proofs of knowledge of s
+// (Cramer, Damgård and Schoenmakers, 1994): Ri = si·H - ei·Pi for P0 = Hc - Wc
+// and P1 = Hc, where e0 + e1 hashes the leaf and R0, R1. The prover
+// simulates the branch it cannot open.
+type RelationProof struct {
+	E0 string `json:"e0"`
+	E1 string `json:"e1"`
+	S0 string `json:"s0"`
+	S1 string `json:"s1"`
+}
+
+// proveRelation proves the relation of the leaf node, whose human
+// commitment is Wc + s·H if human is set and s·H otherwise.
+func proveRelation(node SumNode, human bool, s *scalar) (*RelationProof, error) {
+	P, err := relationPoints(node)
+	if err != nil {
+		return nil, err
+	}
+	known, simulated := 1, 0
+	if human {
+		known, simulated = 0, 1
+	}
+	nonce := newNonces(witnessSecret(nil, []*scalar{s}), "relation;leaf="+node.Hash)
+
+	// 1. Simulate the Other Branch
+	var e, z [2]scalar
+	e[simulated], z[simulated] = *nonce.scalar("e"), *nonce.scalar("s")
+	var R [2]point
+	R[simulated] = multiExp([]scalar{z[simulated], neg(&e[simulated])}, []point{pedersenH, P[simulated]})
+
+	// 2. Prove the Known Branch
+	k := nonce.scalar("k")
+	R[known] = mulPoint(k, &pedersenH)
+	challenge, err := relationChallenge(node, R)
+	if err != nil {
+		return nil, err
+	}
+	e[known] = sub(&challenge, &e[simulated])
+	es := mul(&e[known], s)
+	z[known] = add(k, &es)
+	return &RelationProof{E0: encodeScalar(&e[0]), E1: encodeScalar(&e[1]), S0: encodeScalar(&z[0]), S1: encodeScalar(&z[1])}, nil
+}
+
+// verifyRelation checks the relation proof of a leaf node.
+func verifyRelation(node SumNode) error {
+	if node.Relation == nil {
+		return fmt.Errorf("%w: leaf has no relation proof", ErrRelation)
+	}
+	P, err := relationPoints(node)
+	if err != nil {
+		return err
+	}
+	var e, z [2]scalar
+	for i, s := range []string{node.Relation.E0, node.Relation.E1, node.Relation.S0, node.Relation.S1} {
+		k, err := decodeScalar(s)
+		if err != nil {
+			return err
+		}
+		if i < 2 {
+			e[i] = *k
+		} else {
+			z[i-2] = *k
+		}
+	}
+	var R [2]point
+	for i := range R {
+		R[i] = multiExp([]scalar{z[i], neg(&e[i])}, []point{pedersenH, P[i]})
+	}
+	challenge, err := relationChallenge(node, R)
+	if err != nil {
+		return err
+	}
+	if sum := add(&e[0], &e[1]); !sum.Equals(&challenge) {
+		return ErrRelation
+	}
+	return nil
+}
+
+// relationPoints returns Hc - Wc and Hc of a leaf node.
+func relationPoints(node SumNode) ([2]point, error) {
+	W, err := decodePoint(node.Weight)
+	if err != nil {
+		return [2]point{}, err
+	}
+	H, err := decodePoint(node.Human)
+	if err != nil {
+		return [2]point{}, err
+	}
+	return [2]point{multiExp([]scalar{scalarInt(1), scalarInt(-1)}, []point{H, W}), H}, nil
+}
+
+// relationChallenge hashes the leaf, which covers its commitments, and the
+// commitments of both branches.
+func relationChallenge(node SumNode, R [2]point) (scalar, error) {
+	var enc [2]string
+	for i := range R {
+		var err error
+		if enc[i], err = encodePoint(&R[i]); err != nil {
+			return scalar{}, err
+		}
+	}
+	sum := sha256.Sum256(fmt.Appendf(nil, "HCP/aggregate/relation/v1;leaf=%s;r0=%s;r1=%s", node.Hash, enc[0], enc[1]))
+	var e scalar
+	e.SetByteSlice(sum[:])
+	return e, nil
+}
@@ -0,0 +1,141 @@
+package zkp
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// ErrUnknownSystem means a proof names a ProofSystem that is not registered.
+var ErrUnknownSystem = errors.New("unknown proof system")
+
+// ErrUnproven means a proof is consistent but made by MockSystem, which
+// proves nothing.
+var ErrUnproven = errors.New("proof system proves nothing")
+
+// ProofSystem is a backend that proves the cognitive work behind a file
+// (RFC-002 §3.3). Manifests record the backend of every proof in
+// Proof.System, and verifiers dispatch to the same backend.
+//
+// Third-party backends (e.g. Groth16 or PLONK circuits) register themselves
+// with RegisterSystem and keep their proof in Proof.Data.
+type ProofSystem interface {
+	// Name identifies the backend in manifests (e.g. "pedersen-bulletproofs").
+	Name() string
+	// Version must be bumped whenever the proof format or statement
+	// changes, so that verifiers keep dispatching old proofs to the old
+	// rules.
+	Version() int
+	// Setup prepares the public parameters (generators, proving and
+	// verifying keys). It is called before every Prove and Verify, so it
+	// must be cheap once done.
+	Setup() error
+	// Prove proves the claims w supports, bound to b. Any secret randomness
+	// must be derived from key and w so that releases are reproducible.
+	Prove(w Witness, b Binding, key *btcec.PrivateKey) (*Proof, error)
+	// Verify checks a proof made by Prove for b.
+	Verify(p *Proof, b Binding) error
+}
+
+var (
+	systemsMu sync.RWMutex
+	systems   = make(map[string]ProofSystem)
+)
+
+// DefaultSystem is the ProofSystem of hcp-release unless another is chosen.
+var DefaultSystem ProofSystem = commitmentSystem{}
+
+// RegisterSystem makes s available under SystemID(s), replacing any
+// previous registration of the same name and version.
+func RegisterSystem(s ProofSystem) {
+	systemsMu.Lock()
+	defer systemsMu.Unlock()
+	systems[SystemID(s)] = s
+}
+
+// LookupSystem returns the ProofSystem registered under id ("name@version").
+func LookupSystem(id string) (ProofSystem, bool) {
+	systemsMu.RLock()
+	defer systemsMu.RUnlock()
+	s, ok := systems[id]
+	return s, ok
+}
+
+// Systems returns the IDs of the registered backends, sorted.
+func Systems() []string {
+	systemsMu.RLock()
+	defer systemsMu.RUnlock()
+	ids := make([]string, 0, len(systems))
+	for id := range systems {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// SystemID formats a backend name and version as recorded in Proof.System.
+func SystemID(s ProofSystem) string {
+	return fmt.Sprintf("%s@%d", s.Name(), s.Version())
+}
+
+// GenerateProof proves the cognitive work behind a file with s:
+// 1. The user spent time (proxied by AHA Commits)
+// 2. The code has complexity (Cognitive Load)
+// 3. The ratio is "Human"
+//
+// The proof is bound to b: it only verifies for the same file content and
+// author key. timestamp is recorded as is (see manifest.ReleaseTime).
+func GenerateProof(s ProofSystem, w Witness, b Binding, key *btcec.PrivateKey, timestamp int64) (*Proof, error) {
+	id := SystemID(s)
+	if err := s.Setup(); err != nil {
+		return nil, fmt.Errorf("failed to set up %s: %w", id, err)
+	}
+	p, err := s.Prove(w, b, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", id, err)
+	}
+	p.System = id
+	p.Timestamp = timestamp
+	return p, nil
+}
+
+// VerifyProof checks a proof made for b with the backend it names. Proofs
+// of MockSystem are checked whether it is registered or not, and yield
+// ErrUnproven when consistent. So do legacy proofs, which predate proof
+// systems: a bare hash of the witness without System or commitments.
+func VerifyProof(p *Proof, b Binding) error {
+	if p.System == "" {
+		if len(p.Commitments) > 0 || p.RangeProof != nil || p.Binding != "" || p.Data != nil {
+			return fmt.Errorf("%w: proof names no system", ErrUnknownSystem)
+		}
+		if len(p.ProofID) != 64 {
+			return fmt.Errorf("malformed legacy proof id %q", p.ProofID)
+		}
+		return fmt.Errorf("%w: legacy proof without a proof system", ErrUnproven)
+	}
+	s, ok := LookupSystem(p.System)
+	mock := p.System == SystemID(MockSystem)
+	if mock {
+		s, ok = MockSystem, true
+	}
+	if !ok {
+		return fmt.Errorf("%w %q (registered: %v)", ErrUnknownSystem, p.System, Systems())
+	}
+	if err := s.Setup(); err != nil {
+		return fmt.Errorf("failed to set up %s: %w", SystemID(s), err)
+	}
+	if err := s.Verify(p, b); err != nil {
+		return err
+	}
+	if mock {
+		return fmt.Errorf("%w: %s", ErrUnproven, p.System)
+	}
+	return nil
+}
+
+func init() {
+	RegisterSystem(commitmentSystem{})
+}
@@ -0,0 +1,98 @@
+package zkp
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/windgeek/HCP/pkg/aha"
+	"github.com/windgeek/HCP/pkg/cognitive"
+)
+
+// circuitSystem stands in for a third-party circuit backend that keeps its
+// proof in Proof.Data.
+type circuitSystem struct{ setups *int }
+
+func (circuitSystem) Name() string   { return "circuit" }
+func (circuitSystem) Version() int   { return 2 }
+func (s circuitSystem) Setup() error { *s.setups++; return nil }
+func (circuitSystem) Prove(w Witness, b Binding, key *btcec.PrivateKey) (*Proof, error) {
+	data, _ := json.Marshal(map[string]int{"commits": w.Metrics.Commits})
+	return &Proof{ProofID: "circuit", PublicInput: "claim=" + ClaimNone, Binding: b.Digest(), Data: data}, nil
+}
+func (circuitSystem) Verify(p *Proof, b Binding) error {
+	if p.Binding != b.Digest() {
+		return ErrBinding
+	}
+	return nil
+}
+
+func TestProofSystems(t *testing.T) {
+	setups := 0
+	RegisterSystem(circuitSystem{&setups})
+	s, ok := LookupSystem("circuit@2")
+	if !ok {
+		t.Fatal("registered system not found")
+	}
+	w := Witness{aha.AHAMetrics{Commits: 3}, &cognitive.ComplexityStats{Cyclomatic: 12}}
+
+	// Proofs record their backend and verify with it
+	p, err := GenerateProof(s, w, testBinding, testKey, 0)
+	if err != nil {
+		t.Fatalf("GenerateProof failed: %v", err)
+	}
+	if p.System != "circuit@2" || len(p.Data) == 0 {
+		t.Errorf("proof system %q, data %s", p.System, p.Data)
+	}
+	if err := VerifyProof(p, testBinding); err != nil {
+		t.Errorf("VerifyProof failed: %v", err)
+	}
+	if setups != 2 {
+		t.Errorf("Setup called %d times, want 2", setups)
+	}
+	p.System = "circuit@3"
+	if err := VerifyProof(p, testBinding); !errors.Is(err, ErrUnknownSystem) {
+		t.Errorf("unknown system: got %v, want ErrUnknownSystem", err)
+	}
+
+	// Proofs without a backend predate the registry
+	p, _ = GenerateProof(DefaultSystem, w, testBinding, testKey, 0)
+	if p.System != "pedersen-bulletproofs@1" {
+		t.Errorf("default system %q", p.System)
+	}
+	p.System = ""
+	if err := VerifyProof(p, testBinding); !errors.Is(err, ErrUnknownSystem) {
+		t.Errorf("commitments without a system: got %v, want ErrUnknownSystem", err)
+	}
+
+	// A proof of the hash-commitment prover that predates proof systems
+	legacy := &Proof{ProofID: strings.Repeat("ab", 32), Timestamp: 1, PublicInput: "cyclomatic=3;commits=1"}
+	if err := VerifyProof(legacy, testBinding); !errors.Is(err, ErrUnproven) {
+		t.Errorf("legacy proof: got %v, want ErrUnproven", err)
+	}
+}
+
+func TestMockSystem(t *testing.T) {
+	if _, ok := LookupSystem("mock@1"); ok {
+		t.Fatal("mock system is registered by default")
+	}
+	p, err := GenerateProof(MockSystem, Witness{aha.AHAMetrics{Commits: 3}, &cognitive.ComplexityStats{Cyclomatic: 12}}, testBinding, testKey, 0)
+	if err != nil {
+		t.Fatalf("GenerateProof failed: %v", err)
+	}
+	if p.Claim() != ClaimHumanRatio || !strings.Contains(p.PublicInput, "ratio=3000") {
+		t.Errorf("public input %q", p.PublicInput)
+	}
+	if err := VerifyProof(p, testBinding); !errors.Is(err, ErrUnproven) {
+		t.Fatalf("VerifyProof = %v, want ErrUnproven", err)
+	}
+
+	// A claim the stated ratio does not support
+	p.PublicInput = strings.Replace(p.PublicInput, "ratio=3000", "ratio=30000", 1)
+	p.ProofID = mockProofID(p.Binding, p.PublicInput)
+	if err := VerifyProof(p, testBinding); err == nil || errors.Is(err, ErrUnproven) {
+		t.Errorf("VerifyProof accepted a claim the ratio does not support: %v", err)
+	}
+}